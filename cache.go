@@ -2,6 +2,7 @@ package gocache
 
 import (
 	"errors"
+	"io"
 	"time"
 
 	"github.com/alejandro-carstens/gocache/encoder"
@@ -19,6 +20,12 @@ func New(config config, encoder encoder.Encoder) (Cache, error) {
 		return NewRedisStore(config.(*RedisConfig), encoder)
 	case *MemcacheConfig:
 		return NewMemcacheStore(config.(*MemcacheConfig), encoder)
+	case *DatabaseConfig:
+		return NewDatabaseStore(config.(*DatabaseConfig), encoder)
+	case *FileConfig:
+		return NewFileStore(config.(*FileConfig), encoder)
+	case *NullConfig:
+		return NewNullStore(config.(*NullConfig), encoder)
 	}
 
 	return nil, errors.New("invalid or empty config specified")
@@ -58,6 +65,14 @@ type (
 		GetUint64(key string) (uint64, error)
 		// GetBool gets a bool value from the store
 		GetBool(key string) (bool, error)
+		// GetTime gets a time.Time value from the store
+		GetTime(key string) (time.Time, error)
+		// GetDuration gets a time.Duration value from the store
+		GetDuration(key string) (time.Duration, error)
+		// PutBytes puts raw bytes in the given store for a predetermined amount of time bypassing the encoder
+		PutBytes(key string, value []byte, duration time.Duration) error
+		// GetBytes gets the raw bytes stored for the given key bypassing the encoder
+		GetBytes(key string) ([]byte, error)
 		// Prefix gets the cache key prefix
 		Prefix() string
 		// Many gets many values from the store
@@ -90,6 +105,20 @@ type (
 		store
 		// TagSet returns the underlying tagged cache tag set
 		TagSet() *TagSet
+		// Keys returns about count of the keys written under the tag set starting at the given cursor, an empty
+		// cursor starting from the beginning, along with the cursor of the following page, which is empty once
+		// every key has been returned. Only RedisStore, MemcacheStore and LocalStore are able to enumerate them
+		Keys(cursor string, count int) ([]string, string, error)
+	}
+	// Streamer represents the methods implemented by the stores able to stream values, i.e. RedisStore,
+	// MemcacheStore and LocalStore
+	Streamer interface {
+		// PutStream puts the contents of r in the given store for a predetermined amount of time without
+		// buffering them as a whole in memory. The stream is compressed if the store's encoder is an
+		// encoder.Compressed
+		PutStream(key string, r io.Reader, duration time.Duration) error
+		// GetStream writes the value stored for the given key to w decompressing it if needed
+		GetStream(key string, w io.Writer) error
 	}
 	lock interface {
 		// Acquire is responsible for acquiring a lock
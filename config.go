@@ -3,8 +3,10 @@ package gocache
 import (
 	"context"
 	"crypto/tls"
+	"database/sql"
 	"errors"
 	"net"
+	"os"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,6 +16,9 @@ var (
 	_ config = &RedisConfig{}
 	_ config = &MemcacheConfig{}
 	_ config = &LocalConfig{}
+	_ config = &DatabaseConfig{}
+	_ config = &FileConfig{}
+	_ config = &NullConfig{}
 )
 
 type (
@@ -27,6 +32,14 @@ type (
 	RedisConfig struct {
 		// The value to be appended to every cache entry
 		Prefix string
+		// ConnectionPrefix is prepended to every key ahead of Prefix. It mirrors the prefix option of Laravel's
+		// redis connections, which unlike the cache prefix is not part of the keys recorded by Laravel's tag
+		// references. Laravel's cache prefix, e.g. "laravel_cache:", then goes into Prefix
+		ConnectionPrefix string
+		// LaravelCompatible lays out tagged entries, tag references, locks and rate limiter keys as Laravel does,
+		// which combined with encoder.PHPSerialize allows for a Laravel application sharing the same redis
+		// database to read entries written by the cache, flush its tags and honor its locks and vice versa
+		LaravelCompatible bool
 		// The network type, either tcp or unix.
 		// Default is tcp.
 		Network string
@@ -123,7 +136,102 @@ type (
 		MaxIdleConns int
 		// Servers list to be used by the c. Each server is weighted the same
 		Servers []string
+		// WeightedServers list to be used by the c in addition to Servers. Each server
+		// is given a share of the keys proportional to its weight
+		WeightedServers []MemcacheServer
+		// Distribution determines how keys are mapped to servers.
+		// Default is MemcacheDistributionModula.
+		Distribution MemcacheDistribution
+		// FailureLimit is the number of consecutive connection failures after which a
+		// server is ejected from the pool. Its keys are redistributed amongst the
+		// remaining servers until RetryTimeout elapses.
+		// Default is 0 which disables auto-ejection.
+		FailureLimit int
+		// RetryTimeout is the amount of time an ejected server is kept out of the pool.
+		// Default is 2 seconds.
+		RetryTimeout time.Duration
+		// BatchConcurrency is the maximum number of concurrent requests issued per server
+		// when calling PutMany or ForgetMany.
+		// Default is 4.
+		BatchConcurrency int
+		// ChunkSize is the size in bytes above which values are split across several
+		// items, given that memcached rejects items larger than its slab limit (1MB by
+		// default). A negative value disables chunking.
+		// Default is 1MB minus 1KB.
+		ChunkSize int
+		// TagIndexLimit is the maximum number of keys recorded per tag in order for tagged
+		// Flush calls to evict the underlying entries, the oldest keys being dropped
+		// beyond it. A negative value disables the index.
+		// Default is 10000.
+		TagIndexLimit int
+		// TagIndexBuckets is the number of items each tag index is spread across, keys being
+		// assigned to a bucket by hash, so that concurrent tagged writes rarely contend for
+		// the same item. The TagIndexLimit is split evenly between the buckets.
+		// Default is 16.
+		TagIndexBuckets int
 	}
+	// DatabaseConfig represents the configuration for a cache with a database/sql backend
+	DatabaseConfig struct {
+		// The value to be appended to every cache entry
+		Prefix string
+		// DB is an open database handle. If nil, a handle will be opened using
+		// DriverName and DataSourceName and closed when the store is closed.
+		DB *sql.DB
+		// DriverName is the registered database/sql driver name, e.g. sqlite3,
+		// postgres or mysql. Only used when DB is nil.
+		DriverName string
+		// DataSourceName is the driver specific data source name. Only used when DB is nil.
+		DataSourceName string
+		// Dialect is the SQL dialect spoken by the database.
+		// Default is inferred from DriverName.
+		Dialect DatabaseDialect
+		// Table is the name of the table used to store cache entries.
+		// Default is cache.
+		Table string
+		// LockTable is the name of the table used to store locks.
+		// Default is cache_locks.
+		LockTable string
+		// PruneProbability is the probability with which a write triggers a sweep
+		// of expired entries and locks. A negative value disables sweeping, expired
+		// entries are still deleted when read.
+		// Default is 0.02.
+		PruneProbability float64
+		// AutoMigrate creates the cache and lock tables if they do not exist
+		AutoMigrate bool
+	}
+	// FileConfig represents the configuration for a cache with a filesystem backend
+	FileConfig struct {
+		// The value to be appended to every cache entry
+		Prefix string
+		// Directory is the directory where cache entries and locks are stored.
+		// It will be created if it does not exist
+		Directory string
+		// FileMode is the permission mode for cache files.
+		// Default is 0644.
+		FileMode os.FileMode
+		// DirMode is the permission mode for cache directories.
+		// Default is 0755.
+		DirMode os.FileMode
+		// PruneInterval is the interval at which expired entries are removed from
+		// the filesystem in the background. Prune can also be called on demand.
+		// Default is 0 which disables background pruning.
+		PruneInterval time.Duration
+	}
+	// NullConfig represents the configuration for a cache that does not store anything
+	NullConfig struct {
+		// The value to be appended to every cache entry
+		Prefix string
+	}
+	// MemcacheServer represents a weighted memcache server
+	MemcacheServer struct {
+		// host:port address or unix socket path
+		Addr string
+		// Weight of the server relative to the other servers.
+		// Default is 1.
+		Weight int
+	}
+	// MemcacheDistribution represents the strategy used to map keys to memcache servers
+	MemcacheDistribution int
 	// LocalConfig represents the configuration for a cache with a map backend
 	LocalConfig struct {
 		// The value to be appended to every cache entry
@@ -132,10 +240,31 @@ type (
 		DefaultInterval time.Duration
 		// DefaultExpiration is the default local store cache entry expiration time
 		DefaultExpiration time.Duration
+		// MaxEntries is the maximum number of entries held by the local store. Once reached,
+		// expired entries and then the entries closest to expiring are evicted to make room.
+		// Default is 0 which means no limit.
+		MaxEntries int
 	}
 )
 
-func (*LocalConfig) validate() error {
+const (
+	// MemcacheDistributionModula maps keys to servers via crc32 modulo the total weight of the servers. This is
+	// the gomemcache default, adding or removing a server remaps almost every key
+	MemcacheDistributionModula MemcacheDistribution = iota
+	// MemcacheDistributionKetama maps keys to servers via a ketama consistent hash ring compatible with
+	// libmemcached and the PHP memcached extension. Adding or removing a server only remaps its own keys
+	MemcacheDistributionKetama
+)
+
+func (c *LocalConfig) validate() error {
+	if c.MaxEntries < 0 {
+		return errors.New("local max entries cannot be negative")
+	}
+
+	return nil
+}
+
+func (*NullConfig) validate() error {
 	return nil
 }
 
@@ -147,10 +276,40 @@ func (c *RedisConfig) validate() error {
 	return nil
 }
 
+func (c *DatabaseConfig) validate() error {
+	if c.DB == nil && (len(c.DriverName) == 0 || len(c.DataSourceName) == 0) {
+		return errors.New("either a database handle or a driver name and data source name need to be specified")
+	}
+
+	dialect := c.Dialect
+	if len(dialect) == 0 {
+		dialect = dialectFromDriver(c.DriverName)
+	}
+	if !dialect.valid() {
+		return errors.New("a valid database dialect needs to be specified")
+	}
+	if c.PruneProbability > 1 {
+		return errors.New("database prune probability cannot be greater than 1")
+	}
+
+	return nil
+}
+
+func (c *FileConfig) validate() error {
+	if len(c.Directory) == 0 {
+		return errors.New("a file cache directory needs to be specified")
+	}
+
+	return nil
+}
+
 func (c *MemcacheConfig) validate() error {
-	if len(c.Servers) == 0 {
+	if len(c.Servers) == 0 && len(c.WeightedServers) == 0 {
 		return errors.New("memcache.servers cannot be empty")
 	}
+	if c.Distribution != MemcacheDistributionModula && c.Distribution != MemcacheDistributionKetama {
+		return errors.New("invalid memcache distribution")
+	}
 
 	return nil
 }
@@ -0,0 +1,93 @@
+package gocache_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alejandro-carstens/gocache"
+	"github.com/alejandro-carstens/gocache/encoder"
+	"github.com/alejandro-carstens/gocache/gocachetest"
+	"github.com/alejandro-carstens/gocache/gocachetest/testserver"
+)
+
+func TestConformance(t *testing.T) {
+	factories := map[string]func(t *testing.T) gocache.Cache{
+		"redis": func(t *testing.T) gocache.Cache {
+			cache, err := gocache.NewRedisStore(&gocache.RedisConfig{
+				Prefix: "golavel:",
+				Addr:   testserver.RunRedis(t).Addr(),
+			}, encoder.JSON{})
+			require.NoError(t, err)
+
+			return cache
+		},
+		"memcache": func(t *testing.T) gocache.Cache {
+			cache, err := gocache.NewMemcacheStore(&gocache.MemcacheConfig{
+				Prefix:  "golavel:",
+				Servers: []string{testserver.RunMemcache(t).Addr()},
+			}, encoder.JSON{})
+			require.NoError(t, err)
+
+			return cache
+		},
+		"local": func(t *testing.T) gocache.Cache {
+			cache, err := gocache.NewLocalStore(&gocache.LocalConfig{Prefix: "golavel:"}, encoder.JSON{})
+			require.NoError(t, err)
+
+			return cache
+		},
+		"sharded": func(t *testing.T) gocache.Cache {
+			var shards []gocache.Shard
+			for _, name := range []string{"shard-1", "shard-2", "shard-3"} {
+				store, err := gocache.NewLocalStore(&gocache.LocalConfig{Prefix: "golavel:"}, encoder.JSON{})
+				require.NoError(t, err)
+
+				shards = append(shards, gocache.Shard{Name: name, Cache: store})
+			}
+
+			cache, err := gocache.NewShardedStore(shards...)
+			require.NoError(t, err)
+
+			return cache
+		},
+		"database": func(t *testing.T) gocache.Cache {
+			db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db")+"?_busy_timeout=5000&_journal_mode=WAL&_synchronous=NORMAL")
+			require.NoError(t, err)
+			// SQLite only allows for one writer at a time
+			db.SetMaxOpenConns(1)
+			t.Cleanup(func() {
+				require.NoError(t, db.Close())
+			})
+
+			cache, err := gocache.NewDatabaseStore(&gocache.DatabaseConfig{
+				Prefix:      "golavel:",
+				DB:          db,
+				Dialect:     gocache.DatabaseDialectSQLite,
+				AutoMigrate: true,
+			}, encoder.JSON{})
+			require.NoError(t, err)
+
+			return cache
+		},
+		"file": func(t *testing.T) gocache.Cache {
+			cache, err := gocache.NewFileStore(&gocache.FileConfig{
+				Prefix:    "golavel:",
+				Directory: t.TempDir(),
+			}, encoder.JSON{})
+			require.NoError(t, err)
+
+			return cache
+		},
+	}
+	for name, factory := range factories {
+		factory := factory
+		t.Run(name, func(t *testing.T) {
+			gocachetest.RunConformance(t, func() gocache.Cache {
+				return factory(t)
+			})
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package gocache
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// DatabaseDialectSQLite represents the SQLite (3.24+) SQL dialect
+	DatabaseDialectSQLite DatabaseDialect = "sqlite"
+	// DatabaseDialectPostgres represents the PostgreSQL (9.5+) SQL dialect
+	DatabaseDialectPostgres DatabaseDialect = "postgres"
+	// DatabaseDialectMySQL represents the MySQL/MariaDB SQL dialect
+	DatabaseDialectMySQL DatabaseDialect = "mysql"
+)
+
+// DatabaseDialect represents the SQL dialect spoken by the database backing a DatabaseStore
+type DatabaseDialect string
+
+// dialectFromDriver infers the dialect from the most common database/sql driver names
+func dialectFromDriver(driverName string) DatabaseDialect {
+	switch driverName {
+	case "sqlite", "sqlite3":
+		return DatabaseDialectSQLite
+	case "postgres", "pgx", "pq":
+		return DatabaseDialectPostgres
+	case "mysql":
+		return DatabaseDialectMySQL
+	}
+
+	return ""
+}
+
+func (d DatabaseDialect) valid() bool {
+	switch d {
+	case DatabaseDialectSQLite, DatabaseDialectPostgres, DatabaseDialectMySQL:
+		return true
+	}
+
+	return false
+}
+
+// rebind converts a query written with ? placeholders and double quoted identifiers to the dialect syntax
+func (d DatabaseDialect) rebind(query string) string {
+	switch d {
+	case DatabaseDialectMySQL:
+		return strings.ReplaceAll(query, `"`, "`")
+	case DatabaseDialectPostgres:
+		var (
+			b strings.Builder
+			n int
+		)
+		for _, r := range query {
+			if r != '?' {
+				b.WriteRune(r)
+
+				continue
+			}
+
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		}
+
+		return b.String()
+	}
+
+	return query
+}
+
+// schema returns the statements that create the cache and lock tables
+func (d DatabaseDialect) schema(table, lockTable string) []string {
+	var valueType = "BLOB"
+	switch d {
+	case DatabaseDialectPostgres:
+		valueType = "BYTEA"
+	case DatabaseDialectMySQL:
+		valueType = "LONGBLOB"
+	}
+
+	return []string{
+		d.rebind(`CREATE TABLE IF NOT EXISTS ` + table + ` ("key" VARCHAR(255) NOT NULL PRIMARY KEY, "value" ` +
+			valueType + ` NOT NULL, "expiration" BIGINT NOT NULL)`),
+		d.rebind(`CREATE TABLE IF NOT EXISTS ` + lockTable + ` ("key" VARCHAR(255) NOT NULL PRIMARY KEY, "owner" ` +
+			`VARCHAR(255) NOT NULL, "expiration" BIGINT NOT NULL)`),
+	}
+}
+
+// upsert returns a statement that inserts a row or overwrites the existing one
+func (d DatabaseDialect) upsert(table string) string {
+	if d == DatabaseDialectMySQL {
+		return d.rebind(`INSERT INTO ` + table + ` ("key", "value", "expiration") VALUES (?, ?, ?) ` +
+			`ON DUPLICATE KEY UPDATE "value" = VALUES("value"), "expiration" = VALUES("expiration")`)
+	}
+
+	return d.rebind(`INSERT INTO ` + table + ` ("key", "value", "expiration") VALUES (?, ?, ?) ` +
+		`ON CONFLICT ("key") DO UPDATE SET "value" = excluded."value", "expiration" = excluded."expiration"`)
+}
+
+// insertIfExpired returns a statement that inserts a row only if no row exists for the key or the existing row
+// has expired, along with its arguments. A single affected row means that the row was written
+func (d DatabaseDialect) insertIfExpired(table, column, key string, value interface{}, expiration, now int64) (string, []interface{}) {
+	if d == DatabaseDialectMySQL {
+		expired := `"expiration" <> 0 AND "expiration" <= ?`
+
+		return d.rebind(`INSERT INTO ` + table + ` ("key", "` + column + `", "expiration") VALUES (?, ?, ?) ` +
+				`ON DUPLICATE KEY UPDATE "` + column + `" = IF(` + expired + `, VALUES("` + column + `"), "` + column + `"), ` +
+				`"expiration" = IF(` + expired + `, VALUES("expiration"), "expiration")`),
+			[]interface{}{key, value, expiration, now, now}
+	}
+
+	return d.rebind(`INSERT INTO ` + table + ` ("key", "` + column + `", "expiration") VALUES (?, ?, ?) ` +
+			`ON CONFLICT ("key") DO UPDATE SET "` + column + `" = excluded."` + column + `", "expiration" = excluded."expiration" ` +
+			`WHERE ` + table + `."expiration" <> 0 AND ` + table + `."expiration" <= ?`),
+		[]interface{}{key, value, expiration, now}
+}
+
+// increment returns a statement that atomically adds delta to the integer stored for key along with its
+// arguments. Missing or expired rows are initialized to delta and set to never expire
+func (d DatabaseDialect) increment(table, key string, delta, now int64) (string, []interface{}) {
+	var initial = []byte(strconv.FormatInt(delta, 10))
+	switch d {
+	case DatabaseDialectMySQL:
+		expired := `"expiration" <> 0 AND "expiration" <= ?`
+
+		return d.rebind(`INSERT INTO ` + table + ` ("key", "value", "expiration") VALUES (?, ?, 0) ` +
+				`ON DUPLICATE KEY UPDATE "value" = IF(` + expired + `, VALUES("value"), ` +
+				`CAST(CAST("value" AS SIGNED) + ? AS BINARY)), "expiration" = IF(` + expired + `, 0, "expiration")`),
+			[]interface{}{key, initial, now, delta, now}
+	case DatabaseDialectPostgres:
+		expired := table + `."expiration" <> 0 AND ` + table + `."expiration" <= ?`
+
+		return d.rebind(`INSERT INTO ` + table + ` ("key", "value", "expiration") VALUES (?, ?, 0) ` +
+				`ON CONFLICT ("key") DO UPDATE SET "value" = CASE WHEN ` + expired + ` THEN excluded."value" ` +
+				`ELSE convert_to((convert_from(` + table + `."value", 'UTF8')::BIGINT + ?)::TEXT, 'UTF8') END, ` +
+				`"expiration" = CASE WHEN ` + expired + ` THEN 0 ELSE ` + table + `."expiration" END`),
+			[]interface{}{key, initial, now, delta, now}
+	}
+
+	expired := table + `."expiration" <> 0 AND ` + table + `."expiration" <= ?`
+
+	return d.rebind(`INSERT INTO ` + table + ` ("key", "value", "expiration") VALUES (?, ?, 0) ` +
+			`ON CONFLICT ("key") DO UPDATE SET "value" = CASE WHEN ` + expired + ` THEN excluded."value" ` +
+			`ELSE CAST(CAST(CAST(` + table + `."value" AS TEXT) AS INTEGER) + ? AS TEXT) END, ` +
+			`"expiration" = CASE WHEN ` + expired + ` THEN 0 ELSE ` + table + `."expiration" END`),
+		[]interface{}{key, initial, now, delta, now}
+}
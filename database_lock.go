@@ -0,0 +1,96 @@
+package gocache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var _ Lock = &databaseLock{}
+
+func newDatabaseLock(store *DatabaseStore, name, owner string, duration time.Duration) *databaseLock {
+	return (&databaseLock{
+		store:    store,
+		name:     name,
+		owner:    owner,
+		duration: duration,
+	}).initBaseLock()
+}
+
+type databaseLock struct {
+	baseLock
+	store    *DatabaseStore
+	name     string
+	owner    string
+	duration time.Duration
+}
+
+// Acquire implementation of the Lock interface
+func (dl *databaseLock) Acquire() (bool, error) {
+	query, args := dl.store.dialect.insertIfExpired(
+		dl.store.lockTable,
+		"owner",
+		dl.name,
+		dl.owner,
+		dl.store.expiration(dl.duration),
+		dl.store.now(),
+	)
+
+	return dl.store.affected(query, args...)
+}
+
+// Release implementation of the Lock interface
+func (dl *databaseLock) Release() (bool, error) {
+	return dl.store.affected(
+		dl.store.dialect.rebind(`DELETE FROM `+dl.store.lockTable+` WHERE "key" = ? AND "owner" = ?`),
+		dl.name,
+		dl.owner,
+	)
+}
+
+// ForceRelease implementation of the Lock interface
+func (dl *databaseLock) ForceRelease() error {
+	_, err := dl.store.affected(dl.store.dialect.rebind(`DELETE FROM `+dl.store.lockTable+` WHERE "key" = ?`), dl.name)
+
+	return err
+}
+
+// GetCurrentOwner implementation of the Lock interface
+func (dl *databaseLock) GetCurrentOwner() (string, error) {
+	var owner string
+	if err := dl.store.db.QueryRowContext(
+		context.TODO(),
+		dl.store.dialect.rebind(`SELECT "owner" FROM `+dl.store.lockTable+` WHERE "key" = ? AND ("expiration" = 0 OR "expiration" > ?)`),
+		dl.name,
+		dl.store.now(),
+	).Scan(&owner); errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return owner, nil
+}
+
+// Expire implementation of the Lock interface
+func (dl *databaseLock) Expire(duration time.Duration) (bool, error) {
+	if duration <= 0 {
+		return dl.Release()
+	}
+
+	return dl.store.affected(
+		dl.store.dialect.rebind(`UPDATE `+dl.store.lockTable+` SET "expiration" = ? `+
+			`WHERE "key" = ? AND "owner" = ? AND ("expiration" = 0 OR "expiration" > ?)`),
+		dl.store.expiration(duration),
+		dl.name,
+		dl.owner,
+		dl.store.now(),
+	)
+}
+
+func (dl *databaseLock) initBaseLock() *databaseLock {
+	dl.lock = dl
+
+	return dl
+}
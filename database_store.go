@@ -0,0 +1,636 @@
+package gocache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+)
+
+const (
+	defaultDatabaseTable            = "cache"
+	defaultDatabaseLockTable        = "cache_locks"
+	defaultDatabasePruneProbability = 0.02
+	databaseSelectLimit             = 500
+)
+
+var _ Cache = &DatabaseStore{}
+
+// NewDatabaseStore validates the passed in config and creates a Cache implementation of type *DatabaseStore
+func NewDatabaseStore(cnf *DatabaseConfig, encoder encoder.Encoder) (*DatabaseStore, error) {
+	if err := cnf.validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		db     = cnf.DB
+		ownsDB bool
+	)
+	if db == nil {
+		var err error
+		if db, err = sql.Open(cnf.DriverName, cnf.DataSourceName); err != nil {
+			return nil, err
+		}
+
+		ownsDB = true
+	}
+
+	dialect := cnf.Dialect
+	if len(dialect) == 0 {
+		dialect = dialectFromDriver(cnf.DriverName)
+	}
+
+	s := &DatabaseStore{
+		prefix: prefix{
+			val: cnf.Prefix,
+		},
+		db:               db,
+		ownsDB:           ownsDB,
+		dialect:          dialect,
+		table:            cnf.Table,
+		lockTable:        cnf.LockTable,
+		pruneProbability: cnf.PruneProbability,
+		encoder:          encoder,
+	}
+	if len(s.table) == 0 {
+		s.table = defaultDatabaseTable
+	}
+	if len(s.lockTable) == 0 {
+		s.lockTable = defaultDatabaseLockTable
+	}
+	if s.pruneProbability == 0 {
+		s.pruneProbability = defaultDatabasePruneProbability
+	}
+	if !cnf.AutoMigrate {
+		return s, nil
+	}
+
+	for _, statement := range dialect.schema(s.table, s.lockTable) {
+		if _, err := db.ExecContext(context.TODO(), statement); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// DatabaseStore is the representation of a database/sql caching store. Entries are stored in a cache table
+// and locks in a lock table. Expired entries are deleted when read and periodically swept on writes
+type DatabaseStore struct {
+	prefix
+	db               *sql.DB
+	ownsDB           bool
+	dialect          DatabaseDialect
+	table            string
+	lockTable        string
+	pruneProbability float64
+	encoder          encoder.Encoder
+}
+
+// GetFloat64 gets a float64 value from the store
+func (s *DatabaseStore) GetFloat64(key string) (float64, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
+	}
+
+	return stringToFloat64(value)
+}
+
+// GetFloat32 gets a float32 value from the store
+func (s *DatabaseStore) GetFloat32(key string) (float32, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
+	}
+
+	return stringToFloat32(value)
+}
+
+// GetInt64 gets an int64 value from the store
+func (s *DatabaseStore) GetInt64(key string) (int64, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
+	}
+
+	return stringToInt64(value)
+}
+
+// GetInt gets an int value from the store
+func (s *DatabaseStore) GetInt(key string) (int, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
+	}
+
+	return stringToInt(value)
+}
+
+// GetUint64 gets an uint64 value from the store
+func (s *DatabaseStore) GetUint64(key string) (uint64, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
+	}
+
+	return stringToUint64(value)
+}
+
+// GetBool gets a bool value from the store
+func (s *DatabaseStore) GetBool(key string) (bool, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return false, err
+	}
+
+	return boolValue(s.encoder, value)
+}
+
+// GetString gets a string value from the store
+func (s *DatabaseStore) GetString(key string) (string, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return "", err
+	}
+	res, err := stringValue(s.encoder, value)
+	if err != nil {
+		return "", err
+	}
+
+	reEncode(s.encoder, []byte(value), s.replacer(key))
+
+	return res, nil
+}
+
+// GetTime gets a time.Time value from the store
+func (s *DatabaseStore) GetTime(key string) (time.Time, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return timeValue(s.encoder, value)
+}
+
+// GetDuration gets a time.Duration value from the store
+func (s *DatabaseStore) GetDuration(key string) (time.Duration, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return durationValue(s.encoder, value)
+}
+
+// GetBytes gets the raw bytes stored for the given key bypassing the encoder
+func (s *DatabaseStore) GetBytes(key string) ([]byte, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(value), nil
+}
+
+// PutBytes puts raw bytes in the given store for a predetermined amount of time bypassing the encoder. A
+// duration lower or equal to 0 stores the value forever
+func (s *DatabaseStore) PutBytes(key string, value []byte, duration time.Duration) error {
+	if _, err := s.db.ExecContext(context.TODO(), s.dialect.upsert(s.table), s.k(key), value, s.expiration(duration)); err != nil {
+		return err
+	}
+
+	return s.lottery()
+}
+
+// Get gets the struct representation of a value from the store
+func (s *DatabaseStore) Get(key string, entity interface{}) error {
+	value, err := s.value(key)
+	if err != nil {
+		return err
+	}
+
+	data := []byte(value)
+	if err = decode(s.encoder, data, entity); err != nil {
+		return err
+	}
+
+	reEncode(s.encoder, data, s.replacer(key))
+
+	return nil
+}
+
+// Put puts a value in the given store for a predetermined amount of time in seconds. A duration lower or
+// equal to 0 stores the value forever
+func (s *DatabaseStore) Put(key string, value interface{}, duration time.Duration) error {
+	val, err := s.encode(value)
+	if err != nil {
+		return err
+	}
+	if _, err = s.db.ExecContext(context.TODO(), s.dialect.upsert(s.table), s.k(key), val, s.expiration(duration)); err != nil {
+		return err
+	}
+
+	return s.lottery()
+}
+
+// Add an item to the cache only if an item doesn't already exist for the given key, or if the existing item has
+// expired. If the record was successfully added true will be returned else false will be returned
+func (s *DatabaseStore) Add(key string, value interface{}, duration time.Duration) (bool, error) {
+	val, err := s.encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	query, args := s.dialect.insertIfExpired(s.table, "value", s.k(key), val, s.expiration(duration), s.now())
+
+	return s.affected(query, args...)
+}
+
+// Forever puts a value in the given store until it is forgotten/evicted
+func (s *DatabaseStore) Forever(key string, value interface{}) error {
+	return s.Put(key, value, 0)
+}
+
+// Increment increments an integer counter by a given value
+func (s *DatabaseStore) Increment(key string, value int64) (int64, error) {
+	if isEncrypted(s.encoder) {
+		return 0, ErrEncryptedCounter
+	}
+
+	tx, err := s.db.BeginTx(context.TODO(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	query, args := s.dialect.increment(s.table, s.k(key), value, s.now())
+	if _, err = tx.ExecContext(context.TODO(), query, args...); err != nil {
+		return 0, s.rollback(tx, err)
+	}
+
+	var res []byte
+	if err = tx.QueryRowContext(
+		context.TODO(),
+		s.dialect.rebind(`SELECT "value" FROM `+s.table+` WHERE "key" = ?`),
+		s.k(key),
+	).Scan(&res); err != nil {
+		return 0, s.rollback(tx, err)
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return stringToInt64(string(res))
+}
+
+// Decrement decrements an integer counter by a given value
+func (s *DatabaseStore) Decrement(key string, value int64) (int64, error) {
+	return s.Increment(key, -1*value)
+}
+
+// Forget forgets/evicts a given key-value pair from the store
+func (s *DatabaseStore) Forget(key string) (bool, error) {
+	return s.affected(s.dialect.rebind(`DELETE FROM `+s.table+` WHERE "key" = ?`), s.k(key))
+}
+
+// ForgetMany forgets/evicts a set of given key-value pair from the store
+func (s *DatabaseStore) ForgetMany(keys ...string) error {
+	for start := 0; start < len(keys); start += deleteLimit {
+		end := start + deleteLimit
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		var args = make([]interface{}, 0, end-start)
+		for _, key := range keys[start:end] {
+			args = append(args, s.k(key))
+		}
+
+		query := s.dialect.rebind(`DELETE FROM ` + s.table + ` WHERE "key" IN (` + placeholders(len(args)) + `)`)
+		if _, err := s.db.ExecContext(context.TODO(), query, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PutMany puts many values in the given store until they are forgotten/evicted
+func (s *DatabaseStore) PutMany(entries ...Entry) error {
+	tx, err := s.db.BeginTx(context.TODO(), nil)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		val, err := s.encode(entry.Value)
+		if err != nil {
+			return s.rollback(tx, err)
+		}
+		if _, err = tx.ExecContext(
+			context.TODO(),
+			s.dialect.upsert(s.table),
+			s.k(entry.Key),
+			val,
+			s.expiration(entry.Duration),
+		); err != nil {
+			return s.rollback(tx, err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return s.lottery()
+}
+
+// Many gets many values from the store
+func (s *DatabaseStore) Many(keys ...string) (Items, error) {
+	var (
+		items  = Items{}
+		values = map[string][]byte{}
+		now    = s.now()
+	)
+	for start := 0; start < len(keys); start += databaseSelectLimit {
+		end := start + databaseSelectLimit
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		var args = make([]interface{}, 0, end-start)
+		for _, key := range keys[start:end] {
+			args = append(args, s.k(key))
+		}
+
+		rows, err := s.db.QueryContext(
+			context.TODO(),
+			s.dialect.rebind(`SELECT "key", "value", "expiration" FROM `+s.table+` WHERE "key" IN (`+placeholders(len(args))+`)`),
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var (
+				key        string
+				value      []byte
+				expiration int64
+			)
+			if err = rows.Scan(&key, &value, &expiration); err != nil {
+				_ = rows.Close()
+
+				return nil, err
+			}
+			if expiration != 0 && expiration <= now {
+				continue
+			}
+
+			values[key] = value
+		}
+		if err = rows.Close(); err != nil {
+			return nil, err
+		}
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range keys {
+		value, exists := values[s.k(key)]
+		if !exists {
+			items[key] = Item{
+				key: key,
+				err: ErrNotFound,
+			}
+
+			continue
+		}
+
+		items[key] = Item{
+			key:     key,
+			value:   string(value),
+			encoder: s.encoder,
+		}
+	}
+
+	return items, nil
+}
+
+// Flush flushes the store
+func (s *DatabaseStore) Flush() (bool, error) {
+	if _, err := s.db.ExecContext(context.TODO(), `DELETE FROM `+s.table); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Prune deletes all the expired entries and locks from the database
+func (s *DatabaseStore) Prune() error {
+	now := s.now()
+	for _, table := range []string{s.table, s.lockTable} {
+		if _, err := s.db.ExecContext(
+			context.TODO(),
+			s.dialect.rebind(`DELETE FROM `+table+` WHERE "expiration" <> 0 AND "expiration" <= ?`),
+			now,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close closes the c releasing all open resources. The underlying *sql.DB is only closed if it was opened by
+// the store, i.e. if DatabaseConfig.DB was not provided
+func (s *DatabaseStore) Close() error {
+	if !s.ownsDB {
+		return nil
+	}
+
+	return s.db.Close()
+}
+
+// Tags returns the taggedCache for the given store
+func (s *DatabaseStore) Tags(names ...string) TaggedCache {
+	return &taggedCache{
+		store: s,
+		tags: &TagSet{
+			store: s,
+			names: names,
+		},
+	}
+}
+
+// Lock returns a database implementation of the Lock interface
+func (s *DatabaseStore) Lock(name, owner string, duration time.Duration) Lock {
+	return newDatabaseLock(s, name, owner, duration)
+}
+
+// Exists checks if an entry exists in the cache for the given key
+func (s *DatabaseStore) Exists(key string) (bool, error) {
+	_, err := s.value(key)
+	if err == nil {
+		return true, nil
+	} else if isErrNotFound(err) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// Expire implementation of the Cache interface. A duration lower or equal to 0 evicts the entry
+func (s *DatabaseStore) Expire(key string, duration time.Duration) error {
+	var (
+		updated bool
+		err     error
+	)
+	if duration <= 0 {
+		updated, err = s.affected(
+			s.dialect.rebind(`DELETE FROM `+s.table+` WHERE "key" = ? AND ("expiration" = 0 OR "expiration" > ?)`),
+			s.k(key),
+			s.now(),
+		)
+	} else {
+		updated, err = s.affected(
+			s.dialect.rebind(`UPDATE `+s.table+` SET "expiration" = ? WHERE "key" = ? AND ("expiration" = 0 OR "expiration" > ?)`),
+			s.expiration(duration),
+			s.k(key),
+			s.now(),
+		)
+	}
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// replacer returns a function replacing the value of an existing entry while keeping its expiration, as long as it
+// still holds the value that was read
+func (s *DatabaseStore) replacer(key string) func(current, upgraded []byte) error {
+	return func(current, upgraded []byte) error {
+		_, err := s.affected(
+			s.dialect.rebind(`UPDATE `+s.table+` SET "value" = ? WHERE "key" = ? AND "value" = ? AND ("expiration" = 0 OR "expiration" > ?)`),
+			upgraded,
+			s.k(key),
+			current,
+			s.now(),
+		)
+
+		return err
+	}
+}
+
+func (s *DatabaseStore) value(key string) (string, error) {
+	var (
+		value      []byte
+		expiration int64
+	)
+	if err := s.db.QueryRowContext(
+		context.TODO(),
+		s.dialect.rebind(`SELECT "value", "expiration" FROM `+s.table+` WHERE "key" = ?`),
+		s.k(key),
+	).Scan(&value, &expiration); errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	if expiration != 0 && expiration <= s.now() {
+		// Expired entries are lazily pruned when read
+		if _, err := s.db.ExecContext(
+			context.TODO(),
+			s.dialect.rebind(`DELETE FROM `+s.table+` WHERE "key" = ? AND "expiration" = ?`),
+			s.k(key),
+			expiration,
+		); err != nil {
+			return "", err
+		}
+
+		return "", ErrNotFound
+	}
+
+	return string(value), nil
+}
+
+func (s *DatabaseStore) encode(value interface{}) ([]byte, error) {
+	if storedAsIs(s.encoder, value) {
+		return []byte(fmt.Sprint(value)), nil
+	}
+
+	return encode(s.encoder, value)
+}
+
+func (s *DatabaseStore) affected(query string, args ...interface{}) (bool, error) {
+	res, err := s.db.ExecContext(context.TODO(), query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// lottery prunes expired entries with a probability of DatabaseConfig.PruneProbability
+func (s *DatabaseStore) lottery() error {
+	if s.pruneProbability < 0 || rand.Float64() >= s.pruneProbability {
+		return nil
+	}
+
+	return s.Prune()
+}
+
+func (*DatabaseStore) rollback(tx *sql.Tx, err error) error {
+	if rollbackErr := tx.Rollback(); rollbackErr != nil {
+		return fmt.Errorf("gocache: %v: %v", err.Error(), rollbackErr)
+	}
+
+	return err
+}
+
+// expiration returns the unix time in milliseconds at which an entry stored for duration expires, 0 meaning never
+func (*DatabaseStore) expiration(duration time.Duration) int64 {
+	if duration <= 0 {
+		return 0
+	}
+
+	return time.Now().Add(duration).UnixNano() / int64(time.Millisecond)
+}
+
+func (*DatabaseStore) now() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+
+	return strings.Repeat("?, ", n-1) + "?"
+}
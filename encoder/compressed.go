@@ -0,0 +1,179 @@
+package encoder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+var _ Encoder = Compressed{}
+
+const (
+	// Gzip compresses values via compress/gzip. It is the default Algorithm
+	Gzip Algorithm = iota + 1
+	// Zstd compresses values via Zstandard
+	Zstd
+	// Snappy compresses values via the Snappy block format
+	Snappy
+	// LZ4 compresses values via the LZ4 frame format
+	LZ4
+)
+
+// compressedMagic prefixes every value compressed by Compressed. It is followed by a byte identifying the
+// Algorithm. The leading zero byte cannot start a JSON document, which allows for legacy uncompressed values to be
+// told apart from compressed ones
+var compressedMagic = []byte{0x00, 'g', 'c', 'z'}
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+type (
+	// Algorithm represents a compression algorithm supported by Compressed
+	Algorithm uint8
+	// Compressed is an Encoder that wraps Inner compressing its output via Algorithm whenever it is at least MinSize
+	// bytes long. Compressed values are prefixed by a magic header identifying the algorithm, therefore values
+	// below MinSize, as well as values written before compression was enabled, are stored and decoded as is. This
+	// allows for compression to be rolled out without flushing the cache
+	Compressed struct {
+		// Inner is the encoder whose output gets compressed
+		Inner Encoder
+		// Algorithm is the compression algorithm used for new values. Default is Gzip
+		Algorithm Algorithm
+		// MinSize is the minimum encoded size in bytes for a value to be compressed. Default is 0 which means
+		// every value gets compressed
+		MinSize int
+	}
+)
+
+// String returns the name of the algorithm
+func (a Algorithm) String() string {
+	switch a {
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	case Snappy:
+		return "snappy"
+	case LZ4:
+		return "lz4"
+	}
+
+	return fmt.Sprintf("Algorithm(%d)", uint8(a))
+}
+
+// Encode implementation of the Encoder interface
+func (c Compressed) Encode(item interface{}) ([]byte, error) {
+	data, err := c.Inner.Encode(item)
+	if err != nil || len(data) < c.MinSize {
+		return data, err
+	}
+
+	algorithm := c.Algorithm
+	if algorithm == 0 {
+		algorithm = Gzip
+	}
+
+	var buf bytes.Buffer
+	buf.Write(compressedMagic)
+	buf.WriteByte(byte(algorithm))
+	if err = compress(&buf, algorithm, data); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decode implementation of the Encoder interface
+func (c Compressed) Decode(data []byte, dest interface{}) error {
+	if len(data) <= len(compressedMagic) || !bytes.HasPrefix(data, compressedMagic) {
+		return c.Inner.Decode(data, dest)
+	}
+
+	decompressed, err := decompress(Algorithm(data[len(compressedMagic)]), data[len(compressedMagic)+1:])
+	if err != nil {
+		return err
+	}
+
+	return c.Inner.Decode(decompressed, dest)
+}
+
+func compress(buf *bytes.Buffer, algorithm Algorithm, data []byte) error {
+	switch algorithm {
+	case Gzip:
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+
+		return w.Close()
+	case Zstd:
+		if err := initZstd(); err != nil {
+			return err
+		}
+
+		buf.Write(zstdEncoder.EncodeAll(data, nil))
+	case Snappy:
+		buf.Write(snappy.Encode(nil, data))
+	case LZ4:
+		w := lz4.NewWriter(buf)
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+
+		return w.Close()
+	default:
+		return fmt.Errorf("encoder: unsupported compression algorithm %s", algorithm)
+	}
+
+	return nil
+}
+
+func decompress(algorithm Algorithm, data []byte) ([]byte, error) {
+	switch algorithm {
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		defer r.Close()
+
+		return io.ReadAll(r)
+	case Zstd:
+		if err := initZstd(); err != nil {
+			return nil, err
+		}
+
+		return zstdDecoder.DecodeAll(data, nil)
+	case Snappy:
+		return snappy.Decode(nil, data)
+	case LZ4:
+		return io.ReadAll(lz4.NewReader(bytes.NewReader(data)))
+	}
+
+	return nil, fmt.Errorf("encoder: unsupported compression algorithm %s", algorithm)
+}
+
+// initZstd lazily creates the zstd encoder and decoder, both of which are safe for concurrent use via EncodeAll
+// and DecodeAll
+func initZstd() error {
+	zstdOnce.Do(func() {
+		if zstdEncoder, zstdErr = zstd.NewWriter(nil); zstdErr != nil {
+			return
+		}
+
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+
+	return zstdErr
+}
@@ -1,16 +1,25 @@
 package encoder
 
 import (
+	"bytes"
+	"encoding/gob"
 	"encoding/json"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/vmihailenco/msgpack/v5"
 )
 
 var (
 	_ Encoder = JSON{}
 	_ Encoder = Msgpack{}
+	_ Encoder = Gob{}
+	_ Encoder = CBOR{}
 )
 
+// cborEncMode encodes time.Time values as RFC 3339 strings with nanosecond precision rather than the default
+// integer unix time, which would truncate them to the second
+var cborEncMode, _ = cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+
 type (
 	// Encoder represents an interface that exposes functionality to encode and decode non-numeric or
 	// boolean cache entries
@@ -21,11 +30,23 @@ type (
 		// in the value pointed to by v.
 		Decode(data []byte, destination interface{}) error
 	}
+	// ReEncoder is implemented by encoders whose previously stored values may need to be upgraded, e.g. values
+	// encrypted with a rotated key. Stores supporting it replace such values upon read keeping their expiration
+	ReEncoder interface {
+		// ReEncode returns the upgraded version of data or nil if data does not need to be upgraded
+		ReEncode(data []byte) ([]byte, error)
+	}
 	// JSON is an Encoder implementation for the encoding/json package
 	JSON struct{}
 	// Msgpack is an Encoder implementation for the msgpack package. To learn
 	// more about msgpack please see: https://msgpack.uptrace.dev
 	Msgpack struct{}
+	// Gob is an Encoder implementation for the encoding/gob package. It allows for exact round-trips of Go types,
+	// including interface values whose concrete types have been registered via gob.Register
+	Gob struct{}
+	// CBOR is an Encoder implementation for the Concise Binary Object Representation (RFC 8949), a compact and
+	// cross-language binary format
+	CBOR struct{}
 )
 
 // Encode implementation of the Encoder interface
@@ -47,3 +68,28 @@ func (JSON) Encode(item interface{}) ([]byte, error) {
 func (JSON) Decode(data []byte, dest interface{}) error {
 	return json.Unmarshal(data, dest)
 }
+
+// Encode implementation of the Encoder interface
+func (Gob) Encode(item interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(item); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decode implementation of the Encoder interface
+func (Gob) Decode(data []byte, dest interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(dest)
+}
+
+// Encode implementation of the Encoder interface
+func (CBOR) Encode(item interface{}) ([]byte, error) {
+	return cborEncMode.Marshal(item)
+}
+
+// Decode implementation of the Encoder interface
+func (CBOR) Decode(data []byte, dest interface{}) error {
+	return cbor.Unmarshal(data, dest)
+}
@@ -1,14 +1,36 @@
 package encoder
 
 import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var encoders = map[string]Encoder{
 	"json":    JSON{},
 	"msgpack": Msgpack{},
+	"gob":     Gob{},
+	"cbor":    CBOR{},
+	"php":     PHPSerialize{},
+}
+
+type (
+	shape interface {
+		Area() float64
+	}
+	square struct {
+		Side float64
+	}
+)
+
+func (s square) Area() float64 {
+	return s.Side * s.Side
 }
 
 func TestEncode(t *testing.T) {
@@ -35,3 +57,341 @@ func TestEncode(t *testing.T) {
 		})
 	}
 }
+
+func TestGob_Interface(t *testing.T) {
+	gob.Register(square{})
+
+	type drawing struct {
+		Shapes []shape
+	}
+
+	b, err := Gob{}.Encode(drawing{Shapes: []shape{square{Side: 2}}})
+	require.NoError(t, err)
+
+	var res drawing
+	require.NoError(t, Gob{}.Decode(b, &res))
+	require.Equal(t, []shape{square{Side: 2}}, res.Shapes)
+	require.Equal(t, float64(4), res.Shapes[0].Area())
+}
+
+func TestCBOR_Format(t *testing.T) {
+	b, err := CBOR{}.Encode(map[string]int{"a": 1})
+	require.NoError(t, err)
+	// RFC 8949 example: a map of one pair, a text string of one byte and an unsigned integer
+	require.Equal(t, []byte{0xa1, 0x61, 'a', 0x01}, b)
+
+	var res map[string]int
+	require.NoError(t, CBOR{}.Decode(b, &res))
+	require.Equal(t, map[string]int{"a": 1}, res)
+}
+
+func TestProto(t *testing.T) {
+	e := Proto{Fallback: JSON{}}
+
+	b, err := e.Encode(timestamppb.New(time.Unix(1700000000, 5)))
+	require.NoError(t, err)
+
+	var ts timestamppb.Timestamp
+	require.NoError(t, e.Decode(b, &ts))
+	require.Equal(t, time.Unix(1700000000, 5).UTC(), ts.AsTime())
+
+	b, err = e.Encode("gocache")
+	require.NoError(t, err)
+
+	var s string
+	require.NoError(t, e.Decode(b, &s))
+	require.Equal(t, "gocache", s)
+
+	_, err = Proto{}.Encode("gocache")
+	require.ErrorIs(t, err, ErrNotProtoMessage)
+	require.ErrorIs(t, Proto{}.Decode(b, &s), ErrNotProtoMessage)
+}
+
+func TestCompressed(t *testing.T) {
+	var (
+		value  = map[string]string{"payload": strings.Repeat("gocache", 512)}
+		legacy []byte
+		err    error
+	)
+	legacy, err = JSON{}.Encode(value)
+	require.NoError(t, err)
+
+	for _, algorithm := range []Algorithm{0, Gzip, Zstd, Snappy, LZ4} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			e := Compressed{Inner: JSON{}, Algorithm: algorithm, MinSize: 1024}
+
+			b, err := e.Encode(value)
+			require.NoError(t, err)
+			require.Less(t, len(b), len(legacy))
+			require.Equal(t, compressedMagic, b[:len(compressedMagic)])
+
+			var res map[string]string
+			require.NoError(t, e.Decode(b, &res))
+			require.Equal(t, value, res)
+
+			res = nil
+			require.NoError(t, e.Decode(legacy, &res))
+			require.Equal(t, value, res)
+
+			b, err = e.Encode("small")
+			require.NoError(t, err)
+			require.Equal(t, `"small"`, string(b))
+
+			var s string
+			require.NoError(t, e.Decode(b, &s))
+			require.Equal(t, "small", s)
+		})
+	}
+
+	_, err = Compressed{Inner: JSON{}, Algorithm: 9}.Encode(value)
+	require.Error(t, err)
+	require.Error(t, Compressed{Inner: JSON{}}.Decode(append(append([]byte{}, compressedMagic...), 9, 1), &value))
+}
+
+func TestCompressStream(t *testing.T) {
+	var value = []byte(strings.Repeat("gocache", 4096))
+	for _, algorithm := range []Algorithm{0, Gzip, Zstd, Snappy, LZ4} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewCompressWriter(&buf, algorithm)
+			require.NoError(t, err)
+
+			_, err = w.Write(value)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			require.Less(t, buf.Len(), len(value))
+			require.Equal(t, streamMagic, buf.Bytes()[:len(streamMagic)])
+
+			r, err := NewDecompressReader(&buf)
+			require.NoError(t, err)
+
+			res, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			require.Equal(t, value, res)
+		})
+	}
+
+	r, err := NewDecompressReader(bytes.NewReader(value))
+	require.NoError(t, err)
+
+	res, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, value, res)
+
+	_, err = NewCompressWriter(io.Discard, 9)
+	require.Error(t, err)
+
+	_, err = NewDecompressReader(bytes.NewReader(append(append([]byte{}, streamMagic...), 9)))
+	require.Error(t, err)
+}
+
+func TestEncrypted(t *testing.T) {
+	var (
+		oldKey = Key{ID: "2023", Secret: bytes.Repeat([]byte{1}, 32)}
+		newKey = Key{ID: "2024", Secret: bytes.Repeat([]byte{2}, 32)}
+		old    = Encrypted{Inner: JSON{}, Keys: []Key{oldKey}}
+		e      = Encrypted{Inner: JSON{}, Keys: []Key{newKey, oldKey}}
+	)
+	b, err := old.Encode("secret")
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "secret")
+
+	var s string
+	require.NoError(t, e.Decode(b, &s))
+	require.Equal(t, "secret", s)
+
+	upgraded, err := e.ReEncode(b)
+	require.NoError(t, err)
+	require.Nil(t, upgraded)
+
+	e.ReEncrypt = true
+
+	upgraded, err = e.ReEncode(b)
+	require.NoError(t, err)
+	require.NotNil(t, upgraded)
+
+	upgraded, err = e.ReEncode(upgraded)
+	require.NoError(t, err)
+	require.Nil(t, upgraded)
+
+	var tamperErr *TamperError
+	require.ErrorAs(t, Encrypted{Inner: JSON{}, Keys: []Key{newKey}}.Decode(b, &s), &tamperErr)
+	require.Equal(t, "2023", tamperErr.KeyID)
+
+	tampered := append([]byte{}, b...)
+	tampered[len(tampered)-1] ^= 1
+	require.ErrorAs(t, e.Decode(tampered, &s), &tamperErr)
+
+	// Swapping the key ID in the header is detected as well
+	swapped := append([]byte{}, b...)
+	copy(swapped[2:], "2024")
+	require.ErrorAs(t, e.Decode(swapped, &s), &tamperErr)
+
+	require.ErrorAs(t, e.Decode(b[:10], &s), &tamperErr)
+	require.ErrorAs(t, e.Decode([]byte(`"plain"`), &s), &tamperErr)
+
+	_, err = Encrypted{Inner: JSON{}}.Encode("secret")
+	require.Error(t, err)
+	_, err = Encrypted{Inner: JSON{}, Keys: []Key{{ID: "short", Secret: []byte("short")}}}.Encode("secret")
+	require.Error(t, err)
+}
+
+func TestEnvelope(t *testing.T) {
+	e := Envelope{Inner: JSON{}}
+
+	b, err := e.Encode("123")
+	require.NoError(t, err)
+
+	header, payload, enveloped := OpenEnvelope(b)
+	require.True(t, enveloped)
+	require.Equal(t, EnvelopeHeader{Version: 1, Type: TypeString, EncoderID: 1}, header)
+	require.Equal(t, `"123"`, string(payload))
+
+	var s string
+	require.NoError(t, e.Decode(b, &s))
+	require.Equal(t, "123", s)
+
+	versioned, err := e.EncodeVersion("123", 300)
+	require.NoError(t, err)
+
+	header, payload, enveloped = OpenEnvelope(versioned)
+	require.True(t, enveloped)
+	require.Equal(t, EnvelopeHeader{Version: 1, Type: TypeString, EncoderID: 1, Flags: FlagSchemaVersion, SchemaVersion: 300}, header)
+	require.Equal(t, `"123"`, string(payload))
+	require.NoError(t, e.Decode(versioned, &s))
+	require.Equal(t, "123", s)
+
+	// Values written by a built-in encoder can be decoded after switching encoders
+	require.NoError(t, Envelope{Inner: Msgpack{}}.Decode(b, &s))
+	require.Equal(t, "123", s)
+
+	// Values written before the envelope was enabled are decoded as is
+	require.NoError(t, e.Decode([]byte(`"legacy"`), &s))
+	require.Equal(t, "legacy", s)
+
+	_, _, enveloped = OpenEnvelope([]byte(`"legacy"`))
+	require.False(t, enveloped)
+
+	type custom int
+	for item, expected := range map[interface{}]ValueType{
+		true:                TypeBool,
+		custom(1):           TypeInt,
+		uint8(1):            TypeUint,
+		1.5:                 TypeFloat,
+		&square{}:           TypeOther,
+		"string":            TypeString,
+		[2]string{"a", "b"}: TypeOther,
+	} {
+		b, err = e.Encode(item)
+		require.NoError(t, err)
+
+		header, _, _ = OpenEnvelope(b)
+		require.Equal(t, expected, header.Type, item)
+	}
+
+	b, err = e.Encode([]byte("bytes"))
+	require.NoError(t, err)
+
+	header, _, _ = OpenEnvelope(b)
+	require.Equal(t, TypeBytes, header.Type)
+
+	var (
+		key       = Key{ID: "key", Secret: bytes.Repeat([]byte{1}, 32)}
+		encrypted = Envelope{Inner: Encrypted{Inner: JSON{}, Keys: []Key{key}}}
+	)
+	b, err = encrypted.Encode("secret")
+	require.NoError(t, err)
+
+	encrypted.Inner = Encrypted{Inner: JSON{}, Keys: []Key{{ID: "new", Secret: bytes.Repeat([]byte{2}, 32)}, key}, ReEncrypt: true}
+
+	upgraded, err := encrypted.ReEncode(b)
+	require.NoError(t, err)
+
+	header, _, enveloped = OpenEnvelope(upgraded)
+	require.True(t, enveloped)
+	require.Equal(t, TypeString, header.Type)
+	require.NoError(t, encrypted.Decode(upgraded, &s))
+	require.Equal(t, "secret", s)
+}
+
+func TestPHPSerialize(t *testing.T) {
+	type (
+		address struct {
+			City string `php:"city"`
+		}
+		person struct {
+			Name    string   `php:"name"`
+			Age     int      `php:"age"`
+			Tags    []string `php:"tags"`
+			Address *address `php:"address"`
+			Nick    string   `php:"nick,omitempty"`
+			Ignored string   `php:"-"`
+		}
+	)
+
+	e := PHPSerialize{}
+	for item, expected := range map[interface{}]string{
+		nil:                   `N;`,
+		true:                  `b:1;`,
+		false:                 `b:0;`,
+		-42:                   `i:-42;`,
+		uint64(7):             `i:7;`,
+		1.0:                   `d:1;`,
+		0.1:                   `d:0.1;`,
+		-1.5:                  `d:-1.5;`,
+		1e25:                  `d:1.0E+25;`,
+		0.00001:               `d:1.0E-5;`,
+		1234.5678:             `d:1234.5678;`,
+		"héllo":               `s:6:"héllo";`,
+		[2]string{"a", "b"}:   `a:2:{i:0;s:1:"a";i:1;s:1:"b";}`,
+		time.Unix(0, 0).UTC(): `s:20:"1970-01-01T00:00:00Z";`,
+	} {
+		b, err := e.Encode(item)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(b), item)
+	}
+
+	b, err := e.Encode(map[string]interface{}{"b": []int{1}, "a": nil, "7": "seven"})
+	require.NoError(t, err)
+	require.Equal(t, `a:3:{i:7;s:5:"seven";s:1:"a";N;s:1:"b";a:1:{i:0;i:1;}}`, string(b))
+
+	b, err = e.Encode(&person{Name: "Ayrton", Age: 34, Tags: []string{"f1"}, Ignored: "x"})
+	require.NoError(t, err)
+	require.Equal(t, `O:8:"stdClass":4:{s:4:"name";s:6:"Ayrton";s:3:"age";i:34;s:4:"tags";a:1:{i:0;s:2:"f1";}s:7:"address";N;}`, string(b))
+
+	var p person
+	require.NoError(t, e.Decode(b, &p))
+	require.Equal(t, person{Name: "Ayrton", Age: 34, Tags: []string{"f1"}}, p)
+
+	// Arrays, objects of any class and private or protected properties, as written by PHP
+	data := `a:2:{s:4:"name";s:5:"Alain";s:7:"address";O:7:"Address":1:{s:13:"` + "\x00Address\x00city" + `";s:5:"Paris";}}`
+	require.NoError(t, e.Decode([]byte(data), &p))
+	require.Equal(t, "Alain", p.Name)
+	require.Equal(t, &address{City: "Paris"}, p.Address)
+
+	var v interface{}
+	require.NoError(t, e.Decode([]byte(`a:2:{i:0;s:1:"a";i:1;a:1:{s:1:"k";d:1.5;}}`), &v))
+	require.Equal(t, []interface{}{"a", map[string]interface{}{"k": 1.5}}, v)
+
+	require.NoError(t, e.Decode([]byte(`O:8:"stdClass":1:{s:1:"n";b:1;}`), &v))
+	require.Equal(t, map[string]interface{}{"n": true}, v)
+
+	// Laravel stores numbers as is
+	var n int
+	require.NoError(t, e.Decode([]byte("15"), &n))
+	require.Equal(t, 15, n)
+
+	var s string
+	require.NoError(t, e.Decode([]byte(`b:1;`), &s))
+	require.Equal(t, "1", s)
+
+	var tm time.Time
+	require.NoError(t, e.Decode([]byte(`s:20:"1970-01-01T00:00:00Z";`), &tm))
+	require.True(t, tm.Equal(time.Unix(0, 0)))
+
+	for _, invalid := range []string{``, `s:5:"abc";`, `i:abc;`, `a:1:{i:0;}`, `b:1;trailing`, `C:3:"Foo":0:{}`, `s:9223372036854775807:"x";`} {
+		require.Error(t, e.Decode([]byte(invalid), &v), invalid)
+	}
+	require.Error(t, e.Decode([]byte(`s:1:"a";`), &n))
+}
@@ -0,0 +1,176 @@
+package encoder
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+var (
+	_ Encoder   = Encrypted{}
+	_ ReEncoder = Encrypted{}
+)
+
+// encryptedVersion is the first byte of every payload produced by Encrypted. It is followed by the length of the
+// key ID, the key ID, the nonce and the sealed data
+const encryptedVersion byte = 1
+
+type (
+	// Key is an AES-256 key identified by ID within the keyring of an Encrypted encoder
+	Key struct {
+		// ID identifies the key within the keyring. It is embedded in every payload and can be up to 255 bytes long
+		ID string
+		// Secret is the 32 byte AES-256 key
+		Secret []byte
+	}
+	// Encrypted is an Encoder that wraps Inner encrypting its output via AES-256-GCM. New values are encrypted with
+	// the first key in Keys, while values can be decrypted with any key in Keys, which allows for keys to be rotated
+	// by prepending the new key and keeping the previous ones around until the values encrypted with them expire
+	Encrypted struct {
+		// Inner is the encoder whose output gets encrypted
+		Inner Encoder
+		// Keys is the keyring, the first key being used to encrypt new values
+		Keys []Key
+		// ReEncrypt makes the Redis, Local, Database and File stores re-encrypt, upon read, the values that were
+		// encrypted with a key other than the first one in Keys. Memcache does not expose the expiration of its
+		// items, therefore its values are only re-encrypted when written again
+		ReEncrypt bool
+	}
+	// TamperError is returned by Encrypted when a payload cannot be authenticated, either because it was modified
+	// or truncated, or because it was encrypted with a key that is not part of the keyring
+	TamperError struct {
+		// KeyID is the ID of the key the payload claims to be encrypted with
+		KeyID string
+		// Reason describes why the payload could not be authenticated
+		Reason string
+	}
+)
+
+// Error implementation of the error interface
+func (e *TamperError) Error() string {
+	if len(e.KeyID) == 0 {
+		return "encoder: tampered payload: " + e.Reason
+	}
+
+	return fmt.Sprintf("encoder: tampered payload encrypted with key %q: %s", e.KeyID, e.Reason)
+}
+
+// Encode implementation of the Encoder interface
+func (e Encrypted) Encode(item interface{}) ([]byte, error) {
+	data, err := e.Inner.Encode(item)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.seal(data)
+}
+
+// Decode implementation of the Encoder interface
+func (e Encrypted) Decode(data []byte, dest interface{}) error {
+	plaintext, _, err := e.open(data)
+	if err != nil {
+		return err
+	}
+
+	return e.Inner.Decode(plaintext, dest)
+}
+
+// ReEncode implementation of the ReEncoder interface. Values encrypted with a key other than the first one in Keys
+// are re-encrypted with the latter whenever ReEncrypt is set
+func (e Encrypted) ReEncode(data []byte) ([]byte, error) {
+	if !e.ReEncrypt || len(e.Keys) == 0 {
+		return nil, nil
+	}
+
+	plaintext, keyID, err := e.open(data)
+	if err != nil || keyID == e.Keys[0].ID {
+		return nil, err
+	}
+
+	return e.seal(plaintext)
+}
+
+func (e Encrypted) seal(plaintext []byte) ([]byte, error) {
+	if len(e.Keys) == 0 {
+		return nil, errors.New("encoder: no encryption keys have been specified")
+	}
+
+	key := e.Keys[0]
+	if len(key.ID) > 255 {
+		return nil, fmt.Errorf("encoder: encryption key ID %q exceeds 255 bytes", key.ID)
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var header = make([]byte, 0, 2+len(key.ID)+aead.NonceSize())
+	header = append(header, encryptedVersion, byte(len(key.ID)))
+	header = append(header, key.ID...)
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	// The header is authenticated as additional data so that the key ID cannot be swapped
+	return aead.Seal(append(header, nonce...), nonce, plaintext, header), nil
+}
+
+// open returns the decrypted payload along with the ID of the key it was encrypted with
+func (e Encrypted) open(data []byte) ([]byte, string, error) {
+	if len(data) < 2 || data[0] != encryptedVersion || len(data) < 2+int(data[1]) {
+		return nil, "", &TamperError{Reason: "invalid header"}
+	}
+
+	var (
+		headerSize = 2 + int(data[1])
+		keyID      = string(data[2:headerSize])
+		key        *Key
+	)
+	for i := range e.Keys {
+		if e.Keys[i].ID == keyID {
+			key = &e.Keys[i]
+
+			break
+		}
+	}
+	if key == nil {
+		return nil, keyID, &TamperError{KeyID: keyID, Reason: "unknown key"}
+	}
+
+	aead, err := newGCM(*key)
+	if err != nil {
+		return nil, keyID, err
+	}
+	if len(data) < headerSize+aead.NonceSize()+aead.Overhead() {
+		return nil, keyID, &TamperError{KeyID: keyID, Reason: "truncated payload"}
+	}
+
+	var (
+		header = data[:headerSize]
+		nonce  = data[headerSize : headerSize+aead.NonceSize()]
+	)
+	plaintext, err := aead.Open(nil, nonce, data[headerSize+aead.NonceSize():], header)
+	if err != nil {
+		return nil, keyID, &TamperError{KeyID: keyID, Reason: "message authentication failed"}
+	}
+
+	return plaintext, keyID, nil
+}
+
+func newGCM(key Key) (cipher.AEAD, error) {
+	if len(key.Secret) != 32 {
+		return nil, fmt.Errorf("encoder: encryption key %q must be 32 bytes long", key.ID)
+	}
+
+	block, err := aes.NewCipher(key.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,247 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+)
+
+var (
+	_ Encoder   = Envelope{}
+	_ ReEncoder = Envelope{}
+)
+
+const (
+	// TypeOther tags enveloped values that are neither strings, booleans, numbers nor byte slices, e.g. structs
+	TypeOther ValueType = iota
+	// TypeString tags enveloped strings
+	TypeString
+	// TypeBool tags enveloped booleans
+	TypeBool
+	// TypeInt tags enveloped signed integers
+	TypeInt
+	// TypeUint tags enveloped unsigned integers
+	TypeUint
+	// TypeFloat tags enveloped floating point numbers
+	TypeFloat
+	// TypeBytes tags enveloped byte slices
+	TypeBytes
+)
+
+// FlagSchemaVersion is set on enveloped values whose header is followed by the schema version of the value, see
+// Envelope.EncodeVersion
+const FlagSchemaVersion uint8 = 1 << 0
+
+// envelopeVersion is the version of the envelope layout written by Envelope
+const envelopeVersion byte = 1
+
+// envelopeMagic prefixes every value written by Envelope. It is followed by the envelope version, the type tag, the
+// encoder ID, the flags, the schema version as a uvarint if FlagSchemaVersion is set and the payload produced by the
+// encoder
+var envelopeMagic = []byte{0x00, 'g', 'e'}
+
+// envelopeHeaderSize is the size of the magic, version, type tag, encoder ID and flags
+var envelopeHeaderSize = len(envelopeMagic) + 4
+
+type (
+	// ValueType represents the type tag of an enveloped value
+	ValueType uint8
+	// EnvelopeHeader describes an enveloped value
+	EnvelopeHeader struct {
+		// Version is the version of the envelope layout
+		Version uint8
+		// Type is the type tag of the value
+		Type ValueType
+		// EncoderID identifies the encoder that produced the payload, 0 meaning unknown
+		EncoderID uint8
+		// Flags describes the features stored alongside the payload, e.g. FlagSchemaVersion
+		Flags uint8
+		// SchemaVersion is the schema version of the value if FlagSchemaVersion is set
+		SchemaVersion uint32
+	}
+	// Envelope is an Encoder that wraps the output of Inner in a self-describing, versioned envelope made of a type
+	// tag, an encoder ID, flags and the payload. Stores use the type tag to decode values deterministically, e.g. a
+	// string such as "123" is never mistaken for a number, and the encoder ID allows for values written by any of the
+	// built-in encoders to be decoded after Inner is changed. Values that are not enveloped, i.e. values written
+	// before the envelope was enabled, are decoded by Inner as is. Envelope needs to be the outermost encoder for
+	// stores to be able to read the type tag
+	Envelope struct {
+		// Inner is the encoder producing the enveloped payload
+		Inner Encoder
+	}
+)
+
+// Encode implementation of the Encoder interface
+func (e Envelope) Encode(item interface{}) ([]byte, error) {
+	payload, err := e.Inner.Encode(item)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.wrap(EnvelopeHeader{
+		Version:   envelopeVersion,
+		Type:      valueType(item),
+		EncoderID: encoderID(e.Inner),
+	}, payload), nil
+}
+
+// EncodeVersion encodes item storing the given schema version in the envelope
+func (e Envelope) EncodeVersion(item interface{}, version uint32) ([]byte, error) {
+	payload, err := e.Inner.Encode(item)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.wrap(EnvelopeHeader{
+		Version:       envelopeVersion,
+		Type:          valueType(item),
+		EncoderID:     encoderID(e.Inner),
+		Flags:         FlagSchemaVersion,
+		SchemaVersion: version,
+	}, payload), nil
+}
+
+// Decode implementation of the Encoder interface
+func (e Envelope) Decode(data []byte, dest interface{}) error {
+	header, payload, enveloped := OpenEnvelope(data)
+	if !enveloped {
+		return e.Inner.Decode(data, dest)
+	}
+	if header.Version > envelopeVersion {
+		return errors.New("encoder: unsupported envelope version")
+	}
+
+	return e.encoder(header.EncoderID).Decode(payload, dest)
+}
+
+// ReEncode implementation of the ReEncoder interface. The payload of enveloped values is handed to Inner whenever
+// the latter implements ReEncoder
+func (e Envelope) ReEncode(data []byte) ([]byte, error) {
+	reEncoder, valid := e.Inner.(ReEncoder)
+	if !valid {
+		return nil, nil
+	}
+
+	header, payload, enveloped := OpenEnvelope(data)
+	if !enveloped {
+		return reEncoder.ReEncode(data)
+	}
+	if header.EncoderID != encoderID(e.Inner) {
+		return nil, nil
+	}
+
+	upgraded, err := reEncoder.ReEncode(payload)
+	if err != nil || upgraded == nil {
+		return nil, err
+	}
+
+	return e.wrap(header, upgraded), nil
+}
+
+// OpenEnvelope returns the header and the payload of an enveloped value. The last return value reports whether
+// data is enveloped at all
+func OpenEnvelope(data []byte) (EnvelopeHeader, []byte, bool) {
+	if len(data) < envelopeHeaderSize || !bytes.HasPrefix(data, envelopeMagic) {
+		return EnvelopeHeader{}, nil, false
+	}
+
+	var (
+		raw    = data[len(envelopeMagic):envelopeHeaderSize]
+		header = EnvelopeHeader{
+			Version:   raw[0],
+			Type:      ValueType(raw[1]),
+			EncoderID: raw[2],
+			Flags:     raw[3],
+		}
+		payload = data[envelopeHeaderSize:]
+	)
+	if header.Flags&FlagSchemaVersion != 0 {
+		version, n := binary.Uvarint(payload)
+		if n <= 0 || version > uint64(^uint32(0)) {
+			return EnvelopeHeader{}, nil, false
+		}
+
+		header.SchemaVersion = uint32(version)
+		payload = payload[n:]
+	}
+
+	return header, payload, true
+}
+
+func (Envelope) wrap(header EnvelopeHeader, payload []byte) []byte {
+	var data = make([]byte, 0, envelopeHeaderSize+binary.MaxVarintLen32+len(payload))
+	data = append(data, envelopeMagic...)
+	data = append(data, header.Version, byte(header.Type), header.EncoderID, header.Flags)
+	if header.Flags&FlagSchemaVersion != 0 {
+		data = binary.AppendUvarint(data, uint64(header.SchemaVersion))
+	}
+
+	return append(data, payload...)
+}
+
+// encoder returns the built-in encoder identified by id falling back to Inner
+func (e Envelope) encoder(id uint8) Encoder {
+	if id == 0 || id == encoderID(e.Inner) {
+		return e.Inner
+	}
+
+	switch id {
+	case 1:
+		return JSON{}
+	case 2:
+		return Msgpack{}
+	case 3:
+		return Gob{}
+	case 4:
+		return CBOR{}
+	case 5:
+		return PHPSerialize{}
+	}
+
+	return e.Inner
+}
+
+// encoderID returns the envelope ID of the built-in encoders, 0 being used for any other encoder
+func encoderID(enc Encoder) uint8 {
+	switch enc.(type) {
+	case JSON, *JSON:
+		return 1
+	case Msgpack, *Msgpack:
+		return 2
+	case Gob, *Gob:
+		return 3
+	case CBOR, *CBOR:
+		return 4
+	case PHPSerialize, *PHPSerialize:
+		return 5
+	}
+
+	return 0
+}
+
+func valueType(item interface{}) ValueType {
+	v := reflect.ValueOf(item)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return TypeString
+	case reflect.Bool:
+		return TypeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return TypeInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return TypeUint
+	case reflect.Float32, reflect.Float64:
+		return TypeFloat
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return TypeBytes
+		}
+	}
+
+	return TypeOther
+}
@@ -0,0 +1,744 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var _ Encoder = PHPSerialize{}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// PHPSerialize is an Encoder implementation for the format produced by the PHP serialize function, which allows for
+// values to be shared with PHP applications such as Laravel. Nil values are encoded as null, booleans, integers,
+// floats and strings as their PHP counterparts, slices and maps as arrays and structs as stdClass objects whose
+// properties are named after the php struct tag of the fields or the field names otherwise. Map keys are sorted
+// given that Go maps are unordered. Types implementing encoding.TextMarshaler are encoded as strings.
+//
+// Values are decoded into Go values following the same mapping, any object being decoded as if it was a stdClass
+// object. When decoding into an interface{} arrays become []interface{} if their keys are 0 to n-1 and
+// map[string]interface{} otherwise. Bare numbers, which is how Laravel stores numeric values, are decoded as well
+type PHPSerialize struct{}
+
+// Encode implementation of the Encoder interface
+func (PHPSerialize) Encode(item interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := phpEncode(&buf, reflect.ValueOf(item)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decode implementation of the Encoder interface
+func (PHPSerialize) Decode(data []byte, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("encoder: php decode destination must be a non-nil pointer")
+	}
+
+	value, err := phpParse(data)
+	if err != nil {
+		return err
+	}
+
+	return phpAssign(v.Elem(), value)
+}
+
+func phpEncode(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteString("N;")
+
+		return nil
+	}
+	if v.Type().Implements(textMarshalerType) && (v.Kind() != reflect.Pointer || !v.IsNil()) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+
+		phpEncodeString(buf, string(text))
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("N;")
+
+			return nil
+		}
+
+		return phpEncode(buf, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteString("b:1;")
+		} else {
+			buf.WriteString("b:0;")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString("i:" + strconv.FormatInt(v.Int(), 10) + ";")
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			// PHP integers are signed 64 bit integers, bigger values become floats
+			buf.WriteString("d:" + phpFloat(float64(v.Uint())) + ";")
+		} else {
+			buf.WriteString("i:" + strconv.FormatUint(v.Uint(), 10) + ";")
+		}
+	case reflect.Float32, reflect.Float64:
+		buf.WriteString("d:" + phpFloat(v.Float()) + ";")
+	case reflect.String:
+		phpEncodeString(buf, v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			phpEncodeString(buf, string(v.Bytes()))
+
+			return nil
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteString("N;")
+
+			return nil
+		}
+
+		buf.WriteString("a:" + strconv.Itoa(v.Len()) + ":{")
+		for i := 0; i < v.Len(); i++ {
+			buf.WriteString("i:" + strconv.Itoa(i) + ";")
+			if err := phpEncode(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteString("N;")
+
+			return nil
+		}
+
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key, err := phpMapKey(iter.Key())
+			if err != nil {
+				return err
+			}
+
+			keys = append(keys, key)
+			values[key] = iter.Value()
+		}
+		sort.Strings(keys)
+
+		buf.WriteString("a:" + strconv.Itoa(len(keys)) + ":{")
+		for _, key := range keys {
+			phpEncodeKey(buf, key)
+			if err := phpEncode(buf, values[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case reflect.Struct:
+		fields := phpFields(v.Type())
+		var props bytes.Buffer
+		count := 0
+		for _, f := range fields {
+			fv := v.FieldByIndex(f.index)
+			if f.omitEmpty && fv.IsZero() {
+				continue
+			}
+
+			phpEncodeString(&props, f.name)
+			if err := phpEncode(&props, fv); err != nil {
+				return err
+			}
+			count++
+		}
+
+		buf.WriteString(`O:8:"stdClass":` + strconv.Itoa(count) + ":{")
+		buf.Write(props.Bytes())
+		buf.WriteString("}")
+	default:
+		return fmt.Errorf("encoder: php serialize does not support %s values", v.Type())
+	}
+
+	return nil
+}
+
+func phpEncodeString(buf *bytes.Buffer, s string) {
+	buf.WriteString("s:" + strconv.Itoa(len(s)) + `:"` + s + `";`)
+}
+
+// phpEncodeKey encodes an array key, PHP storing decimal integer keys such as "7" as integers
+func phpEncodeKey(buf *bytes.Buffer, key string) {
+	if n, err := strconv.ParseInt(key, 10, 64); err == nil && strconv.FormatInt(n, 10) == key {
+		buf.WriteString("i:" + key + ";")
+
+		return
+	}
+
+	phpEncodeString(buf, key)
+}
+
+func phpMapKey(key reflect.Value) (string, error) {
+	if key.Type().Implements(textMarshalerType) {
+		text, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+
+		return string(text), err
+	}
+
+	switch key.Kind() {
+	case reflect.String:
+		return key.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+
+	return "", fmt.Errorf("encoder: php serialize does not support %s map keys", key.Type())
+}
+
+// phpFloat formats f as PHP does when serialize_precision is -1, i.e. using the shortest representation that
+// round-trips and switching to scientific notation for exponents below -4 or above 16
+func phpFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NAN"
+	case math.IsInf(f, 1):
+		return "INF"
+	case math.IsInf(f, -1):
+		return "-INF"
+	case f == 0:
+		if math.Signbit(f) {
+			return "-0"
+		}
+
+		return "0"
+	}
+
+	var sign string
+	if f < 0 {
+		sign, f = "-", -f
+	}
+
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exponent, _ := strings.Cut(s, "e")
+	exp, _ := strconv.Atoi(exponent)
+	digits := strings.Replace(mantissa, ".", "", 1)
+	decpt := exp + 1
+
+	if decpt < -3 || decpt > 17 {
+		fraction := digits[1:]
+		if len(fraction) == 0 {
+			fraction = "0"
+		}
+
+		expSign := "+"
+		if exp < 0 {
+			expSign, exp = "-", -exp
+		}
+
+		return sign + digits[:1] + "." + fraction + "E" + expSign + strconv.Itoa(exp)
+	}
+	if decpt <= 0 {
+		return sign + "0." + strings.Repeat("0", -decpt) + digits
+	}
+	if len(digits) <= decpt {
+		return sign + digits + strings.Repeat("0", decpt-len(digits))
+	}
+
+	return sign + digits[:decpt] + "." + digits[decpt:]
+}
+
+type phpField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// phpFields returns the exported fields of t named after their php struct tag, embedded structs being flattened
+func phpFields(t reflect.Type) []phpField {
+	var fields []phpField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("php"), ",")
+		if name == "-" && len(opts) == 0 {
+			continue
+		}
+		if f.Anonymous && len(name) == 0 && f.Type.Kind() == reflect.Struct {
+			for _, embedded := range phpFields(f.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+
+		fields = append(fields, phpField{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: opts == "omitempty",
+		})
+	}
+
+	return fields
+}
+
+// phpArray holds the entries of a PHP array or the properties of a PHP object in order
+type phpArray struct {
+	keys   []interface{}
+	values []interface{}
+	object bool
+}
+
+// list reports whether the keys of the array are the integers 0 to n-1
+func (a *phpArray) list() bool {
+	if a.object {
+		return false
+	}
+	for i, key := range a.keys {
+		if n, valid := key.(int64); !valid || n != int64(i) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// phpParse parses the PHP serialized value held by data into nil, bool, int64, float64, string or *phpArray
+func phpParse(data []byte) (interface{}, error) {
+	if n, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(string(data), 64); err == nil {
+		return f, nil
+	}
+
+	p := &phpParser{data: data}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(data) {
+		return nil, p.errorf("unexpected trailing data")
+	}
+
+	return value, nil
+}
+
+type phpParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *phpParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("encoder: invalid php serialized data at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *phpParser) expect(b byte) error {
+	if p.pos >= len(p.data) || p.data[p.pos] != b {
+		return p.errorf("expected %q", b)
+	}
+
+	p.pos++
+
+	return nil
+}
+
+// until returns the data up to the given delimiter consuming the delimiter
+func (p *phpParser) until(delimiter byte) (string, error) {
+	end := bytes.IndexByte(p.data[p.pos:], delimiter)
+	if end < 0 {
+		return "", p.errorf("expected %q", delimiter)
+	}
+
+	s := string(p.data[p.pos : p.pos+end])
+	p.pos += end + 1
+
+	return s, nil
+}
+
+func (p *phpParser) length(delimiter byte) (int, error) {
+	s, err := p.until(delimiter)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, p.errorf("invalid length %q", s)
+	}
+
+	return n, nil
+}
+
+func (p *phpParser) string() (string, error) {
+	n, err := p.length(':')
+	if err != nil {
+		return "", err
+	}
+	if err = p.expect('"'); err != nil {
+		return "", err
+	}
+	if n > len(p.data)-p.pos {
+		return "", p.errorf("string length %d out of range", n)
+	}
+
+	s := string(p.data[p.pos : p.pos+n])
+	p.pos += n
+	if err = p.expect('"'); err != nil {
+		return "", err
+	}
+
+	return s, nil
+}
+
+func (p *phpParser) value() (interface{}, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of data")
+	}
+
+	kind := p.data[p.pos]
+	p.pos++
+	if kind == 'N' {
+		return nil, p.expect(';')
+	}
+	if err := p.expect(':'); err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case 'b':
+		s, err := p.until(';')
+		if err != nil {
+			return nil, err
+		}
+		if s != "0" && s != "1" {
+			return nil, p.errorf("invalid boolean %q", s)
+		}
+
+		return s == "1", nil
+	case 'i':
+		s, err := p.until(';')
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer %q", s)
+		}
+
+		return n, nil
+	case 'd':
+		s, err := p.until(';')
+		if err != nil {
+			return nil, err
+		}
+
+		switch s {
+		case "INF":
+			return math.Inf(1), nil
+		case "-INF":
+			return math.Inf(-1), nil
+		case "NAN":
+			return math.NaN(), nil
+		}
+
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, p.errorf("invalid float %q", s)
+		}
+
+		return f, nil
+	case 's':
+		s, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+
+		return s, p.expect(';')
+	case 'a':
+		return p.entries(&phpArray{})
+	case 'O':
+		if _, err := p.string(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+
+		return p.entries(&phpArray{object: true})
+	}
+
+	return nil, p.errorf("unsupported type %q", kind)
+}
+
+func (p *phpParser) entries(a *phpArray) (*phpArray, error) {
+	n, err := p.length(':')
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect('{'); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < n; i++ {
+		key, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		switch k := key.(type) {
+		case int64:
+		case string:
+			if a.object {
+				key = phpPropertyName(k)
+			}
+		default:
+			return nil, p.errorf("invalid array key %v", key)
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		a.keys = append(a.keys, key)
+		a.values = append(a.values, value)
+	}
+
+	return a, p.expect('}')
+}
+
+// phpPropertyName strips the visibility marker from the name of private ("\0Class\0name") and protected
+// ("\0*\0name") properties
+func phpPropertyName(name string) string {
+	if len(name) == 0 || name[0] != 0 {
+		return name
+	}
+	if i := strings.IndexByte(name[1:], 0); i >= 0 {
+		return name[i+2:]
+	}
+
+	return name
+}
+
+func phpKeyString(key interface{}) string {
+	if n, valid := key.(int64); valid {
+		return strconv.FormatInt(n, 10)
+	}
+
+	return key.(string)
+}
+
+// phpAssign stores value, as returned by phpParse, in dest
+func phpAssign(dest reflect.Value, value interface{}) error {
+	if value == nil {
+		dest.Set(reflect.Zero(dest.Type()))
+
+		return nil
+	}
+	if dest.Kind() == reflect.Pointer {
+		if dest.IsNil() {
+			dest.Set(reflect.New(dest.Type().Elem()))
+		}
+
+		return phpAssign(dest.Elem(), value)
+	}
+	if s, valid := value.(string); valid && dest.CanAddr() && dest.Addr().Type().Implements(textUnmarshalerType) {
+		return dest.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if dest.Kind() == reflect.Interface && dest.NumMethod() == 0 {
+		dest.Set(reflect.ValueOf(phpInterface(value)))
+
+		return nil
+	}
+
+	mismatch := fmt.Errorf("encoder: cannot decode php %T into %s", value, dest.Type())
+	switch dest.Kind() {
+	case reflect.Bool:
+		b, valid := value.(bool)
+		if !valid {
+			return mismatch
+		}
+
+		dest.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := phpInt(value)
+		if err != nil || dest.OverflowInt(n) {
+			return mismatch
+		}
+
+		dest.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := phpInt(value)
+		if err != nil || n < 0 || dest.OverflowUint(uint64(n)) {
+			return mismatch
+		}
+
+		dest.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		switch v := value.(type) {
+		case float64:
+			dest.SetFloat(v)
+		case int64:
+			dest.SetFloat(float64(v))
+		default:
+			return mismatch
+		}
+	case reflect.String:
+		// Scalars are converted to strings the way PHP casts them
+		switch v := value.(type) {
+		case string:
+			dest.SetString(v)
+		case int64:
+			dest.SetString(strconv.FormatInt(v, 10))
+		case float64:
+			dest.SetString(phpFloat(v))
+		case bool:
+			if v {
+				dest.SetString("1")
+			} else {
+				dest.SetString("")
+			}
+		default:
+			return mismatch
+		}
+	case reflect.Slice:
+		if s, valid := value.(string); valid && dest.Type().Elem().Kind() == reflect.Uint8 {
+			dest.SetBytes([]byte(s))
+
+			return nil
+		}
+
+		a, valid := value.(*phpArray)
+		if !valid {
+			return mismatch
+		}
+
+		slice := reflect.MakeSlice(dest.Type(), len(a.values), len(a.values))
+		for i, v := range a.values {
+			if err := phpAssign(slice.Index(i), v); err != nil {
+				return err
+			}
+		}
+
+		dest.Set(slice)
+	case reflect.Array:
+		a, valid := value.(*phpArray)
+		if !valid || len(a.values) > dest.Len() {
+			return mismatch
+		}
+		for i, v := range a.values {
+			if err := phpAssign(dest.Index(i), v); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		a, valid := value.(*phpArray)
+		if !valid {
+			return mismatch
+		}
+		if dest.IsNil() {
+			dest.Set(reflect.MakeMapWithSize(dest.Type(), len(a.keys)))
+		}
+
+		for i, key := range a.keys {
+			k := reflect.New(dest.Type().Key()).Elem()
+			if err := phpAssign(k, key); err != nil {
+				return err
+			}
+
+			v := reflect.New(dest.Type().Elem()).Elem()
+			if err := phpAssign(v, a.values[i]); err != nil {
+				return err
+			}
+
+			dest.SetMapIndex(k, v)
+		}
+	case reflect.Struct:
+		a, valid := value.(*phpArray)
+		if !valid {
+			return mismatch
+		}
+
+		fields := phpFields(dest.Type())
+		for i, key := range a.keys {
+			name := phpKeyString(key)
+			for _, f := range fields {
+				if f.name != name && !strings.EqualFold(f.name, name) {
+					continue
+				}
+				if err := phpAssign(dest.FieldByIndex(f.index), a.values[i]); err != nil {
+					return err
+				}
+
+				break
+			}
+		}
+	default:
+		return mismatch
+	}
+
+	return nil
+}
+
+func phpInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+
+	return 0, errors.New("not an integer")
+}
+
+// phpInterface converts value, as returned by phpParse, into its interface{} representation
+func phpInterface(value interface{}) interface{} {
+	a, valid := value.(*phpArray)
+	if !valid {
+		return value
+	}
+	if a.list() {
+		list := make([]interface{}, len(a.values))
+		for i, v := range a.values {
+			list[i] = phpInterface(v)
+		}
+
+		return list
+	}
+
+	m := make(map[string]interface{}, len(a.keys))
+	for i, key := range a.keys {
+		m[phpKeyString(key)] = phpInterface(a.values[i])
+	}
+
+	return m
+}
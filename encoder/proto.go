@@ -0,0 +1,45 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var _ Encoder = Proto{}
+
+// ErrNotProtoMessage is returned by Proto when encoding or decoding a value that is not a proto.Message and no
+// Fallback encoder has been configured
+var ErrNotProtoMessage = errors.New("encoder: value is not a proto.Message")
+
+// Proto is an Encoder implementation for Protocol Buffers messages via proto.Marshal and proto.Unmarshal. Values
+// that are not a proto.Message, such as the strings stored via Put and retrieved via GetString, are handled by
+// Fallback. If Fallback is nil, such values result in an ErrNotProtoMessage error
+type Proto struct {
+	Fallback Encoder
+}
+
+// Encode implementation of the Encoder interface
+func (p Proto) Encode(item interface{}) ([]byte, error) {
+	if message, valid := item.(proto.Message); valid {
+		return proto.Marshal(message)
+	}
+	if p.Fallback == nil {
+		return nil, fmt.Errorf("%w: %T", ErrNotProtoMessage, item)
+	}
+
+	return p.Fallback.Encode(item)
+}
+
+// Decode implementation of the Encoder interface
+func (p Proto) Decode(data []byte, dest interface{}) error {
+	if message, valid := dest.(proto.Message); valid {
+		return proto.Unmarshal(data, message)
+	}
+	if p.Fallback == nil {
+		return fmt.Errorf("%w: %T", ErrNotProtoMessage, dest)
+	}
+
+	return p.Fallback.Decode(data, dest)
+}
@@ -0,0 +1,90 @@
+package encoder
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// streamMagic prefixes every stream compressed by NewCompressWriter. It is followed by a byte identifying the
+// Algorithm. It differs from the magic written by Compressed given that streams use the framed formats of the
+// algorithms, e.g. the Snappy framing format rather than the block format
+var streamMagic = []byte{0x00, 'g', 'c', 's'}
+
+// NewCompressWriter returns a writer compressing everything written to it via algorithm into w. The compressed
+// stream is prefixed by a header identifying the algorithm so that NewDecompressReader can tell it apart from an
+// uncompressed one. Close needs to be called in order to flush the stream, it does not close w. Algorithm defaults
+// to Gzip when 0
+func NewCompressWriter(w io.Writer, algorithm Algorithm) (io.WriteCloser, error) {
+	if algorithm == 0 {
+		algorithm = Gzip
+	}
+
+	var (
+		header = append(append([]byte{}, streamMagic...), byte(algorithm))
+		cw     io.WriteCloser
+		err    error
+	)
+	switch algorithm {
+	case Gzip:
+		cw = gzip.NewWriter(w)
+	case Zstd:
+		cw, err = zstd.NewWriter(w)
+	case Snappy:
+		cw = snappy.NewBufferedWriter(w)
+	case LZ4:
+		cw = lz4.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("encoder: unsupported compression algorithm %s", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return cw, nil
+}
+
+// NewDecompressReader returns a reader decompressing r if it was written by NewCompressWriter, r being read as is
+// otherwise. Close releases the resources held by the decompressor, it does not close r
+func NewDecompressReader(r io.Reader) (io.ReadCloser, error) {
+	var (
+		br          = bufio.NewReader(r)
+		header, err = br.Peek(len(streamMagic) + 1)
+	)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(header) <= len(streamMagic) || !bytes.HasPrefix(header, streamMagic) {
+		return io.NopCloser(br), nil
+	}
+	if _, err = br.Discard(len(header)); err != nil {
+		return nil, err
+	}
+
+	switch algorithm := Algorithm(header[len(streamMagic)]); algorithm {
+	case Gzip:
+		return gzip.NewReader(br)
+	case Zstd:
+		d, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.IOReadCloser(), nil
+	case Snappy:
+		return io.NopCloser(snappy.NewReader(br)), nil
+	case LZ4:
+		return io.NopCloser(lz4.NewReader(br)), nil
+	default:
+		return nil, fmt.Errorf("encoder: unsupported compression algorithm %s", algorithm)
+	}
+}
@@ -20,6 +20,12 @@ var (
 	ErrBlockWaitTimeout = errors.New("gocache: failed to acquire lock due to lock wait timeout")
 	// ErrNotImplemented is returned for methods that have not been implemented for the Cache interface
 	ErrNotImplemented = errors.New("gocache: method not implemented")
+	// ErrEncryptedCounter is returned when calling Increment or Decrement on a store whose encoder is encrypted given
+	// that counters are kept in plaintext by the backends in order to be updated atomically
+	ErrEncryptedCounter = errors.New("gocache: counters are not supported by stores whose encoder is encrypted")
+	// ErrTagIndexContended is returned by the tagged writes of the Memcache driver when a tag index could not be
+	// updated due to concurrent updates, in which case the entry is not written so that Flush never misses it
+	ErrTagIndexContended = errors.New("gocache: tag index update contended")
 )
 
 func checkErrNotFound(err error) error {
@@ -0,0 +1,126 @@
+package gocache
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+var _ Lock = &fileLock{}
+
+func newFileLock(store *FileStore, name, owner string, duration time.Duration) *fileLock {
+	return (&fileLock{
+		store:    store,
+		name:     name,
+		owner:    owner,
+		duration: duration,
+	}).initBaseLock()
+}
+
+type fileLock struct {
+	baseLock
+	store    *FileStore
+	name     string
+	owner    string
+	duration time.Duration
+}
+
+// Acquire implementation of the Lock interface
+func (fl *fileLock) Acquire() (bool, error) {
+	var acquired bool
+	if err := fl.store.withFlock(fl.store.lockPath(fl.name), func(path string) error {
+		if _, _, err := fl.store.read(path); err == nil {
+			return nil
+		} else if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		acquired = true
+
+		return fl.store.write(path, []byte(fl.owner), fl.store.expiration(fl.duration))
+	}); err != nil {
+		return false, err
+	}
+
+	return acquired, nil
+}
+
+// Release implementation of the Lock interface
+func (fl *fileLock) Release() (bool, error) {
+	var released bool
+	if err := fl.store.withFlock(fl.store.lockPath(fl.name), func(path string) error {
+		owner, _, err := fl.store.read(path)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if string(owner) != fl.owner {
+			return nil
+		}
+
+		released = true
+
+		return os.Remove(path)
+	}); err != nil {
+		return false, err
+	}
+
+	return released, nil
+}
+
+// ForceRelease implementation of the Lock interface
+func (fl *fileLock) ForceRelease() error {
+	return fl.store.withFlock(fl.store.lockPath(fl.name), func(path string) error {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// GetCurrentOwner implementation of the Lock interface
+func (fl *fileLock) GetCurrentOwner() (string, error) {
+	owner, _, err := fl.store.read(fl.store.lockPath(fl.name))
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return string(owner), nil
+}
+
+// Expire implementation of the Lock interface
+func (fl *fileLock) Expire(duration time.Duration) (bool, error) {
+	var expired bool
+	if err := fl.store.withFlock(fl.store.lockPath(fl.name), func(path string) error {
+		owner, _, err := fl.store.read(path)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if string(owner) != fl.owner {
+			return nil
+		}
+
+		expired = true
+		if duration <= 0 {
+			return os.Remove(path)
+		}
+
+		return fl.store.write(path, owner, fl.store.expiration(duration))
+	}); err != nil {
+		return false, err
+	}
+
+	return expired, nil
+}
+
+func (fl *fileLock) initBaseLock() *fileLock {
+	fl.lock = fl
+
+	return fl
+}
@@ -0,0 +1,644 @@
+package gocache
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+)
+
+const (
+	// fileHeaderSize is the size of the header holding the expiration, in unix milliseconds, of every file
+	fileHeaderSize   = 20
+	fileEntriesDir   = "cache"
+	fileLocksDir     = "locks"
+	fileFlocksDir    = ".flock"
+	fileFlockStripes = 256
+	fileTempPrefix   = ".tmp-"
+	defaultFileMode  = 0o644
+	defaultDirMode   = 0o755
+)
+
+var _ Cache = &FileStore{}
+
+// NewFileStore validates the passed in config and creates a Cache implementation of type *FileStore
+func NewFileStore(cnf *FileConfig, encoder encoder.Encoder) (*FileStore, error) {
+	if err := cnf.validate(); err != nil {
+		return nil, err
+	}
+
+	s := &FileStore{
+		prefix: prefix{
+			val: cnf.Prefix,
+		},
+		directory: cnf.Directory,
+		fileMode:  cnf.FileMode,
+		dirMode:   cnf.DirMode,
+		encoder:   encoder,
+		done:      make(chan struct{}),
+	}
+	if s.fileMode == 0 {
+		s.fileMode = defaultFileMode
+	}
+	if s.dirMode == 0 {
+		s.dirMode = defaultDirMode
+	}
+	if err := os.MkdirAll(filepath.Join(s.directory, fileFlocksDir), s.dirMode); err != nil {
+		return nil, err
+	}
+	if cnf.PruneInterval > 0 {
+		go s.pruneEvery(cnf.PruneInterval)
+	}
+
+	return s, nil
+}
+
+// FileStore is the representation of a filesystem caching store. Every entry is persisted in its own file
+// sharded by the hash of its key. Files are prefixed by a header holding their expiration and are written
+// atomically via write-rename. Writes are guarded by flock so that read-modify-write operations such as Add,
+// Increment and locks remain atomic across processes sharing the same directory. Expired files are ignored
+// when read and removed by Prune
+type FileStore struct {
+	prefix
+	directory string
+	fileMode  os.FileMode
+	dirMode   os.FileMode
+	encoder   encoder.Encoder
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// GetFloat64 gets a float64 value from the store
+func (s *FileStore) GetFloat64(key string) (float64, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
+	}
+
+	return stringToFloat64(value)
+}
+
+// GetFloat32 gets a float32 value from the store
+func (s *FileStore) GetFloat32(key string) (float32, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
+	}
+
+	return stringToFloat32(value)
+}
+
+// GetInt64 gets an int64 value from the store
+func (s *FileStore) GetInt64(key string) (int64, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
+	}
+
+	return stringToInt64(value)
+}
+
+// GetInt gets an int value from the store
+func (s *FileStore) GetInt(key string) (int, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
+	}
+
+	return stringToInt(value)
+}
+
+// GetUint64 gets an uint64 value from the store
+func (s *FileStore) GetUint64(key string) (uint64, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
+	}
+
+	return stringToUint64(value)
+}
+
+// GetBool gets a bool value from the store
+func (s *FileStore) GetBool(key string) (bool, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return false, err
+	}
+
+	return boolValue(s.encoder, value)
+}
+
+// GetString gets a string value from the store
+func (s *FileStore) GetString(key string) (string, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return "", err
+	}
+	res, err := stringValue(s.encoder, value)
+	if err != nil {
+		return "", err
+	}
+
+	reEncode(s.encoder, []byte(value), s.replacer(key))
+
+	return res, nil
+}
+
+// GetTime gets a time.Time value from the store
+func (s *FileStore) GetTime(key string) (time.Time, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return timeValue(s.encoder, value)
+}
+
+// GetDuration gets a time.Duration value from the store
+func (s *FileStore) GetDuration(key string) (time.Duration, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return durationValue(s.encoder, value)
+}
+
+// GetBytes gets the raw bytes stored for the given key bypassing the encoder
+func (s *FileStore) GetBytes(key string) ([]byte, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(value), nil
+}
+
+// PutBytes puts raw bytes in the given store for a predetermined amount of time bypassing the encoder. A
+// duration lower or equal to 0 stores the value forever
+func (s *FileStore) PutBytes(key string, value []byte, duration time.Duration) error {
+	return s.withFlock(s.entryPath(key), func(path string) error {
+		return s.write(path, value, s.expiration(duration))
+	})
+}
+
+// Get gets the struct representation of a value from the store
+func (s *FileStore) Get(key string, entity interface{}) error {
+	value, err := s.value(key)
+	if err != nil {
+		return err
+	}
+
+	data := []byte(value)
+	if err = decode(s.encoder, data, entity); err != nil {
+		return err
+	}
+
+	reEncode(s.encoder, data, s.replacer(key))
+
+	return nil
+}
+
+// Put puts a value in the given store for a predetermined amount of time in seconds. A duration lower or
+// equal to 0 stores the value forever
+func (s *FileStore) Put(key string, value interface{}, duration time.Duration) error {
+	val, err := s.encode(value)
+	if err != nil {
+		return err
+	}
+
+	return s.withFlock(s.entryPath(key), func(path string) error {
+		return s.write(path, val, s.expiration(duration))
+	})
+}
+
+// Add an item to the cache only if an item doesn't already exist for the given key, or if the existing item has
+// expired. If the record was successfully added true will be returned else false will be returned
+func (s *FileStore) Add(key string, value interface{}, duration time.Duration) (bool, error) {
+	val, err := s.encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	var added bool
+	if err = s.withFlock(s.entryPath(key), func(path string) error {
+		if _, _, err := s.read(path); err == nil {
+			return nil
+		} else if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		added = true
+
+		return s.write(path, val, s.expiration(duration))
+	}); err != nil {
+		return false, err
+	}
+
+	return added, nil
+}
+
+// Forever puts a value in the given store until it is forgotten/evicted
+func (s *FileStore) Forever(key string, value interface{}) error {
+	return s.Put(key, value, 0)
+}
+
+// Increment increments an integer counter by a given value
+func (s *FileStore) Increment(key string, value int64) (int64, error) {
+	if isEncrypted(s.encoder) {
+		return 0, ErrEncryptedCounter
+	}
+
+	var res int64
+	if err := s.withFlock(s.entryPath(key), func(path string) error {
+		current, expiration, err := s.read(path)
+		if errors.Is(err, ErrNotFound) {
+			res = value
+
+			return s.write(path, []byte(strconv.FormatInt(res, 10)), 0)
+		} else if err != nil {
+			return err
+		}
+
+		n, err := stringToInt64(string(current))
+		if err != nil {
+			return err
+		}
+
+		res = n + value
+
+		return s.write(path, []byte(strconv.FormatInt(res, 10)), expiration)
+	}); err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
+// Decrement decrements an integer counter by a given value
+func (s *FileStore) Decrement(key string, value int64) (int64, error) {
+	return s.Increment(key, -1*value)
+}
+
+// Forget forgets/evicts a given key-value pair from the store
+func (s *FileStore) Forget(key string) (bool, error) {
+	var forgotten bool
+	if err := s.withFlock(s.entryPath(key), func(path string) error {
+		if _, _, err := s.read(path); errors.Is(err, ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := os.Remove(path); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		forgotten = true
+
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	return forgotten, nil
+}
+
+// ForgetMany forgets/evicts a set of given key-value pair from the store
+func (s *FileStore) ForgetMany(keys ...string) error {
+	for _, key := range keys {
+		if err := os.Remove(s.entryPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PutMany puts many values in the given store until they are forgotten/evicted
+func (s *FileStore) PutMany(entries ...Entry) error {
+	for _, entry := range entries {
+		if err := s.Put(entry.Key, entry.Value, entry.Duration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Many gets many values from the store
+func (s *FileStore) Many(keys ...string) (Items, error) {
+	items := Items{}
+	for _, key := range keys {
+		value, err := s.value(key)
+		if err != nil {
+			items[key] = Item{
+				key: key,
+				err: err,
+			}
+
+			continue
+		}
+
+		items[key] = Item{
+			key:     key,
+			value:   value,
+			encoder: s.encoder,
+		}
+	}
+
+	return items, nil
+}
+
+// Flush flushes the store
+func (s *FileStore) Flush() (bool, error) {
+	if err := os.RemoveAll(filepath.Join(s.directory, fileEntriesDir)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Prune deletes all the expired entries and locks from the filesystem
+func (s *FileStore) Prune() error {
+	for _, dir := range []string{fileEntriesDir, fileLocksDir} {
+		if err := filepath.WalkDir(filepath.Join(s.directory, dir), func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if d.IsDir() || strings.HasPrefix(d.Name(), fileTempPrefix) {
+				return nil
+			}
+
+			return s.prune(path)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close stops the background pruning of expired entries if any
+func (s *FileStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+
+	return nil
+}
+
+// Tags returns the taggedCache for the given store
+func (s *FileStore) Tags(names ...string) TaggedCache {
+	return &taggedCache{
+		store: s,
+		tags: &TagSet{
+			store: s,
+			names: names,
+		},
+	}
+}
+
+// Lock returns a filesystem implementation of the Lock interface
+func (s *FileStore) Lock(name, owner string, duration time.Duration) Lock {
+	return newFileLock(s, name, owner, duration)
+}
+
+// Exists checks if an entry exists in the cache for the given key
+func (s *FileStore) Exists(key string) (bool, error) {
+	_, err := s.value(key)
+	if err == nil {
+		return true, nil
+	} else if isErrNotFound(err) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// Expire implementation of the Cache interface. A duration lower or equal to 0 evicts the entry
+func (s *FileStore) Expire(key string, duration time.Duration) error {
+	return s.withFlock(s.entryPath(key), func(path string) error {
+		value, _, err := s.read(path)
+		if err != nil {
+			return err
+		}
+		if duration <= 0 {
+			return os.Remove(path)
+		}
+
+		return s.write(path, value, s.expiration(duration))
+	})
+}
+
+// replacer returns a function replacing the value of an existing entry while keeping its expiration, as long as it
+// still holds the value that was read
+func (s *FileStore) replacer(key string) func(current, upgraded []byte) error {
+	return func(current, upgraded []byte) error {
+		return s.withFlock(s.entryPath(key), func(path string) error {
+			value, expiration, err := s.read(path)
+			if err != nil || !bytes.Equal(value, current) {
+				return err
+			}
+
+			return s.write(path, upgraded, expiration)
+		})
+	}
+}
+
+func (s *FileStore) value(key string) (string, error) {
+	value, _, err := s.read(s.entryPath(key))
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+// read returns the contents and expiration of the file at path. Expired files are reported as not found
+func (s *FileStore) read(path string) ([]byte, int64, error) {
+	value, expiration, err := s.readFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if expiration != 0 && expiration <= s.now() {
+		return nil, 0, ErrNotFound
+	}
+
+	return value, expiration, nil
+}
+
+func (*FileStore) readFile(path string) ([]byte, int64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, ErrNotFound
+	} else if err != nil {
+		return nil, 0, err
+	}
+	if len(data) < fileHeaderSize {
+		return nil, 0, errors.New("gocache: corrupted cache file " + path)
+	}
+
+	expiration, err := strconv.ParseInt(string(data[:fileHeaderSize]), 10, 64)
+	if err != nil {
+		return nil, 0, errors.New("gocache: corrupted cache file " + path)
+	}
+
+	return data[fileHeaderSize:], expiration, nil
+}
+
+// prune removes the file at path if it has expired. The check is performed while holding the flock in order
+// not to remove a file that was concurrently rewritten
+func (s *FileStore) prune(path string) error {
+	return s.withFlock(path, func(path string) error {
+		_, expiration, err := s.readFile(path)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if expiration == 0 || expiration > s.now() {
+			return nil
+		}
+		if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// write atomically replaces the file at path by writing to a temporary file and renaming it
+func (s *FileStore) write(path string, value []byte, expiration int64) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, s.dirMode); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, fileTempPrefix)
+	if err != nil {
+		return err
+	}
+	if _, err = f.WriteString(fmt.Sprintf("%0*d", fileHeaderSize, expiration)); err == nil {
+		_, err = f.Write(value)
+	}
+	if err == nil {
+		err = f.Chmod(s.fileMode)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	return nil
+}
+
+// withFlock runs fn while holding the flock stripe that guards the given path
+func (s *FileStore) withFlock(path string, fn func(path string) error) error {
+	h := sha1.Sum([]byte(path))
+
+	f, err := os.OpenFile(
+		filepath.Join(s.directory, fileFlocksDir, strconv.Itoa(int(h[0])%fileFlockStripes)),
+		os.O_CREATE|os.O_RDWR,
+		s.fileMode,
+	)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = flock(f); err != nil {
+		return err
+	}
+	defer func() {
+		_ = funlock(f)
+	}()
+
+	return fn(path)
+}
+
+func (s *FileStore) entryPath(key string) string {
+	return s.path(fileEntriesDir, s.k(key))
+}
+
+func (s *FileStore) lockPath(name string) string {
+	return s.path(fileLocksDir, name)
+}
+
+// path shards files in two levels of directories based on the hash of the key
+func (s *FileStore) path(dir, key string) string {
+	h := sha1.Sum([]byte(key))
+	hash := hex.EncodeToString(h[:])
+
+	return filepath.Join(s.directory, dir, hash[0:2], hash[2:4], hash)
+}
+
+func (s *FileStore) encode(value interface{}) ([]byte, error) {
+	if storedAsIs(s.encoder, value) {
+		return []byte(fmt.Sprint(value)), nil
+	}
+
+	return encode(s.encoder, value)
+}
+
+func (s *FileStore) pruneEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			_ = s.Prune()
+		}
+	}
+}
+
+// expiration returns the unix time in milliseconds at which an entry stored for duration expires, 0 meaning never
+func (*FileStore) expiration(duration time.Duration) int64 {
+	if duration <= 0 {
+		return 0
+	}
+
+	return time.Now().Add(duration).UnixNano() / int64(time.Millisecond)
+}
+
+func (*FileStore) now() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
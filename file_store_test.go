@@ -0,0 +1,90 @@
+package gocache
+
+import (
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_Prune(t *testing.T) {
+	for _, e := range encoders {
+		t.Run(fileDriver.string(), func(t *testing.T) {
+			cache, valid := createStore(t, fileDriver, e).(*FileStore)
+			require.True(t, valid)
+			require.NoError(t, cache.Put("expiring", "value", 50*time.Millisecond))
+			require.NoError(t, cache.Forever("forever", "value"))
+
+			acquired, err := cache.Lock("lock", "owner", 50*time.Millisecond).Acquire()
+			require.NoError(t, err)
+			require.True(t, acquired)
+			require.Equal(t, 3, countFiles(t, cache.directory))
+
+			time.Sleep(60 * time.Millisecond)
+
+			// Expired files are ignored when read but only removed when pruned
+			_, err = cache.GetString("expiring")
+			require.ErrorIs(t, err, ErrNotFound)
+			require.Equal(t, 3, countFiles(t, cache.directory))
+			require.NoError(t, cache.Prune())
+			require.Equal(t, 1, countFiles(t, cache.directory))
+
+			v, err := cache.GetString("forever")
+			require.NoError(t, err)
+			require.Equal(t, "value", v)
+		})
+	}
+}
+
+func TestFileStore_ForgetWaitsForFlock(t *testing.T) {
+	cache, valid := createStore(t, fileDriver, encoders[0]).(*FileStore)
+	require.True(t, valid)
+	require.NoError(t, cache.Forever("key", "value"))
+
+	var (
+		forgotten bool
+		err       error
+		done      = make(chan struct{})
+	)
+	require.NoError(t, cache.withFlock(cache.entryPath("key"), func(string) error {
+		go func() {
+			defer close(done)
+
+			forgotten, err = cache.Forget("key")
+		}()
+
+		select {
+		case <-done:
+			t.Error("Forget did not wait for the entry's flock")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		return nil
+	}))
+
+	<-done
+	require.NoError(t, err)
+	require.True(t, forgotten)
+}
+
+func countFiles(t *testing.T, dir string) int {
+	t.Helper()
+
+	var count int
+	for _, sub := range []string{fileEntriesDir, fileLocksDir} {
+		require.NoError(t, filepath.WalkDir(filepath.Join(dir, sub), func(_ string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				count++
+			}
+
+			return nil
+		}))
+	}
+
+	return count
+}
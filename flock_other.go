@@ -0,0 +1,26 @@
+//go:build !unix
+
+package gocache
+
+import (
+	"os"
+	"sync"
+)
+
+// flockMu serializes access on platforms without flock support. Please note that in this case FileStore
+// operations are only atomic within the current process
+var flockMu sync.Mutex
+
+// flock acquires an exclusive lock, blocking until it becomes available
+func flock(*os.File) error {
+	flockMu.Lock()
+
+	return nil
+}
+
+// funlock releases a lock acquired via flock
+func funlock(*os.File) error {
+	flockMu.Unlock()
+
+	return nil
+}
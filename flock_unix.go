@@ -0,0 +1,22 @@
+//go:build unix
+
+package gocache
+
+import (
+	"os"
+	"syscall"
+)
+
+// flock acquires an exclusive advisory lock on the given file, blocking until it becomes available
+func flock(f *os.File) error {
+	for {
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+// funlock releases an advisory lock acquired via flock
+func funlock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
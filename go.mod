@@ -8,7 +8,7 @@ require (
 	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/rs/xid v1.4.0
 	github.com/stretchr/testify v1.7.1
-	gopkg.in/yaml.v3 v3.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0
 )
 
 require (
@@ -16,10 +16,19 @@ require (
 	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 )
 
-require github.com/redis/go-redis/v9 v9.14.0
+require (
+	github.com/fxamacker/cbor/v2 v2.9.4
+	github.com/klauspost/compress v1.17.9
+	github.com/mattn/go-sqlite3 v1.14.32
+	github.com/pierrec/lz4/v4 v4.1.21
+	github.com/redis/go-redis/v9 v9.14.0
+	github.com/yuin/gopher-lua v1.1.1
+	google.golang.org/protobuf v1.34.2
+)
 
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 )
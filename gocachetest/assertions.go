@@ -0,0 +1,188 @@
+package gocachetest
+
+import (
+	"reflect"
+	"time"
+)
+
+// TestingT is the subset of testing.TB used by the assertions of Fake, which *testing.T and *testing.B satisfy
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+var (
+	putMethods    = []string{"Put", "Add", "Forever", "PutMany"}
+	forgetMethods = []string{"Forget", "ForgetMany"}
+)
+
+// AssertPut asserts that a value equal to the given one was stored under key via Put, Add, Forever or PutMany
+// on the cache tagged with the given tags (no tags meaning the untagged cache)
+func (f *Fake) AssertPut(t TestingT, key string, value interface{}, tags ...string) {
+	t.Helper()
+
+	if !f.has(func(op Operation) bool {
+		return matchesMethod(op, putMethods) && op.Key == key && equalTags(op.Tags, tags) &&
+			reflect.DeepEqual(op.Value, value)
+	}) {
+		t.Errorf("gocachetest: expected %q to be put with value %#v and tags %v", key, value, tags)
+	}
+}
+
+// AssertPutWithDuration asserts that a value equal to the given one was stored under key for the given duration
+// on the cache tagged with the given tags (no tags meaning the untagged cache)
+func (f *Fake) AssertPutWithDuration(t TestingT, key string, value interface{}, duration time.Duration, tags ...string) {
+	t.Helper()
+
+	if !f.has(func(op Operation) bool {
+		return matchesMethod(op, putMethods) && op.Key == key && equalTags(op.Tags, tags) &&
+			op.Duration == duration && reflect.DeepEqual(op.Value, value)
+	}) {
+		t.Errorf("gocachetest: expected %q to be put with value %#v, duration %v and tags %v", key, value, duration, tags)
+	}
+}
+
+// AssertNotPut asserts that nothing was stored under key on the cache tagged with the given tags (no tags
+// meaning the untagged cache)
+func (f *Fake) AssertNotPut(t TestingT, key string, tags ...string) {
+	t.Helper()
+
+	if f.has(func(op Operation) bool {
+		return matchesMethod(op, putMethods) && op.Key == key && equalTags(op.Tags, tags)
+	}) {
+		t.Errorf("gocachetest: expected %q not to be put with tags %v", key, tags)
+	}
+}
+
+// AssertForgotten asserts that key was forgotten via Forget or ForgetMany on the cache tagged with the given tags
+// (no tags meaning the untagged cache)
+func (f *Fake) AssertForgotten(t TestingT, key string, tags ...string) {
+	t.Helper()
+
+	if !f.has(func(op Operation) bool {
+		return matchesMethod(op, forgetMethods) && op.Key == key && equalTags(op.Tags, tags)
+	}) {
+		t.Errorf("gocachetest: expected %q to be forgotten with tags %v", key, tags)
+	}
+}
+
+// AssertFlushed asserts that the cache tagged with the given tags (no tags meaning the untagged cache) was flushed
+func (f *Fake) AssertFlushed(t TestingT, tags ...string) {
+	t.Helper()
+
+	if !f.has(func(op Operation) bool {
+		return op.Method == "Flush" && equalTags(op.Tags, tags)
+	}) {
+		t.Errorf("gocachetest: expected cache with tags %v to be flushed", tags)
+	}
+}
+
+// AssertCalled asserts that method was called. If keys are specified the method must have been called with
+// each one of them, regardless of tags
+func (f *Fake) AssertCalled(t TestingT, method string, keys ...string) {
+	t.Helper()
+
+	if len(keys) == 0 {
+		if !f.has(func(op Operation) bool { return op.Method == method }) {
+			t.Errorf("gocachetest: expected %s to be called", method)
+		}
+
+		return
+	}
+
+	for _, key := range keys {
+		if !f.has(func(op Operation) bool { return op.Method == method && op.Key == key }) {
+			t.Errorf("gocachetest: expected %s to be called with %q", method, key)
+		}
+	}
+}
+
+// AssertNotCalled asserts that method was not called. If keys are specified the method must not have been called
+// with any of them, regardless of tags
+func (f *Fake) AssertNotCalled(t TestingT, method string, keys ...string) {
+	t.Helper()
+
+	if len(keys) == 0 {
+		if f.has(func(op Operation) bool { return op.Method == method }) {
+			t.Errorf("gocachetest: expected %s not to be called", method)
+		}
+
+		return
+	}
+
+	for _, key := range keys {
+		if f.has(func(op Operation) bool { return op.Method == method && op.Key == key }) {
+			t.Errorf("gocachetest: expected %s not to be called with %q", method, key)
+		}
+	}
+}
+
+// AssertCalledTimes asserts that method was called exactly n times
+func (f *Fake) AssertCalledTimes(t TestingT, method string, n int) {
+	t.Helper()
+
+	var count int
+	for _, op := range f.Operations() {
+		if op.Method == method {
+			count++
+		}
+	}
+	if count != n {
+		t.Errorf("gocachetest: expected %s to be called %d times, got %d", method, n, count)
+	}
+}
+
+// AssertLockAcquired asserts that the lock identified by name was successfully acquired by owner
+func (f *Fake) AssertLockAcquired(t TestingT, name, owner string) {
+	t.Helper()
+
+	if !f.has(func(op Operation) bool {
+		return op.Method == "Acquire" && op.Key == name && op.Owner == owner && op.Acquired
+	}) {
+		t.Errorf("gocachetest: expected lock %q to be acquired by %q", name, owner)
+	}
+}
+
+// AssertLockNotAcquired asserts that the lock identified by name was never successfully acquired by owner
+func (f *Fake) AssertLockNotAcquired(t TestingT, name, owner string) {
+	t.Helper()
+
+	if f.has(func(op Operation) bool {
+		return op.Method == "Acquire" && op.Key == name && op.Owner == owner && op.Acquired
+	}) {
+		t.Errorf("gocachetest: expected lock %q not to be acquired by %q", name, owner)
+	}
+}
+
+func (f *Fake) has(match func(op Operation) bool) bool {
+	for _, op := range f.Operations() {
+		if match(op) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesMethod(op Operation, methods []string) bool {
+	for _, method := range methods {
+		if op.Method == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
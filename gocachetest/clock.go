@@ -0,0 +1,44 @@
+package gocachetest
+
+import (
+	"sync"
+	"time"
+)
+
+// NewClock creates a *Clock set to the given time
+func NewClock(now time.Time) *Clock {
+	return &Clock{
+		now: now,
+	}
+}
+
+// Clock is a manually controlled clock. Its time only changes when Advance or Set are called which allows for
+// expiration to be tested deterministically
+type Clock struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// Now returns the current time of the clock
+func (c *Clock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.now
+}
+
+// Advance moves the clock forward by the given duration
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Set sets the clock to the given time
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
@@ -0,0 +1,639 @@
+package gocachetest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alejandro-carstens/gocache"
+)
+
+type conformanceExample struct {
+	Name        string
+	Description string
+}
+
+// RunConformance runs the gocache.Cache contract against the caches built by factory. A new cache is requested
+// for every subtest and flushed and closed once the subtest finishes, which allows custom and third-party stores
+// to prove that they behave like the built-in ones. Caches exposing a Clock() *Clock method, such as the Fake,
+// have their clock advanced instead of the suite sleeping while waiting for entries to expire
+func RunConformance(t *testing.T, factory func() gocache.Cache) {
+	t.Helper()
+
+	for _, test := range []struct {
+		name string
+		fn   func(t *testing.T, cache gocache.Cache)
+	}{
+		{name: "TypedGetters", fn: testTypedGetters},
+		{name: "Get", fn: testGet},
+		{name: "BytesTimeDuration", fn: testBytesTimeDuration},
+		{name: "Forever", fn: testForever},
+		{name: "Many", fn: testMany},
+		{name: "Exists", fn: testExists},
+		{name: "Add", fn: testAdd},
+		{name: "AddRace", fn: testAddRace},
+		{name: "IncrementDecrement", fn: testIncrementDecrement},
+		{name: "IncrementAtomicity", fn: testIncrementAtomicity},
+		{name: "Forget", fn: testForget},
+		{name: "Expiration", fn: testExpiration},
+		{name: "Expire", fn: testExpire},
+		{name: "Tags", fn: testTags},
+		{name: "TagsFlush", fn: testTagsFlush},
+		{name: "Lock", fn: testLock},
+		{name: "LockGet", fn: testLockGet},
+		{name: "LockBlock", fn: testLockBlock},
+		{name: "LockExpire", fn: testLockExpire},
+		{name: "RateLimiter", fn: testRateLimiter},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			cache := factory()
+			require.NotNil(t, cache)
+			t.Cleanup(func() {
+				_, err := cache.Flush()
+				require.NoError(t, err)
+				require.NoError(t, cache.Close())
+			})
+
+			test.fn(t, cache)
+		})
+	}
+}
+
+func testTypedGetters(t *testing.T, cache gocache.Cache) {
+	require.NoError(t, cache.Put("int64", int64(100), time.Minute))
+	i64, err := cache.GetInt64("int64")
+	require.NoError(t, err)
+	require.EqualValues(t, 100, i64)
+
+	require.NoError(t, cache.Put("int", 100, time.Minute))
+	i, err := cache.GetInt("int")
+	require.NoError(t, err)
+	require.Equal(t, 100, i)
+
+	require.NoError(t, cache.Put("uint64", uint64(100), time.Minute))
+	u64, err := cache.GetUint64("uint64")
+	require.NoError(t, err)
+	require.EqualValues(t, 100, u64)
+
+	require.NoError(t, cache.Put("float64", 9.99, time.Minute))
+	f64, err := cache.GetFloat64("float64")
+	require.NoError(t, err)
+	require.Equal(t, 9.99, f64)
+
+	f32, err := cache.GetFloat32("float64")
+	require.NoError(t, err)
+	require.EqualValues(t, float32(9.99), f32)
+
+	require.NoError(t, cache.Put("string", "value", time.Minute))
+	s, err := cache.GetString("string")
+	require.NoError(t, err)
+	require.Equal(t, "value", s)
+
+	for value, expected := range map[interface{}]bool{true: true, false: false, 1: true, 0: false, "a": true, "": false} {
+		require.NoError(t, cache.Put("bool", value, time.Minute))
+
+		b, err := cache.GetBool("bool")
+		require.NoError(t, err)
+		require.Equal(t, expected, b, "GetBool(%#v)", value)
+	}
+
+	_, err = cache.GetString("missing")
+	require.ErrorIs(t, err, gocache.ErrNotFound)
+
+	_, err = cache.GetInt64("missing")
+	require.ErrorIs(t, err, gocache.ErrNotFound)
+}
+
+func testGet(t *testing.T, cache gocache.Cache) {
+	expected := conformanceExample{Name: "gocache", Description: "conformance"}
+	require.NoError(t, cache.Put("struct", expected, time.Minute))
+
+	var got conformanceExample
+	require.NoError(t, cache.Get("struct", &got))
+	require.Equal(t, expected, got)
+
+	type custom int
+	require.NoError(t, cache.Put("custom", custom(1), time.Minute))
+
+	var c custom
+	require.NoError(t, cache.Get("custom", &c))
+	require.EqualValues(t, 1, c)
+
+	require.ErrorIs(t, cache.Get("missing", &got), gocache.ErrNotFound)
+}
+
+func testBytesTimeDuration(t *testing.T, cache gocache.Cache) {
+	raw := []byte{0x00, 0xff, 'g', 'o'}
+	require.NoError(t, cache.PutBytes("bytes", raw, time.Minute))
+	b, err := cache.GetBytes("bytes")
+	require.NoError(t, err)
+	require.Equal(t, raw, b)
+
+	now := time.Date(2024, 5, 1, 12, 30, 15, 123456789, time.UTC)
+	require.NoError(t, cache.Put("time", now, time.Minute))
+	tm, err := cache.GetTime("time")
+	require.NoError(t, err)
+	require.True(t, now.Equal(tm), "GetTime() = %v", tm)
+
+	require.NoError(t, cache.Put("duration", 90*time.Second, time.Minute))
+	d, err := cache.GetDuration("duration")
+	require.NoError(t, err)
+	require.Equal(t, 90*time.Second, d)
+
+	_, err = cache.GetBytes("missing")
+	require.ErrorIs(t, err, gocache.ErrNotFound)
+
+	_, err = cache.GetTime("missing")
+	require.ErrorIs(t, err, gocache.ErrNotFound)
+}
+
+func testForever(t *testing.T, cache gocache.Cache) {
+	require.NoError(t, cache.Forever("key", "value"))
+
+	got, err := cache.GetString("key")
+	require.NoError(t, err)
+	require.Equal(t, "value", got)
+}
+
+func testMany(t *testing.T, cache gocache.Cache) {
+	expected := conformanceExample{Name: "hello", Description: "world"}
+	require.NoError(t, cache.PutMany(
+		gocache.Entry{Key: "string", Value: "string", Duration: time.Minute},
+		gocache.Entry{Key: "int64", Value: int64(100), Duration: time.Minute},
+		gocache.Entry{Key: "float64", Value: float64(100), Duration: time.Minute},
+		gocache.Entry{Key: "bool", Value: true, Duration: time.Minute},
+		gocache.Entry{Key: "struct", Value: expected, Duration: time.Minute},
+	))
+
+	items, err := cache.Many("string", "int64", "float64", "bool", "struct", "missing")
+	require.NoError(t, err)
+	require.Len(t, items, 6)
+
+	s, err := items["string"].String()
+	require.NoError(t, err)
+	require.Equal(t, "string", s)
+
+	i64, err := items["int64"].Int64()
+	require.NoError(t, err)
+	require.EqualValues(t, 100, i64)
+
+	f64, err := items["float64"].Float64()
+	require.NoError(t, err)
+	require.EqualValues(t, 100, f64)
+
+	b, err := items["bool"].Bool()
+	require.NoError(t, err)
+	require.True(t, b)
+
+	var got conformanceExample
+	require.NoError(t, items["struct"].Unmarshal(&got))
+	require.Equal(t, expected, got)
+	require.False(t, items["struct"].EntryNotFound())
+
+	require.True(t, items["missing"].EntryNotFound())
+	require.ErrorIs(t, items["missing"].Error(), gocache.ErrNotFound)
+
+	for key, item := range items {
+		require.Equal(t, key, item.Key())
+	}
+}
+
+func testExists(t *testing.T, cache gocache.Cache) {
+	require.NoError(t, cache.Put("key", 2, time.Minute))
+
+	exists, err := cache.Exists("key")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	_, err = cache.Forget("key")
+	require.NoError(t, err)
+
+	exists, err = cache.Exists("key")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func testAdd(t *testing.T, cache gocache.Cache) {
+	added, err := cache.Add("key", 2, time.Minute)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	added, err = cache.Add("key", 3, time.Minute)
+	require.NoError(t, err)
+	require.False(t, added)
+
+	got, err := cache.GetInt("key")
+	require.NoError(t, err)
+	require.Equal(t, 2, got)
+
+	added, err = cache.Add("struct", conformanceExample{Name: "first"}, time.Minute)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	added, err = cache.Add("struct", conformanceExample{Name: "second"}, time.Minute)
+	require.NoError(t, err)
+	require.False(t, added)
+
+	var e conformanceExample
+	require.NoError(t, cache.Get("struct", &e))
+	require.Equal(t, "first", e.Name)
+}
+
+func testAddRace(t *testing.T, cache gocache.Cache) {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		wins  int
+		errs  []error
+		racer = func() {
+			defer wg.Done()
+
+			added, err := cache.Add("race", "value", time.Minute)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if added {
+				wins++
+			}
+		}
+	)
+	wg.Add(20)
+	for i := 0; i < 20; i++ {
+		go racer()
+	}
+	wg.Wait()
+
+	require.Empty(t, errs)
+	require.Equal(t, 1, wins)
+}
+
+func testIncrementDecrement(t *testing.T, cache gocache.Cache) {
+	got, err := cache.Increment("counter", 2)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, got)
+
+	got, err = cache.Increment("counter", 8)
+	require.NoError(t, err)
+	require.EqualValues(t, 10, got)
+
+	got, err = cache.Decrement("counter", 9)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, got)
+
+	stored, err := cache.GetInt64("counter")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, stored)
+
+	// Stores either go negative or, like memcached, bottom out at zero
+	got, err = cache.Decrement("floor", 2)
+	require.NoError(t, err)
+	require.Contains(t, []int64{0, -2}, got)
+}
+
+func testIncrementAtomicity(t *testing.T, cache gocache.Cache) {
+	_, err := cache.Increment("counter", 1)
+	require.NoError(t, err)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	wg.Add(50)
+	for i := 0; i < 50; i++ {
+		go func() {
+			defer wg.Done()
+
+			if _, err := cache.Increment("counter", 1); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Empty(t, errs)
+
+	got, err := cache.GetInt64("counter")
+	require.NoError(t, err)
+	require.EqualValues(t, 51, got)
+}
+
+func testForget(t *testing.T, cache gocache.Cache) {
+	require.NoError(t, cache.Put("key", 1, time.Minute))
+
+	forgotten, err := cache.Forget("key")
+	require.NoError(t, err)
+	require.True(t, forgotten)
+
+	_, err = cache.GetInt("key")
+	require.ErrorIs(t, err, gocache.ErrNotFound)
+
+	forgotten, _ = cache.Forget("key")
+	require.False(t, forgotten)
+
+	require.NoError(t, cache.PutMany(
+		gocache.Entry{Key: "key1", Value: 1, Duration: time.Minute},
+		gocache.Entry{Key: "key2", Value: 2, Duration: time.Minute},
+		gocache.Entry{Key: "key3", Value: 3, Duration: time.Minute},
+	))
+	require.NoError(t, cache.ForgetMany("key1", "key2"))
+
+	items, err := cache.Many("key1", "key2", "key3")
+	require.NoError(t, err)
+	require.True(t, items["key1"].EntryNotFound())
+	require.True(t, items["key2"].EntryNotFound())
+
+	got, err := items["key3"].Int()
+	require.NoError(t, err)
+	require.Equal(t, 3, got)
+}
+
+func testExpiration(t *testing.T, cache gocache.Cache) {
+	require.NoError(t, cache.Put("key", "value", time.Second))
+	require.NoError(t, cache.Forever("forever", "value"))
+
+	exists, err := cache.Exists("key")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// Some backends, like memcached, expire entries with a one second granularity
+	wait(cache, 2*time.Second)
+
+	_, err = cache.GetString("key")
+	require.ErrorIs(t, err, gocache.ErrNotFound)
+
+	got, err := cache.GetString("forever")
+	require.NoError(t, err)
+	require.Equal(t, "value", got)
+
+	added, err := cache.Add("key", "other", time.Minute)
+	require.NoError(t, err)
+	require.True(t, added)
+}
+
+func testExpire(t *testing.T, cache gocache.Cache) {
+	require.NoError(t, cache.Put("key", "value", time.Second))
+
+	err := cache.Expire("key", time.Hour)
+	if errors.Is(err, gocache.ErrNotImplemented) {
+		t.Skip("Expire is not implemented")
+	}
+	require.NoError(t, err)
+
+	wait(cache, 2*time.Second)
+
+	got, err := cache.GetString("key")
+	require.NoError(t, err)
+	require.Equal(t, "value", got)
+}
+
+func testTags(t *testing.T, cache gocache.Cache) {
+	tagged := cache.Tags("people", "artists")
+	require.NoError(t, tagged.Put("key", "tagged", time.Minute))
+	require.NoError(t, cache.Put("key", "untagged", time.Minute))
+
+	got, err := cache.Tags("people", "artists").GetString("key")
+	require.NoError(t, err)
+	require.Equal(t, "tagged", got)
+
+	got, err = cache.GetString("key")
+	require.NoError(t, err)
+	require.Equal(t, "untagged", got)
+
+	_, err = cache.Tags("people").GetString("key")
+	require.ErrorIs(t, err, gocache.ErrNotFound)
+
+	added, err := tagged.Add("key", "other", time.Minute)
+	require.NoError(t, err)
+	require.False(t, added)
+
+	counter, err := tagged.Increment("counter", 3)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, counter)
+
+	require.NoError(t, tagged.PutMany(gocache.Entry{Key: "many", Value: 1, Duration: time.Minute}))
+
+	items, err := tagged.Many("many", "missing")
+	require.NoError(t, err)
+
+	i, err := items["many"].Int()
+	require.NoError(t, err)
+	require.Equal(t, 1, i)
+	require.True(t, items["missing"].EntryNotFound())
+
+	forgotten, err := tagged.Forget("many")
+	require.NoError(t, err)
+	require.True(t, forgotten)
+
+	exists, err := tagged.Exists("many")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func testTagsFlush(t *testing.T, cache gocache.Cache) {
+	require.NoError(t, cache.Tags("person", "dev").Put("joe", "doe", time.Minute))
+	require.NoError(t, cache.Tags("bot", "dev").Forever("bot", "doe"))
+	require.NoError(t, cache.Tags("person", "painter").Put("jane", "doe", time.Minute))
+	require.NoError(t, cache.Put("untagged", "value", time.Minute))
+
+	_, err := cache.Tags("dev").Flush()
+	require.NoError(t, err)
+
+	_, err = cache.Tags("person", "dev").GetString("joe")
+	require.ErrorIs(t, err, gocache.ErrNotFound)
+
+	_, err = cache.Tags("bot", "dev").GetString("bot")
+	require.ErrorIs(t, err, gocache.ErrNotFound)
+
+	got, err := cache.Tags("person", "painter").GetString("jane")
+	require.NoError(t, err)
+	require.Equal(t, "doe", got)
+
+	got, err = cache.GetString("untagged")
+	require.NoError(t, err)
+	require.Equal(t, "value", got)
+
+	require.NoError(t, cache.Tags("person", "dev").Put("joe", "smith", time.Minute))
+
+	got, err = cache.Tags("person", "dev").GetString("joe")
+	require.NoError(t, err)
+	require.Equal(t, "smith", got)
+}
+
+func testLock(t *testing.T, cache gocache.Cache) {
+	var (
+		first  = cache.Lock("lock", "first", time.Minute)
+		second = cache.Lock("lock", "second", time.Minute)
+	)
+	acquired, err := first.Acquire()
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, err = second.Acquire()
+	require.NoError(t, err)
+	require.False(t, acquired)
+
+	acquired, err = first.Acquire()
+	require.NoError(t, err)
+	require.False(t, acquired)
+
+	owner, err := second.GetCurrentOwner()
+	require.NoError(t, err)
+	require.Equal(t, "first", owner)
+
+	released, err := second.Release()
+	require.NoError(t, err)
+	require.False(t, released)
+
+	released, err = first.Release()
+	require.NoError(t, err)
+	require.True(t, released)
+
+	owner, err = first.GetCurrentOwner()
+	require.NoError(t, err)
+	require.Empty(t, owner)
+
+	acquired, err = second.Acquire()
+	require.NoError(t, err)
+	require.True(t, acquired)
+	require.NoError(t, first.ForceRelease())
+
+	acquired, err = first.Acquire()
+	require.NoError(t, err)
+	require.True(t, acquired)
+}
+
+func testLockGet(t *testing.T, cache gocache.Cache) {
+	var (
+		holder  = cache.Lock("lock", "holder", time.Minute)
+		other   = cache.Lock("lock", "other", time.Minute)
+		invoked bool
+		fn      = func() error {
+			invoked = true
+
+			return nil
+		}
+	)
+	acquired, err := holder.Acquire()
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, err = other.Get(fn)
+	require.NoError(t, err)
+	require.False(t, acquired)
+	require.False(t, invoked)
+
+	_, err = holder.Release()
+	require.NoError(t, err)
+
+	expected := errors.New("gocachetest: expected error")
+	acquired, err = other.Get(func() error {
+		invoked = true
+
+		return expected
+	})
+	require.ErrorIs(t, err, expected)
+	require.True(t, acquired)
+	require.True(t, invoked)
+
+	owner, err := other.GetCurrentOwner()
+	require.NoError(t, err)
+	require.Empty(t, owner)
+}
+
+func testLockBlock(t *testing.T, cache gocache.Cache) {
+	var (
+		holder = cache.Lock("lock", "holder", time.Minute)
+		other  = cache.Lock("lock", "other", time.Minute)
+		noop   = func() error { return nil }
+	)
+	acquired, err := holder.Acquire()
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, err = other.Block(10*time.Millisecond, 50*time.Millisecond, noop)
+	require.ErrorIs(t, err, gocache.ErrBlockWaitTimeout)
+	require.False(t, acquired)
+
+	_, err = holder.Release()
+	require.NoError(t, err)
+
+	acquired, err = other.Block(10*time.Millisecond, 50*time.Millisecond, noop)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	owner, err := other.GetCurrentOwner()
+	require.NoError(t, err)
+	require.Empty(t, owner)
+}
+
+func testLockExpire(t *testing.T, cache gocache.Cache) {
+	l := cache.Lock("lock", "owner", 10*time.Second)
+
+	acquired, err := l.Acquire()
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	expired, err := l.Expire(time.Hour)
+	if errors.Is(err, gocache.ErrNotImplemented) {
+		t.Skip("Lock.Expire is not implemented")
+	}
+	require.NoError(t, err)
+	require.True(t, expired)
+
+	owner, err := l.GetCurrentOwner()
+	require.NoError(t, err)
+	require.Equal(t, "owner", owner)
+}
+
+func testRateLimiter(t *testing.T, cache gocache.Cache) {
+	rateLimiter := gocache.NewRateLimiter(cache)
+	for i := 1; i <= 3; i++ {
+		hits, err := rateLimiter.Hit("limiter", time.Minute)
+		require.NoError(t, err)
+		require.EqualValues(t, i, hits)
+	}
+
+	attempts, err := rateLimiter.Attempts("limiter")
+	require.NoError(t, err)
+	require.EqualValues(t, 3, attempts)
+
+	left, err := rateLimiter.AttemptsLeft("limiter", 5)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, left)
+
+	tooMany, err := rateLimiter.TooManyAttempts("limiter", 3)
+	require.NoError(t, err)
+	require.True(t, tooMany)
+
+	tooMany, err = rateLimiter.TooManyAttempts("limiter", 5)
+	require.NoError(t, err)
+	require.False(t, tooMany)
+
+	require.NoError(t, rateLimiter.Clear("limiter"))
+
+	attempts, err = rateLimiter.Attempts("limiter")
+	require.NoError(t, err)
+	require.EqualValues(t, 0, attempts)
+}
+
+// wait advances the clock of caches exposing one and sleeps otherwise
+func wait(cache gocache.Cache, d time.Duration) {
+	if c, ok := cache.(interface{ Clock() *Clock }); ok {
+		c.Clock().Advance(d)
+
+		return
+	}
+
+	time.Sleep(d)
+}
@@ -0,0 +1,13 @@
+package gocachetest
+
+import (
+	"testing"
+
+	"github.com/alejandro-carstens/gocache"
+)
+
+func TestRunConformance_Fake(t *testing.T) {
+	RunConformance(t, func() gocache.Cache {
+		return NewFake()
+	})
+}
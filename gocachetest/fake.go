@@ -0,0 +1,492 @@
+// Package gocachetest provides utilities for testing code that depends on gocache
+package gocachetest
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alejandro-carstens/gocache"
+	"github.com/alejandro-carstens/gocache/encoder"
+)
+
+var (
+	_ gocache.Cache       = &Fake{}
+	_ gocache.TaggedCache = &FakeTaggedCache{}
+)
+
+type (
+	// backend represents the caching methods shared by gocache.Cache and gocache.TaggedCache
+	backend interface {
+		GetString(key string) (string, error)
+		Put(key string, value interface{}, duration time.Duration) error
+		Add(key string, value interface{}, duration time.Duration) (bool, error)
+		Increment(key string, value int64) (int64, error)
+		Decrement(key string, value int64) (int64, error)
+		Forget(key string) (bool, error)
+		ForgetMany(keys ...string) error
+		Forever(key string, value interface{}) error
+		Flush() (bool, error)
+		GetInt64(key string) (int64, error)
+		GetInt(key string) (int, error)
+		GetFloat64(key string) (float64, error)
+		GetFloat32(key string) (float32, error)
+		GetUint64(key string) (uint64, error)
+		GetBool(key string) (bool, error)
+		GetTime(key string) (time.Time, error)
+		GetDuration(key string) (time.Duration, error)
+		GetBytes(key string) ([]byte, error)
+		PutBytes(key string, value []byte, duration time.Duration) error
+		Prefix() string
+		Many(keys ...string) (gocache.Items, error)
+		PutMany(entries ...gocache.Entry) error
+		Get(key string, entity interface{}) error
+		Close() error
+		Exists(key string) (bool, error)
+	}
+	// Operation represents a call made to a Fake, a FakeTaggedCache or a FakeLock
+	Operation struct {
+		// Method is the name of the method that was called, e.g. Put
+		Method string
+		// Key is the key or lock name the method was called with
+		Key string
+		// Value is the value the method was called with if any
+		Value interface{}
+		// Duration is the TTL or lock duration the method was called with if any
+		Duration time.Duration
+		// Tags are the tags of the tagged cache the method was called on if any
+		Tags []string
+		// Owner is the owner of the lock the method was called on if any
+		Owner string
+		// Acquired reports whether a lock was acquired by the call
+		Acquired bool
+	}
+	// view implements the caching methods for both the Fake and its tagged caches
+	view struct {
+		fake  *Fake
+		store backend
+		tags  []string
+	}
+	// Fake is an in-memory gocache.Cache implementation meant for unit tests. It records every operation
+	// performed against it, exposes assertion helpers and relies on a manually controlled Clock for expiration
+	Fake struct {
+		view
+		local       *gocache.LocalStore
+		clock       *Clock
+		mu          sync.Mutex
+		operations  []Operation
+		expirations map[string]time.Time
+		locks       map[string]fakeLockState
+	}
+	// FakeTaggedCache is the gocache.TaggedCache implementation returned by Fake.Tags
+	FakeTaggedCache struct {
+		view
+		tagged gocache.TaggedCache
+	}
+)
+
+// NewFake creates a *Fake whose clock is set to the current time. Values are encoded via encoder.JSON
+func NewFake() *Fake {
+	return NewFakeWithEncoder(encoder.JSON{})
+}
+
+// NewFakeWithEncoder creates a *Fake whose clock is set to the current time and which encodes values with the
+// given encoder
+func NewFakeWithEncoder(enc encoder.Encoder) *Fake {
+	local, _ := gocache.NewLocalStore(&gocache.LocalConfig{}, enc)
+
+	f := &Fake{
+		local:       local,
+		clock:       NewClock(time.Now()),
+		expirations: map[string]time.Time{},
+		locks:       map[string]fakeLockState{},
+	}
+	f.view = view{
+		fake:  f,
+		store: local,
+	}
+
+	return f
+}
+
+// Clock returns the clock used to determine whether entries and locks have expired
+func (f *Fake) Clock() *Clock {
+	return f.clock
+}
+
+// Tags returns a FakeTaggedCache for the given tags
+func (f *Fake) Tags(names ...string) gocache.TaggedCache {
+	// The tag set resolves tag IDs in place, hence the copy
+	tagged := f.local.Tags(append([]string(nil), names...)...)
+
+	return &FakeTaggedCache{
+		view: view{
+			fake:  f,
+			store: tagged,
+			tags:  names,
+		},
+		tagged: tagged,
+	}
+}
+
+// Lock returns a FakeLock
+func (f *Fake) Lock(name, owner string, duration time.Duration) gocache.Lock {
+	return &FakeLock{
+		fake:     f,
+		name:     name,
+		owner:    owner,
+		duration: duration,
+	}
+}
+
+// Operations returns a copy of all the operations recorded so far
+func (f *Fake) Operations() []Operation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	operations := make([]Operation, len(f.operations))
+	copy(operations, f.operations)
+
+	return operations
+}
+
+// Reset clears all the recorded operations
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.operations = nil
+}
+
+// TagSet returns the underlying tagged cache tag set
+func (tc *FakeTaggedCache) TagSet() *gocache.TagSet {
+	return tc.tagged.TagSet()
+}
+
+// Keys implementation of the gocache.TaggedCache interface. Keys expired according to the fake clock are skipped
+func (tc *FakeTaggedCache) Keys(cursor string, count int) ([]string, string, error) {
+	tc.record(Operation{Method: "Keys"})
+
+	keys, next, err := tc.tagged.Keys(cursor, count)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var live = make([]string, 0, len(keys))
+	for _, key := range keys {
+		tc.purge(key)
+
+		exists, err := tc.tagged.Exists(key)
+		if err != nil {
+			return nil, "", err
+		}
+		if exists {
+			live = append(live, key)
+		}
+	}
+
+	return live, next, nil
+}
+
+// GetString implementation of the gocache.Cache interface
+func (v *view) GetString(key string) (string, error) {
+	v.record(Operation{Method: "GetString", Key: key})
+	v.purge(key)
+
+	return v.store.GetString(key)
+}
+
+// GetInt64 implementation of the gocache.Cache interface
+func (v *view) GetInt64(key string) (int64, error) {
+	v.record(Operation{Method: "GetInt64", Key: key})
+	v.purge(key)
+
+	return v.store.GetInt64(key)
+}
+
+// GetInt implementation of the gocache.Cache interface
+func (v *view) GetInt(key string) (int, error) {
+	v.record(Operation{Method: "GetInt", Key: key})
+	v.purge(key)
+
+	return v.store.GetInt(key)
+}
+
+// GetFloat64 implementation of the gocache.Cache interface
+func (v *view) GetFloat64(key string) (float64, error) {
+	v.record(Operation{Method: "GetFloat64", Key: key})
+	v.purge(key)
+
+	return v.store.GetFloat64(key)
+}
+
+// GetFloat32 implementation of the gocache.Cache interface
+func (v *view) GetFloat32(key string) (float32, error) {
+	v.record(Operation{Method: "GetFloat32", Key: key})
+	v.purge(key)
+
+	return v.store.GetFloat32(key)
+}
+
+// GetUint64 implementation of the gocache.Cache interface
+func (v *view) GetUint64(key string) (uint64, error) {
+	v.record(Operation{Method: "GetUint64", Key: key})
+	v.purge(key)
+
+	return v.store.GetUint64(key)
+}
+
+// GetBool implementation of the gocache.Cache interface
+func (v *view) GetBool(key string) (bool, error) {
+	v.record(Operation{Method: "GetBool", Key: key})
+	v.purge(key)
+
+	return v.store.GetBool(key)
+}
+
+// GetTime implementation of the gocache.Cache interface
+func (v *view) GetTime(key string) (time.Time, error) {
+	v.record(Operation{Method: "GetTime", Key: key})
+	v.purge(key)
+
+	return v.store.GetTime(key)
+}
+
+// GetDuration implementation of the gocache.Cache interface
+func (v *view) GetDuration(key string) (time.Duration, error) {
+	v.record(Operation{Method: "GetDuration", Key: key})
+	v.purge(key)
+
+	return v.store.GetDuration(key)
+}
+
+// GetBytes implementation of the gocache.Cache interface
+func (v *view) GetBytes(key string) ([]byte, error) {
+	v.record(Operation{Method: "GetBytes", Key: key})
+	v.purge(key)
+
+	return v.store.GetBytes(key)
+}
+
+// Get implementation of the gocache.Cache interface
+func (v *view) Get(key string, entity interface{}) error {
+	v.record(Operation{Method: "Get", Key: key})
+	v.purge(key)
+
+	return v.store.Get(key, entity)
+}
+
+// Many implementation of the gocache.Cache interface
+func (v *view) Many(keys ...string) (gocache.Items, error) {
+	for _, key := range keys {
+		v.record(Operation{Method: "Many", Key: key})
+		v.purge(key)
+	}
+
+	return v.store.Many(keys...)
+}
+
+// Exists implementation of the gocache.Cache interface
+func (v *view) Exists(key string) (bool, error) {
+	v.record(Operation{Method: "Exists", Key: key})
+	v.purge(key)
+
+	return v.store.Exists(key)
+}
+
+// Put implementation of the gocache.Cache interface
+func (v *view) Put(key string, value interface{}, duration time.Duration) error {
+	v.record(Operation{Method: "Put", Key: key, Value: value, Duration: duration})
+	if err := v.store.Put(key, value, -1); err != nil {
+		return err
+	}
+
+	v.expire(key, duration)
+
+	return nil
+}
+
+// PutBytes implementation of the gocache.Cache interface
+func (v *view) PutBytes(key string, value []byte, duration time.Duration) error {
+	v.record(Operation{Method: "PutBytes", Key: key, Value: value, Duration: duration})
+	if err := v.store.PutBytes(key, value, -1); err != nil {
+		return err
+	}
+
+	v.expire(key, duration)
+
+	return nil
+}
+
+// Add implementation of the gocache.Cache interface
+func (v *view) Add(key string, value interface{}, duration time.Duration) (bool, error) {
+	v.record(Operation{Method: "Add", Key: key, Value: value, Duration: duration})
+	v.purge(key)
+
+	added, err := v.store.Add(key, value, -1)
+	if err != nil || !added {
+		return added, err
+	}
+
+	v.expire(key, duration)
+
+	return true, nil
+}
+
+// Forever implementation of the gocache.Cache interface
+func (v *view) Forever(key string, value interface{}) error {
+	v.record(Operation{Method: "Forever", Key: key, Value: value})
+	if err := v.store.Forever(key, value); err != nil {
+		return err
+	}
+
+	v.expire(key, 0)
+
+	return nil
+}
+
+// PutMany implementation of the gocache.Cache interface
+func (v *view) PutMany(entries ...gocache.Entry) error {
+	var forever = make([]gocache.Entry, len(entries))
+	for i, entry := range entries {
+		v.record(Operation{Method: "PutMany", Key: entry.Key, Value: entry.Value, Duration: entry.Duration})
+
+		entry.Duration = -1
+		forever[i] = entry
+	}
+	if err := v.store.PutMany(forever...); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		v.expire(entry.Key, entry.Duration)
+	}
+
+	return nil
+}
+
+// Increment implementation of the gocache.Cache interface
+func (v *view) Increment(key string, value int64) (int64, error) {
+	v.record(Operation{Method: "Increment", Key: key, Value: value})
+	v.purge(key)
+
+	return v.store.Increment(key, value)
+}
+
+// Decrement implementation of the gocache.Cache interface
+func (v *view) Decrement(key string, value int64) (int64, error) {
+	v.record(Operation{Method: "Decrement", Key: key, Value: value})
+	v.purge(key)
+
+	return v.store.Decrement(key, value)
+}
+
+// Forget implementation of the gocache.Cache interface
+func (v *view) Forget(key string) (bool, error) {
+	v.record(Operation{Method: "Forget", Key: key})
+	v.purge(key)
+	v.expire(key, 0)
+
+	return v.store.Forget(key)
+}
+
+// ForgetMany implementation of the gocache.Cache interface
+func (v *view) ForgetMany(keys ...string) error {
+	for _, key := range keys {
+		v.record(Operation{Method: "ForgetMany", Key: key})
+		v.expire(key, 0)
+	}
+
+	return v.store.ForgetMany(keys...)
+}
+
+// Flush implementation of the gocache.Cache interface
+func (v *view) Flush() (bool, error) {
+	v.record(Operation{Method: "Flush"})
+	if len(v.tags) == 0 {
+		v.fake.mu.Lock()
+		v.fake.expirations = map[string]time.Time{}
+		v.fake.mu.Unlock()
+	}
+
+	return v.store.Flush()
+}
+
+// Expire implementation of the gocache.Cache interface
+func (v *view) Expire(key string, duration time.Duration) error {
+	v.record(Operation{Method: "Expire", Key: key, Duration: duration})
+	v.purge(key)
+
+	exists, err := v.store.Exists(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return gocache.ErrNotFound
+	}
+	if duration <= 0 {
+		v.expire(key, 0)
+		_, err = v.store.Forget(key)
+
+		return err
+	}
+
+	v.expire(key, duration)
+
+	return nil
+}
+
+// Prefix implementation of the gocache.Cache interface
+func (v *view) Prefix() string {
+	return v.store.Prefix()
+}
+
+// Close implementation of the gocache.Cache interface
+func (v *view) Close() error {
+	v.record(Operation{Method: "Close"})
+
+	return nil
+}
+
+func (v *view) record(op Operation) {
+	op.Tags = v.tags
+
+	v.fake.mu.Lock()
+	defer v.fake.mu.Unlock()
+
+	v.fake.operations = append(v.fake.operations, op)
+}
+
+// expire sets the expiration of the given key according to the fake clock, a duration lower or equal to 0
+// meaning that the key never expires
+func (v *view) expire(key string, duration time.Duration) {
+	v.fake.mu.Lock()
+	defer v.fake.mu.Unlock()
+
+	if duration <= 0 {
+		delete(v.fake.expirations, v.expirationKey(key))
+
+		return
+	}
+
+	v.fake.expirations[v.expirationKey(key)] = v.fake.clock.Now().Add(duration)
+}
+
+// purge evicts the given key if it has expired according to the fake clock
+func (v *view) purge(key string) {
+	v.fake.mu.Lock()
+	expiration, exists := v.fake.expirations[v.expirationKey(key)]
+	expired := exists && !v.fake.clock.Now().Before(expiration)
+	if expired {
+		delete(v.fake.expirations, v.expirationKey(key))
+	}
+	v.fake.mu.Unlock()
+
+	if expired {
+		_, _ = v.store.Forget(key)
+	}
+}
+
+func (v *view) expirationKey(key string) string {
+	return strings.Join(v.tags, "|") + "\x00" + key
+}
@@ -0,0 +1,181 @@
+package gocachetest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alejandro-carstens/gocache"
+)
+
+var _ gocache.Lock = &FakeLock{}
+
+type (
+	fakeLockState struct {
+		owner      string
+		expiration time.Time
+	}
+	// FakeLock is the gocache.Lock implementation returned by Fake.Lock. Lock expiration is determined by the
+	// Fake clock
+	FakeLock struct {
+		fake     *Fake
+		name     string
+		owner    string
+		duration time.Duration
+	}
+)
+
+// Acquire implementation of the gocache.Lock interface
+func (l *FakeLock) Acquire() (bool, error) {
+	l.fake.mu.Lock()
+	defer l.fake.mu.Unlock()
+
+	_, held := l.current()
+	if !held {
+		state := fakeLockState{owner: l.owner}
+		if l.duration > 0 {
+			state.expiration = l.fake.clock.Now().Add(l.duration)
+		}
+
+		l.fake.locks[l.name] = state
+	}
+
+	l.fake.operations = append(l.fake.operations, Operation{
+		Method:   "Acquire",
+		Key:      l.name,
+		Duration: l.duration,
+		Owner:    l.owner,
+		Acquired: !held,
+	})
+
+	return !held, nil
+}
+
+// Release implementation of the gocache.Lock interface
+func (l *FakeLock) Release() (bool, error) {
+	l.fake.mu.Lock()
+	defer l.fake.mu.Unlock()
+
+	l.fake.operations = append(l.fake.operations, Operation{Method: "Release", Key: l.name, Owner: l.owner})
+
+	state, held := l.current()
+	if !held || state.owner != l.owner {
+		return false, nil
+	}
+
+	delete(l.fake.locks, l.name)
+
+	return true, nil
+}
+
+// ForceRelease implementation of the gocache.Lock interface
+func (l *FakeLock) ForceRelease() error {
+	l.fake.mu.Lock()
+	defer l.fake.mu.Unlock()
+
+	l.fake.operations = append(l.fake.operations, Operation{Method: "ForceRelease", Key: l.name, Owner: l.owner})
+	delete(l.fake.locks, l.name)
+
+	return nil
+}
+
+// GetCurrentOwner implementation of the gocache.Lock interface
+func (l *FakeLock) GetCurrentOwner() (string, error) {
+	l.fake.mu.Lock()
+	defer l.fake.mu.Unlock()
+
+	state, held := l.current()
+	if !held {
+		return "", nil
+	}
+
+	return state.owner, nil
+}
+
+// Expire implementation of the gocache.Lock interface
+func (l *FakeLock) Expire(duration time.Duration) (bool, error) {
+	l.fake.mu.Lock()
+	defer l.fake.mu.Unlock()
+
+	l.fake.operations = append(l.fake.operations, Operation{
+		Method:   "Expire",
+		Key:      l.name,
+		Duration: duration,
+		Owner:    l.owner,
+	})
+
+	state, held := l.current()
+	if !held || state.owner != l.owner {
+		return false, nil
+	}
+
+	state.expiration = time.Time{}
+	if duration > 0 {
+		state.expiration = l.fake.clock.Now().Add(duration)
+	}
+
+	l.fake.locks[l.name] = state
+
+	return true, nil
+}
+
+// Get attempts to acquire a lock. If acquired, fn will be invoked and the lock will be safely release once
+// the invocation either succeeds or errors
+func (l *FakeLock) Get(fn func() error) (acquired bool, err error) {
+	acquired, err = l.Acquire()
+	if err != nil || !acquired {
+		return acquired, err
+	}
+
+	return true, l.run(fn)
+}
+
+// Block will attempt to acquire a lock for the specified "wait" time. Instead of sleeping between attempts the
+// Fake clock is advanced by interval, which allows for testing lock contention without real waits
+func (l *FakeLock) Block(interval, wait time.Duration, fn func() error) (acquired bool, err error) {
+	starting := l.fake.clock.Now()
+	for {
+		acquired, err = l.Acquire()
+		if err != nil {
+			return false, err
+		}
+		if acquired {
+			break
+		}
+
+		l.fake.clock.Advance(interval)
+		if l.fake.clock.Now().Add(-wait).After(starting) {
+			return false, gocache.ErrBlockWaitTimeout
+		}
+	}
+
+	return true, l.run(fn)
+}
+
+func (l *FakeLock) run(fn func() error) (err error) {
+	defer func() {
+		if _, releaseErr := l.Release(); releaseErr == nil {
+			return
+		} else if err != nil {
+			err = fmt.Errorf("gocache: %v: %v", err.Error(), releaseErr)
+		} else {
+			err = releaseErr
+		}
+	}()
+
+	return fn()
+}
+
+// current returns the state of the lock if it is currently held. The Fake mutex must be held by the caller
+func (l *FakeLock) current() (fakeLockState, bool) {
+	state, exists := l.fake.locks[l.name]
+	if !exists {
+		return state, false
+	}
+	if !state.expiration.IsZero() && !l.fake.clock.Now().Before(state.expiration) {
+		delete(l.fake.locks, l.name)
+
+		return state, false
+	}
+
+	return state, true
+}
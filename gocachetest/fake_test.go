@@ -0,0 +1,156 @@
+package gocachetest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alejandro-carstens/gocache"
+)
+
+type example struct {
+	Name string
+}
+
+func TestFake_Expiration(t *testing.T) {
+	f := NewFake()
+
+	require.NoError(t, f.Put("key", example{Name: "gocache"}, time.Minute))
+	require.NoError(t, f.Forever("forever", 1))
+
+	var e example
+	require.NoError(t, f.Get("key", &e))
+	require.Equal(t, "gocache", e.Name)
+
+	f.Clock().Advance(time.Minute)
+
+	require.ErrorIs(t, f.Get("key", &e), gocache.ErrNotFound)
+
+	v, err := f.GetInt("forever")
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+
+	added, err := f.Add("key", "value", time.Second)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	require.NoError(t, f.Expire("key", time.Hour))
+	f.Clock().Advance(time.Minute)
+
+	exists, err := f.Exists("key")
+	require.NoError(t, err)
+	require.True(t, exists)
+}
+
+func TestFake_Tags(t *testing.T) {
+	f := NewFake()
+
+	tc := f.Tags("users", "posts")
+	require.NoError(t, tc.Put("key", "value", time.Second))
+
+	exists, err := f.Exists("key")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	keys, _, err := tc.Keys("", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"key"}, keys)
+
+	f.Clock().Advance(time.Second)
+
+	keys, _, err = tc.Keys("", 0)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	_, err = tc.GetString("key")
+	require.ErrorIs(t, err, gocache.ErrNotFound)
+
+	require.NoError(t, tc.Forever("key", "value"))
+	_, err = tc.Flush()
+	require.NoError(t, err)
+
+	_, err = tc.GetString("key")
+	require.ErrorIs(t, err, gocache.ErrNotFound)
+
+	f.AssertPutWithDuration(t, "key", "value", time.Second, "users", "posts")
+	f.AssertFlushed(t, "users", "posts")
+	f.AssertNotPut(t, "key")
+}
+
+func TestFake_Lock(t *testing.T) {
+	f := NewFake()
+
+	l := f.Lock("lock", "first", time.Minute)
+	acquired, err := l.Acquire()
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, err = f.Lock("lock", "second", time.Minute).Acquire()
+	require.NoError(t, err)
+	require.False(t, acquired)
+
+	var invoked bool
+	acquired, err = f.Lock("lock", "second", time.Minute).Block(time.Second, 2*time.Minute, func() error {
+		invoked = true
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, acquired)
+	require.True(t, invoked)
+
+	owner, err := l.GetCurrentOwner()
+	require.NoError(t, err)
+	require.Empty(t, owner)
+
+	_, err = f.Lock("other", "third", time.Minute).Acquire()
+	require.NoError(t, err)
+
+	_, err = f.Lock("other", "fourth", time.Minute).Block(time.Second, 10*time.Second, func() error { return nil })
+	require.ErrorIs(t, err, gocache.ErrBlockWaitTimeout)
+
+	f.AssertLockAcquired(t, "lock", "first")
+	f.AssertLockAcquired(t, "lock", "second")
+	f.AssertLockNotAcquired(t, "other", "fourth")
+}
+
+func TestFake_Assertions(t *testing.T) {
+	f := NewFake()
+
+	require.NoError(t, f.PutMany(gocache.Entry{Key: "a", Value: 1, Duration: time.Minute}))
+	_, err := f.Forget("a")
+	require.NoError(t, err)
+	_, err = f.Many("a", "b")
+	require.NoError(t, err)
+
+	f.AssertPut(t, "a", 1)
+	f.AssertPutWithDuration(t, "a", 1, time.Minute)
+	f.AssertForgotten(t, "a")
+	f.AssertCalled(t, "Many", "a", "b")
+	f.AssertCalledTimes(t, "Many", 2)
+	f.AssertNotCalled(t, "Flush")
+
+	mock := &recordingT{}
+	f.AssertPut(mock, "a", 2)
+	f.AssertNotCalled(mock, "Forget", "a")
+	require.Equal(t, []string{
+		`gocachetest: expected "a" to be put with value 2 and tags []`,
+		`gocachetest: expected Forget not to be called with "a"`,
+	}, mock.errors)
+
+	f.Reset()
+	require.Empty(t, f.Operations())
+}
+
+// recordingT records the errors reported by the assertions of Fake
+type recordingT struct {
+	errors []string
+}
+
+func (*recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,474 @@
+package testserver
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memcacheRelativeExpirationLimit is the largest expiration, in seconds, memcached treats as relative to the
+// current time, larger values being unix timestamps
+const memcacheRelativeExpirationLimit = 60 * 60 * 24 * 30
+
+// MemcacheMaxItemSize is the size of the largest value accepted by Memcache, which mirrors the default memcached
+// item size limit. Larger values are rejected with SERVER_ERROR object too large for cache
+const MemcacheMaxItemSize = 1024 * 1024
+
+type (
+	memcacheItem struct {
+		value    []byte
+		flags    uint32
+		cas      uint64
+		expireAt time.Time
+	}
+	// Memcache is an in-process memcached stand-in speaking the text protocol commands used by gocache: get, gets,
+	// set, add, replace, append, prepend, cas, incr, decr, touch, delete, flush_all, version and quit
+	Memcache struct {
+		*listener
+		mu    sync.Mutex
+		items map[string]*memcacheItem
+		cas   uint64
+		stats memcacheStats
+	}
+	// memcacheStats records the commands received by a Memcache and how many of them were in flight at once
+	memcacheStats struct {
+		mu          sync.Mutex
+		latency     time.Duration
+		commands    map[string]int
+		inFlight    int
+		maxInFlight int
+	}
+)
+
+// NewMemcache starts a Memcache stand-in listening on an ephemeral loopback port
+func NewMemcache() (*Memcache, error) {
+	s := &Memcache{
+		items: map[string]*memcacheItem{},
+		stats: memcacheStats{commands: map[string]int{}},
+	}
+
+	l, err := listen(s.serve)
+	if err != nil {
+		return nil, err
+	}
+
+	s.listener = l
+
+	return s, nil
+}
+
+// Len returns the number of live items currently held by the server
+func (s *Memcache) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	for key := range s.items {
+		if s.lookup(key) != nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+// SetLatency delays the handling of every subsequent command by d, which allows for concurrent requests to overlap
+func (s *Memcache) SetLatency(d time.Duration) {
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+
+	s.stats.latency = d
+}
+
+// Commands returns the number of times the given command, e.g. gets, has been received
+func (s *Memcache) Commands(name string) int {
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+
+	return s.stats.commands[name]
+}
+
+// MaxInFlight returns the highest number of commands that were being handled at once across all connections
+func (s *Memcache) MaxInFlight() int {
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+
+	return s.stats.maxInFlight
+}
+
+// begin records the reception of the given command and waits for the configured latency
+func (s *Memcache) begin(cmd string) {
+	s.stats.mu.Lock()
+	s.stats.commands[cmd]++
+	s.stats.inFlight++
+	if s.stats.inFlight > s.stats.maxInFlight {
+		s.stats.maxInFlight = s.stats.inFlight
+	}
+	latency := s.stats.latency
+	s.stats.mu.Unlock()
+
+	time.Sleep(latency)
+}
+
+// end records that a command has been handled
+func (s *Memcache) end() {
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+
+	s.stats.inFlight--
+}
+
+// FastForward moves the expiration of every item with a TTL back by d, as if d had elapsed
+func (s *Memcache) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.items {
+		if !item.expireAt.IsZero() {
+			item.expireAt = item.expireAt.Add(-d)
+		}
+	}
+}
+
+func (s *Memcache) serve(r *bufio.Reader, w *bufio.Writer) error {
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		s.begin(fields[0])
+		err = s.handle(r, w, fields)
+		s.end()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handle handles a single command flushing its reply unless further commands have been pipelined. io.EOF is
+// returned once the client quits
+func (s *Memcache) handle(r *bufio.Reader, w *bufio.Writer, fields []string) error {
+	switch cmd := fields[0]; cmd {
+	case "get", "gets":
+		s.get(w, fields[1:], cmd == "gets")
+	case "set", "add", "replace", "append", "prepend", "cas":
+		if err := s.store(r, w, cmd, fields[1:]); err != nil {
+			return err
+		}
+	case "incr", "decr":
+		s.incr(w, cmd == "decr", fields[1:])
+	case "touch":
+		s.touch(w, fields[1:])
+	case "delete":
+		s.delete(w, fields[1:])
+	case "flush_all":
+		s.mu.Lock()
+		s.items = map[string]*memcacheItem{}
+		s.mu.Unlock()
+
+		reply(w, fields, "OK")
+	case "version":
+		_, _ = w.WriteString("VERSION 1.6.0-testserver\r\n")
+	case "quit":
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		return io.EOF
+	default:
+		_, _ = w.WriteString("ERROR\r\n")
+	}
+
+	if r.Buffered() == 0 {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+func (s *Memcache) get(w *bufio.Writer, keys []string, withCAS bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		item := s.lookup(key)
+		if item == nil {
+			continue
+		}
+
+		_, _ = w.WriteString("VALUE " + key + " " + strconv.FormatUint(uint64(item.flags), 10) + " " +
+			strconv.Itoa(len(item.value)))
+		if withCAS {
+			_, _ = w.WriteString(" " + strconv.FormatUint(item.cas, 10))
+		}
+
+		_, _ = w.WriteString("\r\n")
+		_, _ = w.Write(item.value)
+		_, _ = w.WriteString("\r\n")
+	}
+
+	_, _ = w.WriteString("END\r\n")
+}
+
+// store handles the storage commands: <cmd> <key> <flags> <exptime> <bytes> [<cas unique>] [noreply]
+func (s *Memcache) store(r *bufio.Reader, w *bufio.Writer, cmd string, args []string) error {
+	var minArgs = 4
+	if cmd == "cas" {
+		minArgs = 5
+	}
+	if len(args) < minArgs {
+		_, _ = w.WriteString("ERROR\r\n")
+
+		return nil
+	}
+
+	size, err := strconv.Atoi(args[3])
+	if err != nil || size < 0 {
+		_, _ = w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+
+		return nil
+	}
+
+	data := make([]byte, size+2)
+	if _, err = io.ReadFull(r, data); err != nil {
+		return err
+	}
+
+	flags, flagsErr := strconv.ParseUint(args[1], 10, 32)
+	exptime, exptimeErr := strconv.ParseInt(args[2], 10, 64)
+	if flagsErr != nil || exptimeErr != nil || string(data[size:]) != "\r\n" {
+		_, _ = w.WriteString("CLIENT_ERROR bad command line format\r\n")
+
+		return nil
+	}
+
+	if size > MemcacheMaxItemSize {
+		_, _ = w.WriteString("SERVER_ERROR object too large for cache\r\n")
+
+		return nil
+	}
+
+	var cas uint64
+	if cmd == "cas" {
+		if cas, err = strconv.ParseUint(args[4], 10, 64); err != nil {
+			_, _ = w.WriteString("CLIENT_ERROR bad command line format\r\n")
+
+			return nil
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		key     = args[0]
+		value   = data[:size]
+		current = s.lookup(key)
+	)
+	switch cmd {
+	case "add":
+		if current != nil {
+			reply(w, args, "NOT_STORED")
+
+			return nil
+		}
+	case "replace":
+		if current == nil {
+			reply(w, args, "NOT_STORED")
+
+			return nil
+		}
+	case "append", "prepend":
+		if current == nil {
+			reply(w, args, "NOT_STORED")
+
+			return nil
+		}
+		if cmd == "append" {
+			current.value = append(append([]byte{}, current.value...), value...)
+		} else {
+			current.value = append(append([]byte{}, value...), current.value...)
+		}
+
+		current.cas = s.nextCAS()
+		reply(w, args, "STORED")
+
+		return nil
+	case "cas":
+		if current == nil {
+			reply(w, args, "NOT_FOUND")
+
+			return nil
+		}
+		if current.cas != cas {
+			reply(w, args, "EXISTS")
+
+			return nil
+		}
+	}
+
+	s.items[key] = &memcacheItem{
+		value:    value,
+		flags:    uint32(flags),
+		cas:      s.nextCAS(),
+		expireAt: expiration(exptime),
+	}
+	if !s.items[key].expireAt.IsZero() && !time.Now().Before(s.items[key].expireAt) {
+		delete(s.items, key)
+	}
+
+	reply(w, args, "STORED")
+
+	return nil
+}
+
+// incr handles incr and decr: <cmd> <key> <value> [noreply]. Decrementing below zero yields zero
+func (s *Memcache) incr(w *bufio.Writer, decr bool, args []string) {
+	if len(args) < 2 {
+		_, _ = w.WriteString("ERROR\r\n")
+
+		return
+	}
+
+	delta, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		_, _ = w.WriteString("CLIENT_ERROR invalid numeric delta argument\r\n")
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := s.lookup(args[0])
+	if item == nil {
+		reply(w, args, "NOT_FOUND")
+
+		return
+	}
+
+	current, err := strconv.ParseUint(strings.TrimSpace(string(item.value)), 10, 64)
+	if err != nil {
+		reply(w, args, "CLIENT_ERROR cannot increment or decrement non-numeric value")
+
+		return
+	}
+
+	switch {
+	case !decr:
+		current += delta
+	case delta > current:
+		current = 0
+	default:
+		current -= delta
+	}
+
+	item.value = []byte(strconv.FormatUint(current, 10))
+	item.cas = s.nextCAS()
+
+	reply(w, args, string(item.value))
+}
+
+// touch handles touch: touch <key> <exptime> [noreply]
+func (s *Memcache) touch(w *bufio.Writer, args []string) {
+	if len(args) < 2 {
+		_, _ = w.WriteString("ERROR\r\n")
+
+		return
+	}
+
+	exptime, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		_, _ = w.WriteString("CLIENT_ERROR invalid exptime argument\r\n")
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := s.lookup(args[0])
+	if item == nil {
+		reply(w, args, "NOT_FOUND")
+
+		return
+	}
+
+	item.expireAt = expiration(exptime)
+	reply(w, args, "TOUCHED")
+}
+
+// delete handles delete: delete <key> [noreply]
+func (s *Memcache) delete(w *bufio.Writer, args []string) {
+	if len(args) < 1 {
+		_, _ = w.WriteString("ERROR\r\n")
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lookup(args[0]) == nil {
+		reply(w, args, "NOT_FOUND")
+
+		return
+	}
+
+	delete(s.items, args[0])
+	reply(w, args, "DELETED")
+}
+
+// lookup returns the item stored under key evicting it if it has expired. The server mutex must be held by the
+// caller
+func (s *Memcache) lookup(key string) *memcacheItem {
+	item, exists := s.items[key]
+	if !exists {
+		return nil
+	}
+	if !item.expireAt.IsZero() && !time.Now().Before(item.expireAt) {
+		delete(s.items, key)
+
+		return nil
+	}
+
+	return item
+}
+
+func (s *Memcache) nextCAS() uint64 {
+	s.cas++
+
+	return s.cas
+}
+
+// expiration converts a memcached exptime to an absolute time, the zero time meaning that the item never expires
+func expiration(exptime int64) time.Time {
+	switch {
+	case exptime == 0:
+		return time.Time{}
+	case exptime < 0:
+		return time.Now()
+	case exptime > memcacheRelativeExpirationLimit:
+		return time.Unix(exptime, 0)
+	}
+
+	return time.Now().Add(time.Duration(exptime) * time.Second)
+}
+
+// reply writes the given response unless the command line ends with noreply
+func reply(w *bufio.Writer, args []string, response string) {
+	if len(args) > 0 && args[len(args)-1] == "noreply" {
+		return
+	}
+
+	_, _ = w.WriteString(response + "\r\n")
+}
@@ -0,0 +1,1305 @@
+package testserver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	redisErrSyntax     = redisError("ERR syntax error")
+	redisErrNotInteger = redisError("ERR value is not an integer or out of range")
+	redisErrWrongType  = redisError("WRONGTYPE Operation against a key holding the wrong kind of value")
+	redisErrExpireTime = redisError("ERR invalid expire time in 'set' command")
+)
+
+type (
+	// redisStatus represents a RESP simple string reply
+	redisStatus string
+	// redisError represents a RESP error reply
+	redisError string
+	// redisKind represents the type of the value held by a key
+	redisKind int
+	// redisValue represents a key held by the server, either a string, a list, a set or a sorted set
+	redisValue struct {
+		kind     redisKind
+		str      []byte
+		list     [][]byte
+		set      map[string]struct{}
+		zset     map[string]float64
+		expireAt time.Time
+	}
+	redisCommand struct {
+		arity int
+		fn    func(s *Redis, args [][]byte) interface{}
+	}
+	// Redis is an in-process Redis stand-in supporting the string, list, set, sorted set, key expiration,
+	// transaction and Lua scripting commands used by gocache. Replies are any of redisStatus, redisError, int64,
+	// []byte, nil and []interface{} which are respectively written as simple strings, errors, integers, bulk
+	// strings, null bulk strings and arrays
+	Redis struct {
+		*listener
+		mu   sync.Mutex
+		data map[string]*redisValue
+	}
+)
+
+const (
+	redisKindString redisKind = iota
+	redisKindList
+	redisKindSet
+	redisKindZSet
+)
+
+var redisCommands map[string]redisCommand
+
+func init() {
+	// Arities follow the Redis convention, negative values meaning "at least" and including the command name
+	redisCommands = map[string]redisCommand{
+		"ping":             {arity: -1, fn: (*Redis).ping},
+		"echo":             {arity: 2, fn: (*Redis).echo},
+		"select":           {arity: 2, fn: (*Redis).ok},
+		"client":           {arity: -2, fn: (*Redis).ok},
+		"auth":             {arity: -2, fn: (*Redis).ok},
+		"get":              {arity: 2, fn: (*Redis).get},
+		"set":              {arity: -3, fn: (*Redis).set},
+		"setex":            {arity: 4, fn: (*Redis).setex},
+		"setnx":            {arity: 3, fn: (*Redis).setnx},
+		"mget":             {arity: -2, fn: (*Redis).mget},
+		"append":           {arity: 3, fn: (*Redis).append},
+		"getrange":         {arity: 4, fn: (*Redis).getrange},
+		"strlen":           {arity: 2, fn: (*Redis).strlen},
+		"rename":           {arity: 3, fn: (*Redis).rename},
+		"del":              {arity: -2, fn: (*Redis).del},
+		"unlink":           {arity: -2, fn: (*Redis).del},
+		"exists":           {arity: -2, fn: (*Redis).exists},
+		"incr":             {arity: 2, fn: (*Redis).incr},
+		"decr":             {arity: 2, fn: (*Redis).decr},
+		"incrby":           {arity: 3, fn: (*Redis).incrby},
+		"decrby":           {arity: 3, fn: (*Redis).decrby},
+		"expire":           {arity: 3, fn: (*Redis).expire},
+		"pexpire":          {arity: 3, fn: (*Redis).pexpire},
+		"persist":          {arity: 2, fn: (*Redis).persist},
+		"ttl":              {arity: 2, fn: (*Redis).ttl},
+		"pttl":             {arity: 2, fn: (*Redis).pttl},
+		"type":             {arity: 2, fn: (*Redis).typ},
+		"lpush":            {arity: -3, fn: (*Redis).lpush},
+		"rpush":            {arity: -3, fn: (*Redis).rpush},
+		"lrange":           {arity: 4, fn: (*Redis).lrange},
+		"llen":             {arity: 2, fn: (*Redis).llen},
+		"sadd":             {arity: -3, fn: (*Redis).sadd},
+		"srem":             {arity: -3, fn: (*Redis).srem},
+		"smembers":         {arity: 2, fn: (*Redis).smembers},
+		"scard":            {arity: 2, fn: (*Redis).scard},
+		"sscan":            {arity: -3, fn: (*Redis).sscan},
+		"zadd":             {arity: -4, fn: (*Redis).zadd},
+		"zrem":             {arity: -3, fn: (*Redis).zrem},
+		"zscore":           {arity: 3, fn: (*Redis).zscore},
+		"zcard":            {arity: 2, fn: (*Redis).zcard},
+		"zcount":           {arity: 4, fn: (*Redis).zcount},
+		"zrange":           {arity: -4, fn: (*Redis).zrange},
+		"zremrangebyscore": {arity: 4, fn: (*Redis).zremrangebyscore},
+		"zscan":            {arity: -3, fn: (*Redis).zscan},
+		"scan":             {arity: -2, fn: (*Redis).scan},
+		"keys":             {arity: 2, fn: (*Redis).keys},
+		"dbsize":           {arity: 1, fn: (*Redis).dbsize},
+		"flushdb":          {arity: -1, fn: (*Redis).flush},
+		"flushall":         {arity: -1, fn: (*Redis).flush},
+		"eval":             {arity: -3, fn: (*Redis).eval},
+	}
+}
+
+// NewRedis starts a Redis stand-in listening on an ephemeral loopback port
+func NewRedis() (*Redis, error) {
+	s := &Redis{
+		data: map[string]*redisValue{},
+	}
+
+	l, err := listen(s.serve)
+	if err != nil {
+		return nil, err
+	}
+
+	s.listener = l
+
+	return s, nil
+}
+
+// Keys returns the sorted list of keys currently held by the server
+func (s *Redis) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sortedKeys()
+}
+
+// FastForward moves the expiration of every key with a TTL back by d, as if d had elapsed
+func (s *Redis) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range s.data {
+		if !v.expireAt.IsZero() {
+			v.expireAt = v.expireAt.Add(-d)
+		}
+	}
+}
+
+func (s *Redis) serve(r *bufio.Reader, w *bufio.Writer) error {
+	var (
+		queue [][][]byte
+		multi bool
+	)
+	for {
+		args, err := readRESP(r)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		var reply interface{}
+		switch name := strings.ToLower(string(args[0])); {
+		case name == "quit":
+			writeRESP(w, redisStatus("OK"))
+
+			return w.Flush()
+		case name == "hello":
+			// Makes clients fall back to RESP2
+			reply = redisError("ERR unknown command 'HELLO'")
+		case name == "multi" && !multi:
+			multi, queue, reply = true, nil, redisStatus("OK")
+		case name == "discard" && multi:
+			multi, queue, reply = false, nil, redisStatus("OK")
+		case name == "exec" && multi:
+			s.mu.Lock()
+			replies := make([]interface{}, len(queue))
+			for i, queued := range queue {
+				replies[i] = s.call(queued)
+			}
+			s.mu.Unlock()
+
+			multi, queue, reply = false, nil, replies
+		case name == "exec" || name == "discard":
+			reply = redisError("ERR " + strings.ToUpper(name) + " without MULTI")
+		case multi:
+			if _, exists := redisCommands[name]; !exists {
+				reply = redisError("ERR unknown command '" + name + "'")
+
+				break
+			}
+
+			queue, reply = append(queue, args), redisStatus("QUEUED")
+		default:
+			s.mu.Lock()
+			reply = s.call(args)
+			s.mu.Unlock()
+		}
+
+		writeRESP(w, reply)
+		if r.Buffered() == 0 {
+			if err = w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// call dispatches the given command. The server mutex must be held by the caller
+func (s *Redis) call(args [][]byte) interface{} {
+	name := strings.ToLower(string(args[0]))
+
+	cmd, exists := redisCommands[name]
+	if !exists {
+		return redisError("ERR unknown command '" + name + "'")
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		return redisError("ERR wrong number of arguments for '" + name + "' command")
+	}
+
+	return cmd.fn(s, args)
+}
+
+// lookup returns the value held by key evicting it if it has expired
+func (s *Redis) lookup(key string) *redisValue {
+	v, exists := s.data[key]
+	if !exists {
+		return nil
+	}
+	if !v.expireAt.IsZero() && !time.Now().Before(v.expireAt) {
+		delete(s.data, key)
+
+		return nil
+	}
+
+	return v
+}
+
+func (s *Redis) sortedKeys() []string {
+	var keys = make([]string, 0, len(s.data))
+	for key := range s.data {
+		if s.lookup(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (s *Redis) ping(args [][]byte) interface{} {
+	if len(args) > 1 {
+		return args[1]
+	}
+
+	return redisStatus("PONG")
+}
+
+func (s *Redis) echo(args [][]byte) interface{} {
+	return args[1]
+}
+
+func (s *Redis) ok([][]byte) interface{} {
+	return redisStatus("OK")
+}
+
+func (s *Redis) get(args [][]byte) interface{} {
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return nil
+	}
+	if v.kind != redisKindString {
+		return redisErrWrongType
+	}
+
+	return v.str
+}
+
+func (s *Redis) set(args [][]byte) interface{} {
+	var (
+		key      = string(args[1])
+		expireAt time.Time
+		nx, xx   bool
+		keepTTL  bool
+	)
+	for i := 3; i < len(args); i++ {
+		switch opt := strings.ToLower(string(args[i])); opt {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "keepttl":
+			keepTTL = true
+		case "ex", "px":
+			if i+1 >= len(args) {
+				return redisErrSyntax
+			}
+
+			n, err := strconv.ParseInt(string(args[i+1]), 10, 64)
+			if err != nil {
+				return redisErrNotInteger
+			}
+			if n <= 0 {
+				return redisErrExpireTime
+			}
+
+			unit := time.Second
+			if opt == "px" {
+				unit = time.Millisecond
+			}
+
+			expireAt = time.Now().Add(time.Duration(n) * unit)
+			i++
+		default:
+			return redisErrSyntax
+		}
+	}
+	if nx && xx {
+		return redisErrSyntax
+	}
+
+	current := s.lookup(key)
+	if (nx && current != nil) || (xx && current == nil) {
+		return nil
+	}
+	if keepTTL && current != nil {
+		expireAt = current.expireAt
+	}
+
+	s.data[key] = &redisValue{str: args[2], expireAt: expireAt}
+
+	return redisStatus("OK")
+}
+
+func (s *Redis) setex(args [][]byte) interface{} {
+	return s.set([][]byte{args[0], args[1], args[3], []byte("ex"), args[2]})
+}
+
+func (s *Redis) setnx(args [][]byte) interface{} {
+	if s.set([][]byte{args[0], args[1], args[2], []byte("nx")}) == nil {
+		return int64(0)
+	}
+
+	return int64(1)
+}
+
+func (s *Redis) mget(args [][]byte) interface{} {
+	var values = make([]interface{}, len(args)-1)
+	for i, key := range args[1:] {
+		if v := s.lookup(string(key)); v != nil && v.kind == redisKindString {
+			values[i] = v.str
+		}
+	}
+
+	return values
+}
+
+func (s *Redis) append(args [][]byte) interface{} {
+	key := string(args[1])
+	v := s.lookup(key)
+	if v == nil {
+		v = &redisValue{}
+		s.data[key] = v
+	}
+	if v.kind != redisKindString {
+		return redisErrWrongType
+	}
+
+	v.str = append(append([]byte{}, v.str...), args[2]...)
+
+	return int64(len(v.str))
+}
+
+func (s *Redis) getrange(args [][]byte) interface{} {
+	start, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		return redisErrNotInteger
+	}
+
+	end, err := strconv.ParseInt(string(args[3]), 10, 64)
+	if err != nil {
+		return redisErrNotInteger
+	}
+
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return []byte{}
+	}
+	if v.kind != redisKindString {
+		return redisErrWrongType
+	}
+
+	n := int64(len(v.str))
+	if start < 0 {
+		start += n
+	}
+	if end < 0 {
+		end += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end >= n {
+		end = n - 1
+	}
+	if start > end {
+		return []byte{}
+	}
+
+	return v.str[start : end+1]
+}
+
+func (s *Redis) strlen(args [][]byte) interface{} {
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return int64(0)
+	}
+	if v.kind != redisKindString {
+		return redisErrWrongType
+	}
+
+	return int64(len(v.str))
+}
+
+func (s *Redis) rename(args [][]byte) interface{} {
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return redisError("ERR no such key")
+	}
+
+	delete(s.data, string(args[1]))
+	s.data[string(args[2])] = v
+
+	return redisStatus("OK")
+}
+
+func (s *Redis) del(args [][]byte) interface{} {
+	var deleted int64
+	for _, key := range args[1:] {
+		if s.lookup(string(key)) != nil {
+			delete(s.data, string(key))
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+func (s *Redis) exists(args [][]byte) interface{} {
+	var count int64
+	for _, key := range args[1:] {
+		if s.lookup(string(key)) != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (s *Redis) incr(args [][]byte) interface{} {
+	return s.incrementBy(string(args[1]), 1)
+}
+
+func (s *Redis) decr(args [][]byte) interface{} {
+	return s.incrementBy(string(args[1]), -1)
+}
+
+func (s *Redis) incrby(args [][]byte) interface{} {
+	delta, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		return redisErrNotInteger
+	}
+
+	return s.incrementBy(string(args[1]), delta)
+}
+
+func (s *Redis) decrby(args [][]byte) interface{} {
+	delta, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		return redisErrNotInteger
+	}
+
+	return s.incrementBy(string(args[1]), -delta)
+}
+
+func (s *Redis) incrementBy(key string, delta int64) interface{} {
+	v := s.lookup(key)
+	if v == nil {
+		v = &redisValue{str: []byte("0")}
+		s.data[key] = v
+	}
+	if v.kind != redisKindString {
+		return redisErrWrongType
+	}
+
+	current, err := strconv.ParseInt(string(v.str), 10, 64)
+	if err != nil {
+		return redisErrNotInteger
+	}
+
+	current += delta
+	v.str = []byte(strconv.FormatInt(current, 10))
+
+	return current
+}
+
+func (s *Redis) expire(args [][]byte) interface{} {
+	return s.expireIn(args, time.Second)
+}
+
+func (s *Redis) pexpire(args [][]byte) interface{} {
+	return s.expireIn(args, time.Millisecond)
+}
+
+func (s *Redis) expireIn(args [][]byte, unit time.Duration) interface{} {
+	n, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		return redisErrNotInteger
+	}
+
+	key := string(args[1])
+	v := s.lookup(key)
+	if v == nil {
+		return int64(0)
+	}
+	if n <= 0 {
+		delete(s.data, key)
+
+		return int64(1)
+	}
+
+	v.expireAt = time.Now().Add(time.Duration(n) * unit)
+
+	return int64(1)
+}
+
+func (s *Redis) persist(args [][]byte) interface{} {
+	v := s.lookup(string(args[1]))
+	if v == nil || v.expireAt.IsZero() {
+		return int64(0)
+	}
+
+	v.expireAt = time.Time{}
+
+	return int64(1)
+}
+
+func (s *Redis) ttl(args [][]byte) interface{} {
+	return s.remaining(string(args[1]), time.Second)
+}
+
+func (s *Redis) pttl(args [][]byte) interface{} {
+	return s.remaining(string(args[1]), time.Millisecond)
+}
+
+func (s *Redis) remaining(key string, unit time.Duration) interface{} {
+	v := s.lookup(key)
+	if v == nil {
+		return int64(-2)
+	}
+	if v.expireAt.IsZero() {
+		return int64(-1)
+	}
+
+	return int64((time.Until(v.expireAt) + unit - 1) / unit)
+}
+
+func (s *Redis) typ(args [][]byte) interface{} {
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return redisStatus("none")
+	}
+
+	return redisStatus(v.typeName())
+}
+
+// typeName returns the name of the type of the value as reported by the TYPE command
+func (v *redisValue) typeName() string {
+	switch v.kind {
+	case redisKindList:
+		return "list"
+	case redisKindSet:
+		return "set"
+	case redisKindZSet:
+		return "zset"
+	}
+
+	return "string"
+}
+
+func (s *Redis) lpush(args [][]byte) interface{} {
+	return s.push(args, true)
+}
+
+func (s *Redis) rpush(args [][]byte) interface{} {
+	return s.push(args, false)
+}
+
+func (s *Redis) push(args [][]byte, left bool) interface{} {
+	key := string(args[1])
+	v := s.lookup(key)
+	if v == nil {
+		v = &redisValue{kind: redisKindList}
+		s.data[key] = v
+	}
+	if v.kind != redisKindList {
+		return redisErrWrongType
+	}
+
+	for _, element := range args[2:] {
+		if left {
+			v.list = append([][]byte{element}, v.list...)
+		} else {
+			v.list = append(v.list, element)
+		}
+	}
+
+	return int64(len(v.list))
+}
+
+func (s *Redis) lrange(args [][]byte) interface{} {
+	start, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		return redisErrNotInteger
+	}
+
+	stop, err := strconv.ParseInt(string(args[3]), 10, 64)
+	if err != nil {
+		return redisErrNotInteger
+	}
+
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return []interface{}{}
+	}
+	if v.kind != redisKindList {
+		return redisErrWrongType
+	}
+
+	n := int64(len(v.list))
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+
+	var elements = []interface{}{}
+	for i := start; i <= stop; i++ {
+		elements = append(elements, v.list[i])
+	}
+
+	return elements
+}
+
+func (s *Redis) llen(args [][]byte) interface{} {
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return int64(0)
+	}
+	if v.kind != redisKindList {
+		return redisErrWrongType
+	}
+
+	return int64(len(v.list))
+}
+
+func (s *Redis) sadd(args [][]byte) interface{} {
+	key := string(args[1])
+	v := s.lookup(key)
+	if v == nil {
+		v = &redisValue{kind: redisKindSet, set: map[string]struct{}{}}
+		s.data[key] = v
+	}
+	if v.kind != redisKindSet {
+		return redisErrWrongType
+	}
+
+	var added int64
+	for _, member := range args[2:] {
+		if _, exists := v.set[string(member)]; !exists {
+			v.set[string(member)] = struct{}{}
+			added++
+		}
+	}
+
+	return added
+}
+
+func (s *Redis) srem(args [][]byte) interface{} {
+	key := string(args[1])
+	v := s.lookup(key)
+	if v == nil {
+		return int64(0)
+	}
+	if v.kind != redisKindSet {
+		return redisErrWrongType
+	}
+
+	var removed int64
+	for _, member := range args[2:] {
+		if _, exists := v.set[string(member)]; exists {
+			delete(v.set, string(member))
+			removed++
+		}
+	}
+	if len(v.set) == 0 {
+		delete(s.data, key)
+	}
+
+	return removed
+}
+
+// smembers returns the members of a set sorted so that replies are deterministic
+func (s *Redis) smembers(args [][]byte) interface{} {
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return []interface{}{}
+	}
+	if v.kind != redisKindSet {
+		return redisErrWrongType
+	}
+
+	var members = make([]string, 0, len(v.set))
+	for member := range v.set {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+
+	var reply = make([]interface{}, len(members))
+	for i, member := range members {
+		reply[i] = []byte(member)
+	}
+
+	return reply
+}
+
+func (s *Redis) scard(args [][]byte) interface{} {
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return int64(0)
+	}
+	if v.kind != redisKindSet {
+		return redisErrWrongType
+	}
+
+	return int64(len(v.set))
+}
+
+// zadd supports the NX, XX, GT, LT and CH options
+func (s *Redis) zadd(args [][]byte) interface{} {
+	var nx, xx, gt, lt, ch bool
+	i := 2
+options:
+	for ; i < len(args); i++ {
+		switch strings.ToLower(string(args[i])) {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "gt":
+			gt = true
+		case "lt":
+			lt = true
+		case "ch":
+			ch = true
+		default:
+			break options
+		}
+	}
+
+	pairs := args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 || (nx && (xx || gt || lt)) || (gt && lt) {
+		return redisErrSyntax
+	}
+
+	var scores = make([]float64, len(pairs)/2)
+	for j := range scores {
+		score, err := parseScore(string(pairs[2*j]))
+		if err != nil {
+			return redisError("ERR value is not a valid float")
+		}
+
+		scores[j] = score
+	}
+
+	key := string(args[1])
+	v := s.lookup(key)
+	if v == nil {
+		if xx {
+			return int64(0)
+		}
+
+		v = &redisValue{kind: redisKindZSet, zset: map[string]float64{}}
+		s.data[key] = v
+	}
+	if v.kind != redisKindZSet {
+		return redisErrWrongType
+	}
+
+	var added, changed int64
+	for j, score := range scores {
+		member := string(pairs[2*j+1])
+		current, exists := v.zset[member]
+		switch {
+		case exists && nx, !exists && xx:
+			continue
+		case exists && (gt && score <= current || lt && score >= current):
+			continue
+		}
+		if !exists {
+			added++
+		} else if current != score {
+			changed++
+		}
+
+		v.zset[member] = score
+	}
+	if ch {
+		return added + changed
+	}
+
+	return added
+}
+
+func (s *Redis) zrem(args [][]byte) interface{} {
+	key := string(args[1])
+	v := s.lookup(key)
+	if v == nil {
+		return int64(0)
+	}
+	if v.kind != redisKindZSet {
+		return redisErrWrongType
+	}
+
+	var removed int64
+	for _, member := range args[2:] {
+		if _, exists := v.zset[string(member)]; exists {
+			delete(v.zset, string(member))
+			removed++
+		}
+	}
+	if len(v.zset) == 0 {
+		delete(s.data, key)
+	}
+
+	return removed
+}
+
+func (s *Redis) zscore(args [][]byte) interface{} {
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return nil
+	}
+	if v.kind != redisKindZSet {
+		return redisErrWrongType
+	}
+
+	score, exists := v.zset[string(args[2])]
+	if !exists {
+		return nil
+	}
+
+	return []byte(formatScore(score))
+}
+
+func (s *Redis) zcard(args [][]byte) interface{} {
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return int64(0)
+	}
+	if v.kind != redisKindZSet {
+		return redisErrWrongType
+	}
+
+	return int64(len(v.zset))
+}
+
+// zrange supports ranges by index only, with the WITHSCORES option
+func (s *Redis) zrange(args [][]byte) interface{} {
+	start, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		return redisErrNotInteger
+	}
+
+	stop, err := strconv.ParseInt(string(args[3]), 10, 64)
+	if err != nil {
+		return redisErrNotInteger
+	}
+
+	var withScores bool
+	for _, arg := range args[4:] {
+		if !strings.EqualFold(string(arg), "withscores") {
+			return redisErrSyntax
+		}
+
+		withScores = true
+	}
+
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return []interface{}{}
+	}
+	if v.kind != redisKindZSet {
+		return redisErrWrongType
+	}
+
+	members := v.sortedMembers()
+	n := int64(len(members))
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+
+	var elements = []interface{}{}
+	for i := start; i <= stop; i++ {
+		elements = append(elements, []byte(members[i]))
+		if withScores {
+			elements = append(elements, []byte(formatScore(v.zset[members[i]])))
+		}
+	}
+
+	return elements
+}
+
+func (s *Redis) zcount(args [][]byte) interface{} {
+	inRange, failure := parseScoreRange(args[2], args[3])
+	if failure != nil {
+		return failure
+	}
+
+	v := s.lookup(string(args[1]))
+	if v == nil {
+		return int64(0)
+	}
+	if v.kind != redisKindZSet {
+		return redisErrWrongType
+	}
+
+	var count int64
+	for _, score := range v.zset {
+		if inRange(score) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (s *Redis) zremrangebyscore(args [][]byte) interface{} {
+	inRange, failure := parseScoreRange(args[2], args[3])
+	if failure != nil {
+		return failure
+	}
+
+	key := string(args[1])
+	v := s.lookup(key)
+	if v == nil {
+		return int64(0)
+	}
+	if v.kind != redisKindZSet {
+		return redisErrWrongType
+	}
+
+	var removed int64
+	for member, score := range v.zset {
+		if !inRange(score) {
+			continue
+		}
+
+		delete(v.zset, member)
+		removed++
+	}
+	if len(v.zset) == 0 {
+		delete(s.data, key)
+	}
+
+	return removed
+}
+
+// sortedMembers returns the members of a sorted set ordered by score and then lexicographically
+func (v *redisValue) sortedMembers() []string {
+	var members = make([]string, 0, len(v.zset))
+	for member := range v.zset {
+		members = append(members, member)
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		a, b := v.zset[members[i]], v.zset[members[j]]
+		if a != b {
+			return a < b
+		}
+
+		return members[i] < members[j]
+	})
+
+	return members
+}
+
+// parseScoreRange returns whether a score falls between the given min and max bounds
+func parseScoreRange(min, max []byte) (func(float64) bool, interface{}) {
+	minScore, minExclusive, err := parseScoreBound(string(min))
+	if err != nil {
+		return nil, redisError("ERR min or max is not a float")
+	}
+
+	maxScore, maxExclusive, err := parseScoreBound(string(max))
+	if err != nil {
+		return nil, redisError("ERR min or max is not a float")
+	}
+
+	return func(score float64) bool {
+		return score >= minScore && score <= maxScore && !(minExclusive && score == minScore) && !(maxExclusive && score == maxScore)
+	}, nil
+}
+
+func parseScore(s string) (float64, error) {
+	switch strings.ToLower(s) {
+	case "+inf", "inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseScoreBound parses a score range bound, a leading ( making it exclusive
+func parseScoreBound(s string) (float64, bool, error) {
+	exclusive := strings.HasPrefix(s, "(")
+	score, err := parseScore(strings.TrimPrefix(s, "("))
+
+	return score, exclusive, err
+}
+
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}
+
+// scan iterates over the sorted key space, the cursor being the offset of the next key to return
+func (s *Redis) scan(args [][]byte) interface{} {
+	cursor, opts, failure := parseScanArgs(args[1:])
+	if failure != nil {
+		return failure
+	}
+
+	var (
+		keys    = s.sortedKeys()
+		matches = []interface{}{}
+	)
+	for i := cursor; i < len(keys) && i < cursor+opts.count; i++ {
+		if len(opts.typ) > 0 && s.data[keys[i]].typeName() != opts.typ {
+			continue
+		}
+		if matched, _ := path.Match(opts.pattern, keys[i]); matched {
+			matches = append(matches, []byte(keys[i]))
+		}
+	}
+
+	return []interface{}{[]byte(strconv.Itoa(opts.next(cursor, len(keys)))), matches}
+}
+
+// sscan iterates over the sorted members of a set, the cursor being the offset of the next member to return
+func (s *Redis) sscan(args [][]byte) interface{} {
+	cursor, opts, failure := parseScanArgs(args[2:])
+	if failure != nil {
+		return failure
+	}
+	if len(opts.typ) > 0 {
+		return redisErrSyntax
+	}
+
+	var members []string
+	if v := s.lookup(string(args[1])); v != nil {
+		if v.kind != redisKindSet {
+			return redisErrWrongType
+		}
+
+		for member := range v.set {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+	}
+
+	var matches = []interface{}{}
+	for i := cursor; i < len(members) && i < cursor+opts.count; i++ {
+		if matched, _ := path.Match(opts.pattern, members[i]); matched {
+			matches = append(matches, []byte(members[i]))
+		}
+	}
+
+	return []interface{}{[]byte(strconv.Itoa(opts.next(cursor, len(members)))), matches}
+}
+
+// zscan iterates over the members of a sorted set ordered by score, the cursor being the offset of the next member
+// to return. Each member is followed by its score
+func (s *Redis) zscan(args [][]byte) interface{} {
+	cursor, opts, failure := parseScanArgs(args[2:])
+	if failure != nil {
+		return failure
+	}
+	if len(opts.typ) > 0 {
+		return redisErrSyntax
+	}
+
+	var (
+		v       = s.lookup(string(args[1]))
+		members []string
+	)
+	if v != nil {
+		if v.kind != redisKindZSet {
+			return redisErrWrongType
+		}
+
+		members = v.sortedMembers()
+	}
+
+	var matches = []interface{}{}
+	for i := cursor; i < len(members) && i < cursor+opts.count; i++ {
+		if matched, _ := path.Match(opts.pattern, members[i]); matched {
+			matches = append(matches, []byte(members[i]), []byte(formatScore(v.zset[members[i]])))
+		}
+	}
+
+	return []interface{}{[]byte(strconv.Itoa(opts.next(cursor, len(members)))), matches}
+}
+
+// scanOptions holds the options shared by the scan family of commands
+type scanOptions struct {
+	pattern string
+	count   int
+	typ     string
+}
+
+// next returns the cursor following the given one over a collection of size elements, 0 once it is exhausted
+func (o scanOptions) next(cursor, size int) int {
+	if cursor+o.count >= size {
+		return 0
+	}
+
+	return cursor + o.count
+}
+
+// parseScanArgs parses a cursor followed by the MATCH, COUNT and TYPE options
+func parseScanArgs(args [][]byte) (int, scanOptions, interface{}) {
+	var opts = scanOptions{pattern: "*", count: 10}
+
+	cursor, err := strconv.Atoi(string(args[0]))
+	if err != nil || cursor < 0 {
+		return 0, opts, redisError("ERR invalid cursor")
+	}
+
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return 0, opts, redisErrSyntax
+		}
+
+		switch strings.ToLower(string(args[i])) {
+		case "match":
+			opts.pattern = string(args[i+1])
+		case "count":
+			if opts.count, err = strconv.Atoi(string(args[i+1])); err != nil || opts.count < 1 {
+				return 0, opts, redisErrSyntax
+			}
+		case "type":
+			opts.typ = strings.ToLower(string(args[i+1]))
+		default:
+			return 0, opts, redisErrSyntax
+		}
+	}
+
+	return cursor, opts, nil
+}
+
+func (s *Redis) keys(args [][]byte) interface{} {
+	var matches = []interface{}{}
+	for _, key := range s.sortedKeys() {
+		if matched, _ := path.Match(string(args[1]), key); matched {
+			matches = append(matches, []byte(key))
+		}
+	}
+
+	return matches
+}
+
+func (s *Redis) dbsize([][]byte) interface{} {
+	return int64(len(s.sortedKeys()))
+}
+
+func (s *Redis) flush([][]byte) interface{} {
+	s.data = map[string]*redisValue{}
+
+	return redisStatus("OK")
+}
+
+// readRESP reads a command sent either as an array of bulk strings or inline
+func readRESP(r *bufio.Reader) ([][]byte, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, nil
+	}
+	if line[0] != '*' {
+		var args [][]byte
+		for _, field := range strings.Fields(line) {
+			args = append(args, []byte(field))
+		}
+
+		return args, nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, fmt.Errorf("testserver: invalid multibulk length %q", line)
+	}
+
+	var args = make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		header, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(header) == 0 || header[0] != '$' {
+			return nil, fmt.Errorf("testserver: expected bulk string, got %q", header)
+		}
+
+		size, err := strconv.Atoi(header[1:])
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("testserver: invalid bulk length %q", header)
+		}
+
+		arg := make([]byte, size+2)
+		if _, err = io.ReadFull(r, arg); err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg[:size])
+	}
+
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func writeRESP(w *bufio.Writer, reply interface{}) {
+	switch v := reply.(type) {
+	case nil:
+		_, _ = w.WriteString("$-1\r\n")
+	case redisStatus:
+		_, _ = w.WriteString("+" + string(v) + "\r\n")
+	case redisError:
+		_, _ = w.WriteString("-" + string(v) + "\r\n")
+	case int64:
+		_, _ = w.WriteString(":" + strconv.FormatInt(v, 10) + "\r\n")
+	case []byte:
+		_, _ = w.WriteString("$" + strconv.Itoa(len(v)) + "\r\n")
+		_, _ = w.Write(v)
+		_, _ = w.WriteString("\r\n")
+	case []interface{}:
+		_, _ = w.WriteString("*" + strconv.Itoa(len(v)) + "\r\n")
+		for _, element := range v {
+			writeRESP(w, element)
+		}
+	default:
+		writeRESP(w, redisError("ERR unsupported reply"))
+	}
+}
@@ -0,0 +1,182 @@
+package testserver
+
+import (
+	"strconv"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// eval runs a Lua script the way Redis does, exposing KEYS, ARGV and the redis.call and redis.pcall functions,
+// the latter dispatching commands without releasing the server mutex which makes scripts atomic
+func (s *Redis) eval(args [][]byte) interface{} {
+	numKeys, err := strconv.Atoi(string(args[2]))
+	if err != nil || numKeys < 0 {
+		return redisError("ERR value is not an integer or out of range")
+	}
+	if numKeys > len(args)-3 {
+		return redisError("ERR Number of keys can't be greater than number of args")
+	}
+
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer L.Close()
+
+	for _, lib := range []struct {
+		name string
+		fn   lua.LGFunction
+	}{
+		{name: lua.BaseLibName, fn: lua.OpenBase},
+		{name: lua.TabLibName, fn: lua.OpenTable},
+		{name: lua.StringLibName, fn: lua.OpenString},
+		{name: lua.MathLibName, fn: lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	L.SetGlobal("KEYS", luaArray(L, args[3:3+numKeys]))
+	L.SetGlobal("ARGV", luaArray(L, args[3+numKeys:]))
+
+	redis := L.NewTable()
+	L.SetField(redis, "call", L.NewFunction(func(L *lua.LState) int {
+		reply := s.luaCall(L)
+		if e, ok := reply.(redisError); ok {
+			L.RaiseError("%s", string(e))
+
+			return 0
+		}
+
+		L.Push(toLua(L, reply))
+
+		return 1
+	}))
+	L.SetField(redis, "pcall", L.NewFunction(func(L *lua.LState) int {
+		L.Push(toLua(L, s.luaCall(L)))
+
+		return 1
+	}))
+	L.SetField(redis, "status_reply", L.NewFunction(func(L *lua.LState) int {
+		t := L.NewTable()
+		L.SetField(t, "ok", lua.LString(L.CheckString(1)))
+		L.Push(t)
+
+		return 1
+	}))
+	L.SetField(redis, "error_reply", L.NewFunction(func(L *lua.LState) int {
+		t := L.NewTable()
+		L.SetField(t, "err", lua.LString(L.CheckString(1)))
+		L.Push(t)
+
+		return 1
+	}))
+	L.SetGlobal("redis", redis)
+
+	if err = L.DoString(string(args[1])); err != nil {
+		return redisError("ERR Error running script: " + strings.ReplaceAll(err.Error(), "\n", " "))
+	}
+	if L.GetTop() == 0 {
+		return nil
+	}
+
+	return fromLua(L.Get(1))
+}
+
+func (s *Redis) luaCall(L *lua.LState) interface{} {
+	var args [][]byte
+	for i := 1; i <= L.GetTop(); i++ {
+		switch v := L.Get(i).(type) {
+		case lua.LString:
+			args = append(args, []byte(v))
+		case lua.LNumber:
+			args = append(args, []byte(v.String()))
+		default:
+			return redisError("ERR Lua redis() command arguments must be strings or integers")
+		}
+	}
+	if len(args) == 0 {
+		return redisError("ERR Please specify at least one argument for redis.call()")
+	}
+
+	return s.call(args)
+}
+
+func luaArray(L *lua.LState, values [][]byte) *lua.LTable {
+	t := L.NewTable()
+	for _, v := range values {
+		t.Append(lua.LString(v))
+	}
+
+	return t
+}
+
+// toLua converts a command reply to its Lua representation following the Redis conversion rules
+func toLua(L *lua.LState, reply interface{}) lua.LValue {
+	switch v := reply.(type) {
+	case nil:
+		return lua.LFalse
+	case redisStatus:
+		t := L.NewTable()
+		L.SetField(t, "ok", lua.LString(v))
+
+		return t
+	case redisError:
+		t := L.NewTable()
+		L.SetField(t, "err", lua.LString(v))
+
+		return t
+	case int64:
+		return lua.LNumber(v)
+	case []byte:
+		return lua.LString(v)
+	case []interface{}:
+		t := L.NewTable()
+		for _, element := range v {
+			t.Append(toLua(L, element))
+		}
+
+		return t
+	}
+
+	return lua.LNil
+}
+
+// fromLua converts a Lua value returned by a script to a reply following the Redis conversion rules
+func fromLua(value lua.LValue) interface{} {
+	switch v := value.(type) {
+	case lua.LBool:
+		if v {
+			return int64(1)
+		}
+
+		return nil
+	case lua.LNumber:
+		return int64(v)
+	case lua.LString:
+		return []byte(v)
+	case *lua.LTable:
+		if ok, isString := v.RawGetString("ok").(lua.LString); isString {
+			return redisStatus(ok)
+		}
+		if err, isString := v.RawGetString("err").(lua.LString); isString {
+			return redisError(err)
+		}
+
+		var elements []interface{}
+		for i := 1; ; i++ {
+			element := v.RawGetInt(i)
+			if element == lua.LNil {
+				break
+			}
+
+			elements = append(elements, fromLua(element))
+		}
+		if elements == nil {
+			elements = []interface{}{}
+		}
+
+		return elements
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+// Package testserver provides in-process stand-ins for the Redis and Memcache servers backing gocache stores.
+// The servers listen on an ephemeral loopback port and speak enough of the RESP and memcache text protocols for
+// the gocache drivers, which allows their tests to run hermetically
+package testserver
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"testing"
+)
+
+// RunRedis starts a Redis stand-in which is closed once the test and all its subtests complete
+func RunRedis(t testing.TB) *Redis {
+	t.Helper()
+
+	s, err := NewRedis()
+	if err != nil {
+		t.Fatalf("testserver: could not start redis: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	return s
+}
+
+// RunMemcache starts a Memcache stand-in which is closed once the test and all its subtests complete
+func RunMemcache(t testing.TB) *Memcache {
+	t.Helper()
+
+	s, err := NewMemcache()
+	if err != nil {
+		t.Fatalf("testserver: could not start memcache: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	return s
+}
+
+// listener accepts connections on an ephemeral loopback port and serves each one of them on its own goroutine
+type listener struct {
+	ln    net.Listener
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+func listen(serve func(r *bufio.Reader, w *bufio.Writer) error) (*listener, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	l := &listener{
+		ln:    ln,
+		conns: map[net.Conn]struct{}{},
+	}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			l.mu.Lock()
+			l.conns[conn] = struct{}{}
+			l.mu.Unlock()
+
+			l.wg.Add(1)
+			go func() {
+				defer l.wg.Done()
+				defer func() {
+					l.mu.Lock()
+					delete(l.conns, conn)
+					l.mu.Unlock()
+
+					_ = conn.Close()
+				}()
+
+				_ = serve(bufio.NewReader(conn), bufio.NewWriter(conn))
+			}()
+		}
+	}()
+
+	return l, nil
+}
+
+// Addr returns the address the server listens on
+func (l *listener) Addr() string {
+	return l.ln.Addr().String()
+}
+
+// Close stops the server closing all open connections
+func (l *listener) Close() error {
+	err := l.ln.Close()
+
+	l.mu.Lock()
+	for conn := range l.conns {
+		_ = conn.Close()
+	}
+	l.mu.Unlock()
+
+	l.wg.Wait()
+
+	return err
+}
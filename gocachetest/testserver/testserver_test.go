@@ -0,0 +1,160 @@
+package testserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedis(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		server = RunRedis(t)
+		client = redis.NewClient(&redis.Options{Addr: server.Addr()})
+	)
+	defer client.Close()
+
+	require.NoError(t, client.Set(ctx, "string", "value", time.Minute).Err())
+	require.NoError(t, client.LPush(ctx, "list", "a", "b").Err())
+
+	values, err := client.MGet(ctx, "string", "list", "missing").Result()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"value", nil, nil}, values)
+
+	elements, err := client.LRange(ctx, "list", 0, -1).Result()
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "a"}, elements)
+
+	keys, cursor, err := client.Scan(ctx, 0, "s*", 10).Result()
+	require.NoError(t, err)
+	require.Zero(t, cursor)
+	require.Equal(t, []string{"string"}, keys)
+
+	script := `return redis.call('exists',KEYS[1])<1 and redis.call('setex',KEYS[1],ARGV[2],ARGV[1])`
+
+	res, err := client.Eval(ctx, script, []string{"added"}, "value", 60).Text()
+	require.NoError(t, err)
+	require.Equal(t, "OK", res)
+
+	_, err = client.Eval(ctx, script, []string{"added"}, "value", 60).Text()
+	require.ErrorIs(t, err, redis.Nil)
+
+	ttl, err := client.TTL(ctx, "added").Result()
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, ttl)
+
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.IncrBy(ctx, "counter", 5)
+		pipe.DecrBy(ctx, "counter", 2)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	counter, err := client.Get(ctx, "counter").Int64()
+	require.NoError(t, err)
+	require.EqualValues(t, 3, counter)
+
+	require.NoError(t, client.Append(ctx, "stream", "hello ").Err())
+	require.NoError(t, client.Append(ctx, "stream", "world").Err())
+	require.NoError(t, client.Rename(ctx, "stream", "renamed").Err())
+	require.Error(t, client.Rename(ctx, "stream", "renamed").Err())
+
+	chunk, err := client.GetRange(ctx, "renamed", 6, 100).Result()
+	require.NoError(t, err)
+	require.Equal(t, "world", chunk)
+	require.EqualValues(t, 11, client.StrLen(ctx, "renamed").Val())
+
+	require.EqualValues(t, 2, client.SAdd(ctx, "set", "b", "a", "b").Val())
+	require.EqualValues(t, 0, client.SAdd(ctx, "set", "a").Val())
+	require.Equal(t, []string{"a", "b"}, client.SMembers(ctx, "set").Val())
+	require.EqualValues(t, 1, client.SRem(ctx, "set", "a", "c").Val())
+	require.EqualValues(t, 1, client.SCard(ctx, "set").Val())
+
+	members, cursor, err := client.SScan(ctx, "set", 0, "b*", 10).Result()
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, members)
+	require.Zero(t, cursor)
+	require.Equal(t, "set", client.Type(ctx, "set").Val())
+	require.Error(t, client.Get(ctx, "set").Err())
+
+	require.EqualValues(t, 3, client.ZAdd(ctx, "zset", redis.Z{Score: 20, Member: "b"}, redis.Z{Score: -1, Member: "a"}, redis.Z{Score: 10, Member: "c"}).Val())
+	require.EqualValues(t, 0, client.ZAddNX(ctx, "zset", redis.Z{Score: 30, Member: "b"}).Val())
+	require.EqualValues(t, 20, client.ZScore(ctx, "zset", "b").Val())
+	require.Equal(t, []string{"a", "c", "b"}, client.ZRange(ctx, "zset", 0, -1).Val())
+	require.Equal(t, []redis.Z{{Score: 10, Member: "c"}}, client.ZRangeWithScores(ctx, "zset", 1, 1).Val())
+
+	members, cursor, err = client.ZScan(ctx, "zset", 0, "*", 2).Result()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "-1", "c", "10"}, members)
+	require.EqualValues(t, 2, cursor)
+
+	members, cursor, err = client.ZScan(ctx, "zset", cursor, "*", 2).Result()
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "20"}, members)
+	require.Zero(t, cursor)
+	require.EqualValues(t, 1, client.ZCount(ctx, "zset", "-1", "-1").Val())
+	require.EqualValues(t, 1, client.ZCount(ctx, "zset", "(10", "+inf").Val())
+	require.EqualValues(t, 2, client.ZRemRangeByScore(ctx, "zset", "0", "20").Val())
+	require.EqualValues(t, 1, client.ZCard(ctx, "zset").Val())
+
+	zsets, _, err := client.ScanType(ctx, 0, "*", 100, "zset").Result()
+	require.NoError(t, err)
+	require.Equal(t, []string{"zset"}, zsets)
+	require.EqualValues(t, 1, client.ZRem(ctx, "zset", "a").Val())
+	require.EqualValues(t, 0, client.Exists(ctx, "zset").Val())
+
+	server.FastForward(time.Minute)
+
+	exists, err := client.Exists(ctx, "string", "added", "counter").Result()
+	require.NoError(t, err)
+	require.EqualValues(t, 1, exists)
+}
+
+func TestMemcache(t *testing.T) {
+	var (
+		server = RunMemcache(t)
+		client = memcache.New(server.Addr())
+	)
+	require.NoError(t, client.Set(&memcache.Item{Key: "key", Value: []byte("1"), Flags: 7, Expiration: 60}))
+	require.ErrorIs(t, client.Add(&memcache.Item{Key: "key", Value: []byte("2")}), memcache.ErrNotStored)
+
+	item, err := client.Get("key")
+	require.NoError(t, err)
+	require.Equal(t, "1", string(item.Value))
+	require.EqualValues(t, 7, item.Flags)
+
+	item.Value = []byte("10")
+	require.NoError(t, client.CompareAndSwap(item))
+	require.ErrorIs(t, client.CompareAndSwap(item), memcache.ErrCASConflict)
+
+	n, err := client.Increment("key", 5)
+	require.NoError(t, err)
+	require.EqualValues(t, 15, n)
+
+	n, err = client.Decrement("key", 20)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	items, err := client.GetMulti([]string{"key", "missing"})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	require.NoError(t, client.Touch("key", 1))
+	server.FastForward(time.Second)
+
+	_, err = client.Get("key")
+	require.ErrorIs(t, err, memcache.ErrCacheMiss)
+
+	require.Error(t, client.Set(&memcache.Item{Key: "large", Value: make([]byte, MemcacheMaxItemSize+1)}))
+
+	require.NoError(t, client.Set(&memcache.Item{Key: "other", Value: []byte("value")}))
+	require.NoError(t, client.Delete("other"))
+	require.ErrorIs(t, client.Delete("other"), memcache.ErrCacheMiss)
+	require.NoError(t, client.DeleteAll())
+	require.Zero(t, server.Len())
+}
@@ -0,0 +1,121 @@
+package gocache
+
+import (
+	"crypto/md5"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+)
+
+const (
+	// ketamaPointsPerServer is the number of points a server gets on the ring when all servers share the same
+	// weight. It matches the value used by libketama, libmemcached and the PHP memcached extension
+	ketamaPointsPerServer = 160
+	// memcacheDefaultPort is the port libmemcached leaves out when hashing the points of a server
+	memcacheDefaultPort = "11211"
+)
+
+type (
+	// hashRing is a ketama compatible consistent hash ring. Nodes are placed on the ring proportionally to their
+	// weight so that adding or removing a node only remaps the keys that belonged to it
+	hashRing struct {
+		points []ringPoint
+	}
+	ringPoint struct {
+		hash uint32
+		node string
+	}
+)
+
+// newHashRing creates a ring for the given weighted nodes, the points of each node being the hashes of the strings
+// returned by pointKey for every one of its point indexes
+func newHashRing(nodes map[string]int, pointKey func(node string, i int) string) *hashRing {
+	var (
+		names       = make([]string, 0, len(nodes))
+		totalWeight int
+	)
+	for name, weight := range nodes {
+		if weight <= 0 {
+			weight = 1
+		}
+
+		names = append(names, name)
+		totalWeight += weight
+	}
+	// Sorting the names guarantees that hash collisions are always resolved the same way
+	sort.Strings(names)
+
+	r := &hashRing{}
+	for _, name := range names {
+		weight := nodes[name]
+		if weight <= 0 {
+			weight = 1
+		}
+
+		var (
+			pct    = float64(weight) / float64(totalWeight)
+			hashes = int(math.Floor(pct*ketamaPointsPerServer/4*float64(len(names)) + 0.0000000001))
+		)
+		for i := 0; i < hashes; i++ {
+			digest := md5.Sum([]byte(pointKey(name, i)))
+			for h := 0; h < 4; h++ {
+				r.points = append(r.points, ringPoint{
+					hash: ketamaPoint(digest, h),
+					node: name,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(r.points, func(i, j int) bool {
+		return r.points[i].hash < r.points[j].hash
+	})
+
+	return r
+}
+
+// get returns the node that owns the given key
+func (r *hashRing) get(key string) (string, bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+
+	var (
+		hash = ketamaHash(key)
+		i    = sort.Search(len(r.points), func(i int) bool {
+			return r.points[i].hash >= hash
+		})
+	)
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.points[i].node, true
+}
+
+// ketamaPointKey returns the string hashed for the i-th point of the given node as libketama does
+func ketamaPointKey(node string, i int) string {
+	return node + "-" + strconv.Itoa(i)
+}
+
+// memcachePointKey returns the string hashed for the i-th point of the given host:port server as libmemcached does,
+// which leaves the port out for servers listening on the default one
+func memcachePointKey(server string, i int) string {
+	if host, port, err := net.SplitHostPort(server); err == nil && port == memcacheDefaultPort {
+		server = host
+	}
+
+	return ketamaPointKey(server, i)
+}
+
+func ketamaHash(key string) uint32 {
+	return ketamaPoint(md5.Sum([]byte(key)), 0)
+}
+
+func ketamaPoint(digest [md5.Size]byte, h int) uint32 {
+	return uint32(digest[3+h*4])<<24 |
+		uint32(digest[2+h*4])<<16 |
+		uint32(digest[1+h*4])<<8 |
+		uint32(digest[h*4])
+}
@@ -1,8 +1,12 @@
 package gocache
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"time"
+
+	"github.com/alejandro-carstens/gocache/encoder"
 )
 
 func isNumeric(i interface{}) bool {
@@ -47,6 +51,64 @@ func isBool(i interface{}) bool {
 	}
 }
 
+// isEncrypted reports whether enc encrypts its output, in which case numbers and booleans are encoded like any other
+// value rather than stored as is
+func isEncrypted(enc encoder.Encoder) bool {
+	switch e := enc.(type) {
+	case encoder.Encrypted, *encoder.Encrypted:
+		return true
+	case encoder.Compressed:
+		return isEncrypted(e.Inner)
+	case encoder.Envelope:
+		return isEncrypted(e.Inner)
+	default:
+		return false
+	}
+}
+
+// storedAsIs reports whether value is stored as is rather than encoded via enc
+func storedAsIs(enc encoder.Encoder, value interface{}) bool {
+	return (isNumeric(value) || isBool(value)) && !isEncrypted(enc)
+}
+
+// encryptedNumber returns the string representation of a number encoded by an encrypting enc
+func encryptedNumber(enc encoder.Encoder, value string) (string, bool) {
+	if !isEncrypted(enc) {
+		return "", false
+	}
+
+	var i int64
+	if err := enc.Decode([]byte(value), &i); err == nil {
+		return strconv.FormatInt(i, 10), true
+	}
+
+	var u uint64
+	if err := enc.Decode([]byte(value), &u); err == nil {
+		return strconv.FormatUint(u, 10), true
+	}
+
+	var f float64
+	if err := enc.Decode([]byte(value), &f); err == nil {
+		return strconv.FormatFloat(f, 'g', -1, 64), true
+	}
+
+	return "", false
+}
+
+// encryptedBool returns the string representation of a boolean or a number encoded by an encrypting enc
+func encryptedBool(enc encoder.Encoder, value string) (string, bool) {
+	if !isEncrypted(enc) {
+		return "", false
+	}
+
+	var b bool
+	if err := enc.Decode([]byte(value), &b); err == nil {
+		return strconv.FormatBool(b), true
+	}
+
+	return encryptedNumber(enc, value)
+}
+
 func isStringNumeric(value string) bool {
 	_, err := strconv.ParseFloat(value, 64)
 
@@ -109,15 +171,6 @@ func interfaceToUint64(value interface{}) (uint64, error) {
 	return stringToUint64(fmt.Sprint(value))
 }
 
-func isInterfaceNumericString(value interface{}) bool {
-	str, valid := value.(string)
-	if !valid {
-		return false
-	}
-
-	return isStringNumeric(str)
-}
-
 func stringToBool(value string) bool {
 	// If the cache value is '0' or 'false' we return false
 	if len(value) > 0 && (value == "0" || value == "false" || value == `""`) {
@@ -130,3 +183,166 @@ func stringToBool(value string) bool {
 
 	return true
 }
+
+// reEncode replaces data via replace whenever enc reports that it needs to be upgraded, e.g. because it was
+// encrypted with a rotated key. replace is handed the data read so that it only overwrites an entry that has not
+// been written since. Failures are ignored given that data has already been read successfully
+func reEncode(enc encoder.Encoder, data []byte, replace func(current, upgraded []byte) error) {
+	reEncoder, valid := enc.(encoder.ReEncoder)
+	if !valid {
+		return
+	}
+	if upgraded, err := reEncoder.ReEncode(data); err == nil && upgraded != nil {
+		_ = replace(data, upgraded)
+	}
+}
+
+// numericString returns the numeric representation of a stored value. Values written via encoder.Envelope are
+// decoded according to their type tag, which allows for encoded numbers to be retrieved via the typed getters
+func numericString(enc encoder.Encoder, value string) (string, error) {
+	header, _, enveloped := encoder.OpenEnvelope([]byte(value))
+	if !enveloped {
+		if isStringNumeric(value) {
+			return value, nil
+		}
+		if n, decoded := encryptedNumber(enc, value); decoded {
+			return n, nil
+		}
+
+		return "", errors.New("invalid numeric value")
+	}
+
+	switch header.Type {
+	case encoder.TypeInt:
+		var n int64
+		if err := enc.Decode([]byte(value), &n); err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(n, 10), nil
+	case encoder.TypeUint:
+		var n uint64
+		if err := enc.Decode([]byte(value), &n); err != nil {
+			return "", err
+		}
+
+		return strconv.FormatUint(n, 10), nil
+	case encoder.TypeFloat:
+		var n float64
+		if err := enc.Decode([]byte(value), &n); err != nil {
+			return "", err
+		}
+
+		return strconv.FormatFloat(n, 'g', -1, 64), nil
+	}
+
+	return "", errors.New("invalid numeric value")
+}
+
+// boolValue returns the boolean representation of a stored value decoding it via enc if needed
+func boolValue(enc encoder.Encoder, value string) (bool, error) {
+	header, _, enveloped := encoder.OpenEnvelope([]byte(value))
+	if !enveloped && (isStringNumeric(value) || isStringBool(value)) {
+		return stringToBool(value), nil
+	}
+	if enveloped && header.Type != encoder.TypeString {
+		if header.Type == encoder.TypeBool {
+			var b bool
+			if err := enc.Decode([]byte(value), &b); err != nil {
+				return false, err
+			}
+
+			return b, nil
+		}
+
+		n, err := numericString(enc, value)
+		if err != nil {
+			return false, err
+		}
+
+		return stringToBool(n), nil
+	}
+
+	var v string
+	if err := enc.Decode([]byte(value), &v); err != nil {
+		if b, decoded := encryptedBool(enc, value); decoded {
+			return stringToBool(b), nil
+		}
+
+		return false, err
+	}
+
+	return stringToBool(v), nil
+}
+
+// stringValue returns the string representation of a stored value decoding it via enc if needed. Enveloped values
+// are decoded according to their type tag, so that encoded strings such as "123" are never mistaken for numbers
+func stringValue(enc encoder.Encoder, value string) (string, error) {
+	header, _, enveloped := encoder.OpenEnvelope([]byte(value))
+	if !enveloped && (isStringNumeric(value) || isStringBool(value)) {
+		return value, nil
+	}
+	if enveloped {
+		switch header.Type {
+		case encoder.TypeInt, encoder.TypeUint, encoder.TypeFloat:
+			return numericString(enc, value)
+		case encoder.TypeBool:
+			b, err := boolValue(enc, value)
+
+			return strconv.FormatBool(b), err
+		}
+	}
+
+	var v string
+	if err := enc.Decode([]byte(value), &v); err != nil {
+		if scalar, decoded := encryptedBool(enc, value); decoded {
+			return scalar, nil
+		}
+
+		return "", err
+	}
+
+	return v, nil
+}
+
+// timeValue returns the time.Time representation of a stored value. Raw RFC 3339 values, e.g. written via PutBytes,
+// are parsed as is while any other value is decoded via enc
+func timeValue(enc encoder.Encoder, value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+
+	var t time.Time
+	if err := enc.Decode([]byte(value), &t); err != nil {
+		return time.Time{}, errors.New("invalid time value")
+	}
+
+	return t, nil
+}
+
+// durationValue returns the time.Duration representation of a stored value. Numbers are interpreted as
+// nanoseconds and strings such as "1m30s" are parsed via time.ParseDuration whether they are raw or encoded
+func durationValue(enc encoder.Encoder, value string) (time.Duration, error) {
+	if n, err := numericString(enc, value); err == nil {
+		if d, err := strconv.ParseInt(n, 10, 64); err == nil {
+			return time.Duration(d), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return d, nil
+	}
+
+	var d time.Duration
+	if err := enc.Decode([]byte(value), &d); err == nil {
+		return d, nil
+	}
+
+	var s string
+	if err := enc.Decode([]byte(value), &s); err == nil {
+		if d, err = time.ParseDuration(s); err == nil {
+			return d, nil
+		}
+	}
+
+	return 0, errors.New("invalid duration value")
+}
@@ -42,16 +42,8 @@ func (i Item) String() (string, error) {
 	if i.err != nil {
 		return "", ErrFailedToRetrieveEntry
 	}
-	if isStringNumeric(i.value) || isStringBool(i.value) {
-		return i.value, nil
-	}
-
-	var v = i.value
-	if err := i.encoder.Decode([]byte(v), &v); err != nil {
-		return "", err
-	}
 
-	return v, nil
+	return stringValue(i.encoder, i.value)
 }
 
 // Uint64 returns the uint64 representation of an Item's value
@@ -59,11 +51,13 @@ func (i Item) Uint64() (uint64, error) {
 	if i.err != nil {
 		return 0, ErrFailedToRetrieveEntry
 	}
-	if !isInterfaceNumericString(i.value) && !isNumeric(i.value) {
-		return 0, errors.New("invalid numeric value")
+
+	value, err := numericString(i.encoder, i.value)
+	if err != nil {
+		return 0, err
 	}
 
-	return strconv.ParseUint(i.value, 10, 64)
+	return strconv.ParseUint(value, 10, 64)
 }
 
 // Int returns the int representation of an Item's value
@@ -71,11 +65,13 @@ func (i Item) Int() (int, error) {
 	if i.err != nil {
 		return 0, ErrFailedToRetrieveEntry
 	}
-	if !isInterfaceNumericString(i.value) && !isNumeric(i.value) {
-		return 0, errors.New("invalid numeric value")
+
+	value, err := numericString(i.encoder, i.value)
+	if err != nil {
+		return 0, err
 	}
 
-	return strconv.Atoi(i.value)
+	return strconv.Atoi(value)
 }
 
 // Bool returns the boolean representation of an Item's value
@@ -84,6 +80,10 @@ func (i Item) Bool() (bool, error) {
 		return false, ErrFailedToRetrieveEntry
 	}
 
+	if _, _, enveloped := encoder.OpenEnvelope([]byte(i.value)); enveloped {
+		return boolValue(i.encoder, i.value)
+	}
+
 	return stringToBool(i.value), nil
 }
 
@@ -92,11 +92,13 @@ func (i Item) Int64() (int64, error) {
 	if i.err != nil {
 		return 0, ErrFailedToRetrieveEntry
 	}
-	if !isInterfaceNumericString(i.value) && !isNumeric(i.value) {
-		return 0, errors.New("invalid numeric value")
+
+	value, err := numericString(i.encoder, i.value)
+	if err != nil {
+		return 0, err
 	}
 
-	return strconv.ParseInt(i.value, 10, 64)
+	return strconv.ParseInt(value, 10, 64)
 }
 
 // Float32 returns the float32 representation of an Item's value
@@ -104,11 +106,13 @@ func (i Item) Float32() (float32, error) {
 	if i.err != nil {
 		return 0, ErrFailedToRetrieveEntry
 	}
-	if !isInterfaceNumericString(i.value) && !isNumeric(i.value) {
-		return 0, errors.New("invalid numeric value")
+
+	value, err := numericString(i.encoder, i.value)
+	if err != nil {
+		return 0, err
 	}
 
-	f, err := strconv.ParseFloat(i.value, 32)
+	f, err := strconv.ParseFloat(value, 32)
 	if err != nil {
 		return 0, err
 	}
@@ -121,11 +125,40 @@ func (i Item) Float64() (float64, error) {
 	if i.err != nil {
 		return 0, ErrFailedToRetrieveEntry
 	}
-	if !isInterfaceNumericString(i.value) && !isNumeric(i.value) {
-		return 0, errors.New("invalid numeric value")
+
+	value, err := numericString(i.encoder, i.value)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// Bytes returns the raw bytes of an Item's value
+func (i Item) Bytes() ([]byte, error) {
+	if i.err != nil {
+		return nil, ErrFailedToRetrieveEntry
+	}
+
+	return []byte(i.value), nil
+}
+
+// Time returns the time.Time representation of an Item's value
+func (i Item) Time() (time.Time, error) {
+	if i.err != nil {
+		return time.Time{}, ErrFailedToRetrieveEntry
+	}
+
+	return timeValue(i.encoder, i.value)
+}
+
+// Duration returns the time.Duration representation of an Item's value
+func (i Item) Duration() (time.Duration, error) {
+	if i.err != nil {
+		return 0, ErrFailedToRetrieveEntry
 	}
 
-	return strconv.ParseFloat(i.value, 64)
+	return durationValue(i.encoder, i.value)
 }
 
 // Unmarshal decodes an Item's value to the provided entity
@@ -134,7 +167,7 @@ func (i Item) Unmarshal(entity interface{}) error {
 		return ErrFailedToRetrieveEntry
 	}
 
-	return i.encoder.Decode([]byte(i.value), entity)
+	return decode(i.encoder, []byte(i.value), entity)
 }
 
 // Error returns the error that occurred when trying to retrieve a given Item
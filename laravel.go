@@ -0,0 +1,95 @@
+package gocache
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// laravelCompatible is implemented by stores that can lay out their keys as Laravel does
+type laravelCompatible interface {
+	laravelCompatible() bool
+}
+
+// isLaravelCompatible reports whether v is a store configured to lay out its keys as Laravel does
+func isLaravelCompatible(v interface{}) bool {
+	l, valid := v.(laravelCompatible)
+
+	return valid && l.laravelCompatible()
+}
+
+// cleanRateLimiterKey mirrors Laravel's RateLimiter::cleanRateLimiterKey, which runs the key through htmlentities
+// (PHP 8.1 defaults) and replaces every named entity by its first letter, e.g. "é" becomes "e" and "&" becomes "a"
+func cleanRateLimiterKey(key string) string {
+	var b strings.Builder
+	for len(key) > 0 {
+		r, size := utf8.DecodeRuneInString(key)
+		key = key[size:]
+		if r == utf8.RuneError && size == 1 {
+			// ENT_SUBSTITUTE replaces invalid code unit sequences with U+FFFD
+			b.WriteRune(utf8.RuneError)
+
+			continue
+		}
+		if r == '\'' {
+			b.WriteString("&#039;")
+
+			continue
+		}
+		if replacement, exists := htmlEntityInitials[r]; exists {
+			b.WriteString(replacement)
+
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// htmlEntityInitials maps the characters htmlentities converts to HTML 4.01 named entities to the first letter of
+// the entity name. Entities whose names contain digits are kept as is given that Laravel's pattern does not match them
+var htmlEntityInitials = map[rune]string{
+	0x0022: "q", 0x0026: "a", 0x003c: "l", 0x003e: "g", 0x00a0: "n", 0x00a1: "i",
+	0x00a2: "c", 0x00a3: "p", 0x00a4: "c", 0x00a5: "y", 0x00a6: "b", 0x00a7: "s",
+	0x00a8: "u", 0x00a9: "c", 0x00aa: "o", 0x00ab: "l", 0x00ac: "n", 0x00ad: "s",
+	0x00ae: "r", 0x00af: "m", 0x00b0: "d", 0x00b1: "p", 0x00b2: "&sup2;", 0x00b3: "&sup3;",
+	0x00b4: "a", 0x00b5: "m", 0x00b6: "p", 0x00b7: "m", 0x00b8: "c", 0x00b9: "&sup1;",
+	0x00ba: "o", 0x00bb: "r", 0x00bc: "&frac14;", 0x00bd: "&frac12;", 0x00be: "&frac34;", 0x00bf: "i",
+	0x00c0: "A", 0x00c1: "A", 0x00c2: "A", 0x00c3: "A", 0x00c4: "A", 0x00c5: "A",
+	0x00c6: "A", 0x00c7: "C", 0x00c8: "E", 0x00c9: "E", 0x00ca: "E", 0x00cb: "E",
+	0x00cc: "I", 0x00cd: "I", 0x00ce: "I", 0x00cf: "I", 0x00d0: "E", 0x00d1: "N",
+	0x00d2: "O", 0x00d3: "O", 0x00d4: "O", 0x00d5: "O", 0x00d6: "O", 0x00d7: "t",
+	0x00d8: "O", 0x00d9: "U", 0x00da: "U", 0x00db: "U", 0x00dc: "U", 0x00dd: "Y",
+	0x00de: "T", 0x00df: "s", 0x00e0: "a", 0x00e1: "a", 0x00e2: "a", 0x00e3: "a",
+	0x00e4: "a", 0x00e5: "a", 0x00e6: "a", 0x00e7: "c", 0x00e8: "e", 0x00e9: "e",
+	0x00ea: "e", 0x00eb: "e", 0x00ec: "i", 0x00ed: "i", 0x00ee: "i", 0x00ef: "i",
+	0x00f0: "e", 0x00f1: "n", 0x00f2: "o", 0x00f3: "o", 0x00f4: "o", 0x00f5: "o",
+	0x00f6: "o", 0x00f7: "d", 0x00f8: "o", 0x00f9: "u", 0x00fa: "u", 0x00fb: "u",
+	0x00fc: "u", 0x00fd: "y", 0x00fe: "t", 0x00ff: "y", 0x0152: "O", 0x0153: "o",
+	0x0160: "S", 0x0161: "s", 0x0178: "Y", 0x0192: "f", 0x02c6: "c", 0x02dc: "t",
+	0x0391: "A", 0x0392: "B", 0x0393: "G", 0x0394: "D", 0x0395: "E", 0x0396: "Z",
+	0x0397: "E", 0x0398: "T", 0x0399: "I", 0x039a: "K", 0x039b: "L", 0x039c: "M",
+	0x039d: "N", 0x039e: "X", 0x039f: "O", 0x03a0: "P", 0x03a1: "R", 0x03a3: "S",
+	0x03a4: "T", 0x03a5: "U", 0x03a6: "P", 0x03a7: "C", 0x03a8: "P", 0x03a9: "O",
+	0x03b1: "a", 0x03b2: "b", 0x03b3: "g", 0x03b4: "d", 0x03b5: "e", 0x03b6: "z",
+	0x03b7: "e", 0x03b8: "t", 0x03b9: "i", 0x03ba: "k", 0x03bb: "l", 0x03bc: "m",
+	0x03bd: "n", 0x03be: "x", 0x03bf: "o", 0x03c0: "p", 0x03c1: "r", 0x03c2: "s",
+	0x03c3: "s", 0x03c4: "t", 0x03c5: "u", 0x03c6: "p", 0x03c7: "c", 0x03c8: "p",
+	0x03c9: "o", 0x03d1: "t", 0x03d2: "u", 0x03d6: "p", 0x2002: "e", 0x2003: "e",
+	0x2009: "t", 0x200c: "z", 0x200d: "z", 0x200e: "l", 0x200f: "r", 0x2013: "n",
+	0x2014: "m", 0x2018: "l", 0x2019: "r", 0x201a: "s", 0x201c: "l", 0x201d: "r",
+	0x201e: "b", 0x2020: "d", 0x2021: "D", 0x2022: "b", 0x2026: "h", 0x2030: "p",
+	0x2032: "p", 0x2033: "P", 0x2039: "l", 0x203a: "r", 0x203e: "o", 0x2044: "f",
+	0x20ac: "e", 0x2111: "i", 0x2118: "w", 0x211c: "r", 0x2122: "t", 0x2135: "a",
+	0x2190: "l", 0x2191: "u", 0x2192: "r", 0x2193: "d", 0x2194: "h", 0x21b5: "c",
+	0x21d0: "l", 0x21d1: "u", 0x21d2: "r", 0x21d3: "d", 0x21d4: "h", 0x2200: "f",
+	0x2202: "p", 0x2203: "e", 0x2205: "e", 0x2207: "n", 0x2208: "i", 0x2209: "n",
+	0x220b: "n", 0x220f: "p", 0x2211: "s", 0x2212: "m", 0x2217: "l", 0x221a: "r",
+	0x221d: "p", 0x221e: "i", 0x2220: "a", 0x2227: "a", 0x2228: "o", 0x2229: "c",
+	0x222a: "c", 0x222b: "i", 0x2234: "&there4;", 0x223c: "s", 0x2245: "c", 0x2248: "a",
+	0x2260: "n", 0x2261: "e", 0x2264: "l", 0x2265: "g", 0x2282: "s", 0x2283: "s",
+	0x2284: "n", 0x2286: "s", 0x2287: "s", 0x2295: "o", 0x2297: "o", 0x22a5: "p",
+	0x22c5: "s", 0x2308: "l", 0x2309: "r", 0x230a: "l", 0x230b: "r", 0x2329: "l",
+	0x232a: "r", 0x25ca: "l", 0x2660: "s", 0x2663: "c", 0x2665: "h", 0x2666: "d",
+}
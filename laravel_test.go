@@ -0,0 +1,206 @@
+package gocache
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+	"github.com/alejandro-carstens/gocache/gocachetest/testserver"
+)
+
+func TestLaravelCompatible(t *testing.T) {
+	var (
+		addr       = testserver.RunRedis(t).Addr()
+		ctx        = context.Background()
+		client     = redis.NewClient(&redis.Options{Addr: addr})
+		php        = encoder.PHPSerialize{}
+		prefix     = "app_database_laravel_cache:"
+		cache, err = Open("redis://" + addr + "?prefix=laravel_cache:&connection_prefix=app_database_&laravel_compatible=true&encoder=php")
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+		require.NoError(t, cache.Close())
+	})
+
+	tagged := cache.Tags("people", "authors")
+	require.NoError(t, tagged.Put("name", "Taylor", time.Minute))
+	require.NoError(t, tagged.Forever("counts", map[string]int{"posts": 3}))
+
+	var ids []string
+	for _, name := range []string{"people", "authors"} {
+		raw, err := client.Get(ctx, prefix+"tag:"+name+":key").Bytes()
+		require.NoError(t, err)
+
+		var id string
+		require.NoError(t, php.Decode(raw, &id))
+		require.Equal(t, `s:20:"`+id+`";`, string(raw))
+
+		ids = append(ids, id)
+	}
+
+	sum := sha1.Sum([]byte(ids[0] + "|" + ids[1]))
+	namespace := hex.EncodeToString(sum[:])
+	require.Equal(t, `s:6:"Taylor";`, client.Get(ctx, prefix+namespace+":name").Val())
+	require.Equal(t, `a:1:{s:5:"posts";i:3;}`, client.Get(ctx, prefix+namespace+":counts").Val())
+	for _, id := range ids {
+		require.Equal(t, []string{"laravel_cache:" + namespace + ":name"}, client.SMembers(ctx, prefix+id+":standard_ref").Val())
+		require.Equal(t, []string{"laravel_cache:" + namespace + ":counts"}, client.SMembers(ctx, prefix+id+":forever_ref").Val())
+	}
+
+	keys, cursor, err := tagged.Keys("", 0)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"name", "counts"}, keys)
+	require.Empty(t, cursor)
+
+	counts, err := tagged.TagSet().Counts()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"people": 2, "authors": 2}, counts)
+
+	// An entry written by Laravel
+	require.NoError(t, client.Set(ctx, prefix+namespace+":user", `O:8:"stdClass":1:{s:4:"name";s:4:"Jess";}`, 0).Err())
+	require.NoError(t, client.SAdd(ctx, prefix+ids[0]+":forever_ref", "laravel_cache:"+namespace+":user").Err())
+
+	var user struct {
+		Name string `php:"name"`
+	}
+	require.NoError(t, tagged.Get("user", &user))
+	require.Equal(t, "Jess", user.Name)
+
+	flushed, err := tagged.Flush()
+	require.NoError(t, err)
+	require.True(t, flushed)
+	require.EqualValues(t, 0, client.Exists(ctx,
+		prefix+namespace+":name",
+		prefix+namespace+":counts",
+		prefix+namespace+":user",
+		prefix+ids[0]+":standard_ref",
+		prefix+ids[0]+":forever_ref",
+		prefix+ids[1]+":standard_ref",
+		prefix+ids[1]+":forever_ref",
+	).Val())
+
+	require.NoError(t, cache.Put("enabled", true, time.Minute))
+	require.Equal(t, "b:1;", client.Get(ctx, prefix+"enabled").Val())
+
+	enabled, err := cache.GetBool("enabled")
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	acquired, err := cache.Lock("import", "worker-1", time.Minute).Acquire()
+	require.NoError(t, err)
+	require.True(t, acquired)
+	require.Equal(t, "worker-1", client.Get(ctx, prefix+"import").Val())
+
+	hits, err := NewRateLimiter(cache).Hit("login:josé&co", time.Minute)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, hits)
+	require.Equal(t, "1", client.Get(ctx, prefix+"login:joseaco").Val())
+	require.EqualValues(t, 1, client.Exists(ctx, prefix+"login:joseaco:timer").Val())
+}
+
+func TestLaravelCompatible_Fixture(t *testing.T) {
+	var (
+		addr       = testserver.RunRedis(t).Addr()
+		ctx        = context.Background()
+		client     = redis.NewClient(&redis.Options{Addr: addr})
+		cache, err = Open("redis://" + addr + "?prefix=laravel_cache:&connection_prefix=app_database_&laravel_compatible=true&encoder=php")
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+		require.NoError(t, cache.Close())
+	})
+
+	// Replay the writes of a Laravel application
+	for _, args := range monitorCommands(t, "testdata/laravel_tagged_cache.monitor") {
+		if err := client.Do(ctx, args...).Err(); err != redis.Nil {
+			require.NoError(t, err)
+		}
+	}
+	written := client.Keys(ctx, "*").Val()
+
+	tagged := cache.Tags("people", "authors")
+	name, err := tagged.GetString("name")
+	require.NoError(t, err)
+	require.Equal(t, "Taylor", name)
+
+	var counts map[string]int
+	require.NoError(t, tagged.Get("counts", &counts))
+	require.Equal(t, map[string]int{"posts": 3}, counts)
+
+	keys, _, err := tagged.Keys("", 0)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"name", "counts"}, keys)
+
+	// Writing the same entries records them in the references Laravel uses
+	require.NoError(t, tagged.Put("name", "Taylor", time.Minute))
+	require.NoError(t, tagged.Forever("counts", map[string]int{"posts": 3}))
+	require.ElementsMatch(t, written, client.Keys(ctx, "*").Val())
+
+	flushed, err := tagged.Flush()
+	require.NoError(t, err)
+	require.True(t, flushed)
+	require.ElementsMatch(t, []string{
+		"app_database_laravel_cache:tag:people:key",
+		"app_database_laravel_cache:tag:authors:key",
+	}, client.Keys(ctx, "*").Val())
+}
+
+var monitorArgument = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// monitorCommands parses the commands of a redis-cli MONITOR capture, skipping the lines starting with #
+func monitorCommands(t *testing.T, path string) [][]interface{} {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var (
+		commands [][]interface{}
+		scanner  = bufio.NewScanner(f)
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var args []interface{}
+		for _, quoted := range monitorArgument.FindAllString(line, -1) {
+			arg, err := strconv.Unquote(quoted)
+			require.NoError(t, err)
+
+			args = append(args, arg)
+		}
+		commands = append(commands, args)
+	}
+	require.NoError(t, scanner.Err())
+
+	return commands
+}
+
+func TestCleanRateLimiterKey(t *testing.T) {
+	for key, expected := range map[string]string{
+		"login:127.0.0.1":  "login:127.0.0.1",
+		"Ärger's <b>":      "Arger&#039;s lbg",
+		"½ & \"quoted\"":   "&frac12; a qquotedq",
+		"naïve\xffcafé":    "naive�cafe",
+		"ÆØß€—":            "AOsem",
+		"already &amp; ok": "already aamp; ok",
+	} {
+		require.Equal(t, expected, cleanRateLimiterKey(key), key)
+	}
+}
@@ -1,8 +1,14 @@
 package gocache
 
 import (
+	"bytes"
+	"container/heap"
 	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -10,12 +16,20 @@ import (
 	"github.com/alejandro-carstens/gocache/encoder"
 )
 
-var _ Cache = &LocalStore{}
+// localTagIndexMinPrune is the minimum number of keys an index needs to hold before it is pruned
+const localTagIndexMinPrune = 64
+
+var (
+	_ Cache    = &LocalStore{}
+	_ Streamer = &LocalStore{}
+	_ tagIndex = &LocalStore{}
+	_ tagKeys  = &LocalStore{}
+)
 
 // NewLocalStore validates the passed in config and creates a Cache implementation of type *LocalStore
 func NewLocalStore(cnf *LocalConfig, encoder encoder.Encoder) (*LocalStore, error) {
 	if err := cnf.validate(); err != nil {
-		return nil, nil
+		return nil, err
 	}
 
 	return &LocalStore{
@@ -24,8 +38,10 @@ func NewLocalStore(cnf *LocalConfig, encoder encoder.Encoder) (*LocalStore, erro
 		},
 		defaultExpiration: cnf.DefaultExpiration,
 		defaultInterval:   cnf.DefaultInterval,
+		maxEntries:        cnf.MaxEntries,
 		c:                 cache.New(cnf.DefaultExpiration, cnf.DefaultInterval),
 		encoder:           encoder,
+		tagIndex:          map[string]*localTagIndex{},
 	}, nil
 }
 
@@ -35,7 +51,12 @@ type LocalStore struct {
 	c                 *cache.Cache
 	defaultExpiration time.Duration
 	defaultInterval   time.Duration
+	maxEntries        int
 	encoder           encoder.Encoder
+	tagIndexMu        sync.Mutex
+	tagIndex          map[string]*localTagIndex
+	expiriesMu        sync.Mutex
+	expiries          localExpiries
 }
 
 // GetString gets a string value from the store
@@ -53,22 +74,21 @@ func (s *LocalStore) GetString(key string) (string, error) {
 		return "", errors.New("cannot decode cached value")
 	}
 
-	var v string
-	if err := s.encoder.Decode(data, &v); err != nil {
+	v, err := stringValue(s.encoder, string(data))
+	if err != nil {
 		return "", err
 	}
 
+	reEncode(s.encoder, data, s.replacer(key))
+
 	return v, nil
 }
 
 // GetFloat64 gets a float value from the store
 func (s *LocalStore) GetFloat64(key string) (float64, error) {
-	value, valid := s.c.Get(s.k(key))
-	if !valid {
-		return 0, ErrNotFound
-	}
-	if !isNumeric(value) {
-		return 0, errors.New("invalid numeric value")
+	value, err := s.numeric(key)
+	if err != nil {
+		return 0, err
 	}
 
 	return interfaceToFloat64(value)
@@ -76,12 +96,9 @@ func (s *LocalStore) GetFloat64(key string) (float64, error) {
 
 // GetFloat32 gets a float32 value from the store
 func (s *LocalStore) GetFloat32(key string) (float32, error) {
-	value, valid := s.c.Get(s.k(key))
-	if !valid {
-		return 0, ErrNotFound
-	}
-	if !isNumeric(value) {
-		return 0, errors.New("invalid numeric value")
+	value, err := s.numeric(key)
+	if err != nil {
+		return 0, err
 	}
 
 	return interfaceToFloat32(value)
@@ -89,12 +106,9 @@ func (s *LocalStore) GetFloat32(key string) (float32, error) {
 
 // GetInt64 gets an int value from the store
 func (s *LocalStore) GetInt64(key string) (int64, error) {
-	value, valid := s.c.Get(s.k(key))
-	if !valid {
-		return 0, ErrNotFound
-	}
-	if !isNumeric(value) {
-		return 0, errors.New("invalid numeric value")
+	value, err := s.numeric(key)
+	if err != nil {
+		return 0, err
 	}
 
 	return interfaceToInt64(value)
@@ -102,12 +116,9 @@ func (s *LocalStore) GetInt64(key string) (int64, error) {
 
 // GetInt gets an int value from the store
 func (s *LocalStore) GetInt(key string) (int, error) {
-	value, valid := s.c.Get(s.k(key))
-	if !valid {
-		return 0, ErrNotFound
-	}
-	if !isNumeric(value) {
-		return 0, errors.New("invalid numeric value")
+	value, err := s.numeric(key)
+	if err != nil {
+		return 0, err
 	}
 
 	return interfaceToInt(value)
@@ -115,12 +126,9 @@ func (s *LocalStore) GetInt(key string) (int, error) {
 
 // GetUint64 gets an uint64 value from the store
 func (s *LocalStore) GetUint64(key string) (uint64, error) {
-	value, valid := s.c.Get(s.k(key))
-	if !valid {
-		return 0, ErrNotFound
-	}
-	if !isNumeric(value) {
-		return 0, errors.New("invalid numeric value")
+	value, err := s.numeric(key)
+	if err != nil {
+		return 0, err
 	}
 
 	return interfaceToUint64(value)
@@ -141,12 +149,67 @@ func (s *LocalStore) GetBool(key string) (bool, error) {
 		return false, errors.New("cannot decode cached value")
 	}
 
-	var v string
-	if err := s.encoder.Decode(data, &v); err != nil {
-		return false, err
+	return boolValue(s.encoder, string(data))
+}
+
+// GetTime gets a time.Time value from the store
+func (s *LocalStore) GetTime(key string) (time.Time, error) {
+	value, err := s.raw(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return timeValue(s.encoder, string(value))
+}
+
+// GetDuration gets a time.Duration value from the store
+func (s *LocalStore) GetDuration(key string) (time.Duration, error) {
+	value, err := s.raw(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return durationValue(s.encoder, string(value))
+}
+
+// GetBytes gets the raw bytes stored for the given key bypassing the encoder. Numbers and booleans are returned
+// in their string representation as they would be by any other store
+func (s *LocalStore) GetBytes(key string) ([]byte, error) {
+	value, err := s.raw(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(nil), value...), nil
+}
+
+// PutBytes puts raw bytes in the given store for a predetermined amount of time bypassing the encoder
+func (s *LocalStore) PutBytes(key string, value []byte, duration time.Duration) error {
+	s.set(s.k(key), append([]byte(nil), value...), duration)
+
+	return nil
+}
+
+// PutStream implementation of the Streamer interface. Given that entries are held in memory the stream is
+// buffered as a whole before being stored
+func (s *LocalStore) PutStream(key string, r io.Reader, duration time.Duration) error {
+	return writeStream(s.encoder, &bufferStreamWriter{
+		put: func(value []byte) error {
+			s.set(s.k(key), value, duration)
+
+			return nil
+		},
+	}, r)
+}
+
+// GetStream implementation of the Streamer interface
+func (s *LocalStore) GetStream(key string, w io.Writer) error {
+	value, err := s.raw(key)
+	if err != nil {
+		return err
 	}
 
-	return stringToBool(v), nil
+	return readStream(bytes.NewReader(value), w)
 }
 
 // Increment increments an integer counter by a given value
@@ -186,17 +249,17 @@ func (s *LocalStore) Decrement(key string, value int64) (int64, error) {
 // Put puts a value in the given store for a predetermined amount of time in seconds.
 func (s *LocalStore) Put(key string, value interface{}, duration time.Duration) error {
 	if isNumeric(value) || isBool(value) {
-		s.c.Set(s.k(key), value, duration)
+		s.set(s.k(key), value, duration)
 
 		return nil
 	}
 
-	val, err := s.encoder.Encode(value)
+	val, err := encode(s.encoder, value)
 	if err != nil {
 		return err
 	}
 
-	s.c.Set(s.k(key), val, duration)
+	s.set(s.k(key), val, duration)
 
 	return nil
 }
@@ -205,15 +268,20 @@ func (s *LocalStore) Put(key string, value interface{}, duration time.Duration)
 // expired. If the record was successfully added true will be returned else false will be returned
 func (s *LocalStore) Add(key string, value interface{}, duration time.Duration) (bool, error) {
 	if isNumeric(value) || isBool(value) {
-		return s.c.Add(s.k(key), value, duration) == nil, nil
+		return s.add(s.k(key), value, duration), nil
 	}
 
-	val, err := s.encoder.Encode(value)
+	val, err := encode(s.encoder, value)
 	if err != nil {
 		return false, err
 	}
 
-	return s.c.Add(s.k(key), val, duration) == nil, nil
+	return s.add(s.k(key), val, duration), nil
+}
+
+// addForever implementation of the foreverAdder interface
+func (s *LocalStore) addForever(key string, value interface{}) (bool, error) {
+	return s.Add(key, value, cache.NoExpiration)
 }
 
 // Forever puts a value in the given store until it is forgotten/evicted
@@ -225,6 +293,14 @@ func (s *LocalStore) Forever(key string, value interface{}) error {
 func (s *LocalStore) Flush() (bool, error) {
 	s.c.Flush()
 
+	s.tagIndexMu.Lock()
+	s.tagIndex = map[string]*localTagIndex{}
+	s.tagIndexMu.Unlock()
+
+	s.expiriesMu.Lock()
+	s.expiries = nil
+	s.expiriesMu.Unlock()
+
 	return true, nil
 }
 
@@ -307,8 +383,13 @@ func (s *LocalStore) Get(key string, entity interface{}) error {
 	if !valid {
 		return errors.New("cannot decode cached value")
 	}
+	if err := decode(s.encoder, data, entity); err != nil {
+		return err
+	}
+
+	reEncode(s.encoder, data, s.replacer(key))
 
-	return s.encoder.Decode(data, entity)
+	return nil
 }
 
 // Close closes the c releasing all open resources
@@ -318,12 +399,15 @@ func (*LocalStore) Close() error {
 
 // Tags returns the taggedCache for the given store
 func (s *LocalStore) Tags(names ...string) TaggedCache {
-	return &taggedCache{
-		store: s,
-		tags: &TagSet{
+	return &indexedTaggedCache{
+		taggedCache: taggedCache{
 			store: s,
-			names: names,
+			tags: &TagSet{
+				store: s,
+				names: names,
+			},
 		},
+		index: s,
 	}
 }
 
@@ -343,3 +427,346 @@ func (s *LocalStore) Exists(key string) (bool, error) {
 func (s *LocalStore) Expire(string, time.Duration) error {
 	return ErrNotImplemented
 }
+
+// numeric returns the numeric value stored for key decoding it if needed
+func (s *LocalStore) numeric(key string) (interface{}, error) {
+	value, valid := s.c.Get(s.k(key))
+	if !valid {
+		return nil, ErrNotFound
+	}
+	if isNumeric(value) {
+		return value, nil
+	}
+
+	data, valid := value.([]byte)
+	if !valid {
+		return nil, errors.New("invalid numeric value")
+	}
+
+	return numericString(s.encoder, string(data))
+}
+
+// raw returns the bytes representation of the value stored for key
+func (s *LocalStore) raw(key string) ([]byte, error) {
+	value, valid := s.c.Get(s.k(key))
+	if !valid {
+		return nil, ErrNotFound
+	}
+	if isNumeric(value) || isBool(value) {
+		return []byte(fmt.Sprint(value)), nil
+	}
+
+	data, valid := value.([]byte)
+	if !valid {
+		return nil, errors.New("cannot decode cached value")
+	}
+
+	return data, nil
+}
+
+// replacer returns a function replacing the value of an existing entry while keeping its expiration, as long as it
+// still holds the value that was read
+func (s *LocalStore) replacer(key string) func(current, upgraded []byte) error {
+	return func(current, upgraded []byte) error {
+		value, expiration, exists := s.c.GetWithExpiration(s.k(key))
+		if !exists {
+			return ErrNotFound
+		}
+		if data, valid := value.([]byte); !valid || !bytes.Equal(data, current) {
+			return nil
+		}
+
+		var duration time.Duration = cache.NoExpiration
+		if !expiration.IsZero() {
+			if duration = time.Until(expiration); duration <= 0 {
+				return ErrNotFound
+			}
+		}
+
+		s.c.Set(s.k(key), upgraded, duration)
+		s.track(s.k(key))
+
+		return nil
+	}
+}
+
+// set makes room for and sets the given prefixed key
+func (s *LocalStore) set(key string, value interface{}, duration time.Duration) {
+	s.makeRoom(key)
+	s.c.Set(key, value, duration)
+	s.track(key)
+}
+
+// add makes room for and adds the given prefixed key, returning whether it was added
+func (s *LocalStore) add(key string, value interface{}, duration time.Duration) bool {
+	s.makeRoom(key)
+	if s.c.Add(key, value, duration) != nil {
+		return false
+	}
+
+	s.track(key)
+
+	return true
+}
+
+// track records the expiration of the given prefixed key so that makeRoom can evict it in order. The expiries are
+// rebuilt from the live entries once they reach twice MaxEntries, dropping the ones outdated by later writes
+func (s *LocalStore) track(key string) {
+	if s.maxEntries <= 0 {
+		return
+	}
+
+	_, expiration, exists := s.c.GetWithExpiration(key)
+	if !exists {
+		return
+	}
+
+	s.expiriesMu.Lock()
+	defer s.expiriesMu.Unlock()
+
+	if len(s.expiries) >= 2*s.maxEntries {
+		s.rebuildExpiries()
+	}
+
+	heap.Push(&s.expiries, localExpiry{key: key, expiration: unixNano(expiration)})
+}
+
+// makeRoom evicts entries when the store holds MaxEntries entries and the given prefixed key is not one of them.
+// Expired entries are evicted first followed by the ones closest to expiring
+func (s *LocalStore) makeRoom(key string) {
+	if s.maxEntries <= 0 || s.c.ItemCount() < s.maxEntries {
+		return
+	}
+	if _, exists := s.c.Get(key); exists {
+		return
+	}
+
+	s.expiriesMu.Lock()
+	defer s.expiriesMu.Unlock()
+
+	for s.c.ItemCount() >= s.maxEntries {
+		if len(s.expiries) == 0 {
+			if s.rebuildExpiries(); len(s.expiries) == 0 {
+				return
+			}
+		}
+
+		expiry := heap.Pop(&s.expiries).(localExpiry)
+		if _, expiration, exists := s.c.GetWithExpiration(expiry.key); exists && unixNano(expiration) != expiry.expiration {
+			continue
+		}
+
+		s.c.Delete(expiry.key)
+	}
+}
+
+// rebuildExpiries replaces the expiries with the ones of the live entries. The caller must hold expiriesMu
+func (s *LocalStore) rebuildExpiries() {
+	s.c.DeleteExpired()
+
+	items := s.c.Items()
+	s.expiries = make(localExpiries, 0, len(items))
+	for k, item := range items {
+		s.expiries = append(s.expiries, localExpiry{key: k, expiration: item.Expiration})
+	}
+
+	heap.Init(&s.expiries)
+}
+
+// unixNano returns the given expiration in nanoseconds, zero standing for no expiration
+func unixNano(expiration time.Time) int64 {
+	if expiration.IsZero() {
+		return 0
+	}
+
+	return expiration.UnixNano()
+}
+
+// localExpiry is the expiration in nanoseconds a prefixed key was written with, zero standing for no expiration
+type localExpiry struct {
+	key        string
+	expiration int64
+}
+
+// localExpiries is a min-heap of expirations in which the ones that never expire come last. Keys that are
+// overwritten or deleted are not removed from it, their outdated expirations being skipped once popped
+type localExpiries []localExpiry
+
+// Len implementation of the heap.Interface interface
+func (e localExpiries) Len() int {
+	return len(e)
+}
+
+// Less implementation of the heap.Interface interface
+func (e localExpiries) Less(i, j int) bool {
+	a, b := e[i].expiration, e[j].expiration
+	if a == 0 || b == 0 {
+		return b == 0 && a != 0
+	}
+
+	return a < b
+}
+
+// Swap implementation of the heap.Interface interface
+func (e localExpiries) Swap(i, j int) {
+	e[i], e[j] = e[j], e[i]
+}
+
+// Push implementation of the heap.Interface interface
+func (e *localExpiries) Push(x interface{}) {
+	*e = append(*e, x.(localExpiry))
+}
+
+// Pop implementation of the heap.Interface interface
+func (e *localExpiries) Pop() interface{} {
+	old := *e
+	expiry := old[len(old)-1]
+	*e = old[:len(old)-1]
+
+	return expiry
+}
+
+// localTagIndex holds the set of keys written under a tag id
+type localTagIndex struct {
+	keys map[string]struct{}
+	// sorted holds the keys in lexicographical order. It is built when scanning and reset by every write
+	sorted []string
+	// fresh holds the keys added since the last prune, which are kept given that their entries may not be written yet
+	fresh map[string]struct{}
+	// pruneAt is the number of keys at which the keys whose entries are gone are pruned
+	pruneAt int
+}
+
+// sortedKeys returns the keys of the index in lexicographical order, sorting them unless no key was added or pruned
+// since the last call
+func (index *localTagIndex) sortedKeys() []string {
+	if index.sorted == nil {
+		index.sorted = make([]string, 0, len(index.keys))
+		for key := range index.keys {
+			index.sorted = append(index.sorted, key)
+		}
+
+		sort.Strings(index.sorted)
+	}
+
+	return index.sorted
+}
+
+// indexTagKey implementation of the tagIndex interface. Keys whose entries have expired or been evicted are pruned
+// whenever an index doubles in size, except for the ones added since the previous prune as keys are indexed before
+// their entries are written
+func (s *LocalStore) indexTagKey(ids []string, key string) error {
+	s.tagIndexMu.Lock()
+	defer s.tagIndexMu.Unlock()
+
+	for _, id := range ids {
+		index, exists := s.tagIndex[id]
+		if !exists {
+			index = &localTagIndex{
+				keys:    map[string]struct{}{},
+				fresh:   map[string]struct{}{},
+				pruneAt: localTagIndexMinPrune,
+			}
+			s.tagIndex[id] = index
+		}
+		if _, exists = index.keys[key]; exists {
+			continue
+		}
+
+		index.keys[key] = struct{}{}
+		index.fresh[key] = struct{}{}
+		index.sorted = nil
+		if len(index.keys) < index.pruneAt {
+			continue
+		}
+
+		for k := range index.keys {
+			if _, fresh := index.fresh[k]; fresh {
+				continue
+			}
+			if _, found := s.c.Get(s.k(k)); !found {
+				delete(index.keys, k)
+			}
+		}
+
+		index.fresh = map[string]struct{}{}
+
+		index.pruneAt = 2 * len(index.keys)
+		if index.pruneAt < localTagIndexMinPrune {
+			index.pruneAt = localTagIndexMinPrune
+		}
+	}
+
+	return nil
+}
+
+// flushTagIndex implementation of the tagIndex interface
+func (s *LocalStore) flushTagIndex(id string) error {
+	s.tagIndexMu.Lock()
+	index, exists := s.tagIndex[id]
+	delete(s.tagIndex, id)
+	s.tagIndexMu.Unlock()
+	if !exists {
+		return nil
+	}
+
+	for key := range index.keys {
+		s.c.Delete(s.k(key))
+	}
+
+	return nil
+}
+
+// scanTagKeys implementation of the tagKeys interface. Keys are scanned in lexicographical order, the cursor being
+// the last key examined, and those whose entries are gone are skipped
+func (s *LocalStore) scanTagKeys(id, prefix, cursor string, count int) ([]string, string, error) {
+	s.tagIndexMu.Lock()
+	defer s.tagIndexMu.Unlock()
+
+	index, exists := s.tagIndex[id]
+	if !exists {
+		return nil, "", nil
+	}
+
+	var (
+		sorted = index.sortedKeys()
+		keys   = sorted[sort.SearchStrings(sorted, cursor):]
+		next   string
+	)
+	if len(keys) > 0 && keys[0] == cursor {
+		keys = keys[1:]
+	}
+	if len(keys) > count {
+		keys = keys[:count]
+		next = keys[count-1]
+	}
+
+	var matches []string
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if _, found := s.c.Get(s.k(key)); found {
+			matches = append(matches, key)
+		}
+	}
+
+	return matches, next, nil
+}
+
+// countTagKeys implementation of the tagKeys interface
+func (s *LocalStore) countTagKeys(id string) (int64, error) {
+	s.tagIndexMu.Lock()
+	defer s.tagIndexMu.Unlock()
+
+	var count int64
+	if index, exists := s.tagIndex[id]; exists {
+		for key := range index.keys {
+			if _, found := s.c.Get(s.k(key)); found {
+				count++
+			}
+		}
+	}
+
+	return count, nil
+}
@@ -99,6 +99,7 @@ func TestLock_Block(t *testing.T) {
 				var (
 					cache = createStore(t, d, e)
 					wg    sync.WaitGroup
+					mu    sync.Mutex
 
 					cnt             int
 					acquiredResults []bool
@@ -109,12 +110,17 @@ func TestLock_Block(t *testing.T) {
 				go func() {
 					defer wg.Done()
 
-					acquired, err := l.Block(25*time.Millisecond, 80*time.Millisecond, func() error {
+					acquired, err := l.Block(25*time.Millisecond, 150*time.Millisecond, func() error {
+						mu.Lock()
 						cnt++
-						time.Sleep(50 * time.Millisecond)
+						mu.Unlock()
+						time.Sleep(100 * time.Millisecond)
 
 						return nil
 					})
+
+					mu.Lock()
+					defer mu.Unlock()
 					if err != nil {
 						errs = append(errs, err)
 					}
@@ -123,12 +129,17 @@ func TestLock_Block(t *testing.T) {
 				go func() {
 					defer wg.Done()
 
-					acquired, err := l.Block(25*time.Millisecond, 80*time.Millisecond, func() error {
+					acquired, err := l.Block(25*time.Millisecond, 150*time.Millisecond, func() error {
+						mu.Lock()
 						cnt++
-						time.Sleep(50 * time.Millisecond)
+						mu.Unlock()
+						time.Sleep(100 * time.Millisecond)
 
 						return nil
 					})
+
+					mu.Lock()
+					defer mu.Unlock()
 					if err != nil {
 						errs = append(errs, err)
 					}
@@ -137,12 +148,17 @@ func TestLock_Block(t *testing.T) {
 				go func() {
 					defer wg.Done()
 
-					acquired, err := l.Block(25*time.Millisecond, 80*time.Millisecond, func() error {
+					acquired, err := l.Block(25*time.Millisecond, 150*time.Millisecond, func() error {
+						mu.Lock()
 						cnt++
-						time.Sleep(50 * time.Millisecond)
+						mu.Unlock()
+						time.Sleep(100 * time.Millisecond)
 
 						return nil
 					})
+
+					mu.Lock()
+					defer mu.Unlock()
 					if err != nil {
 						errs = append(errs, err)
 					}
@@ -169,7 +185,7 @@ func TestLock_Block(t *testing.T) {
 
 func TestLock_Expire(t *testing.T) {
 	for _, e := range encoders {
-		for _, d := range drivers(t, localDriver, redisDriver) {
+		for _, d := range drivers(t, localDriver, redisDriver, shardedDriver) {
 			t.Run(d.string(), func(t *testing.T) {
 				var (
 					cache    = createStore(t, d, e)
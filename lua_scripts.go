@@ -10,6 +10,13 @@ end
 `
 	redisLuaAddScript = `
 return redis.call('exists',KEYS[1])<1 and redis.call('setex',KEYS[1],ARGV[2],ARGV[1])
+`
+	redisLuaReplaceScript = `
+if redis.call('get',KEYS[1]) == ARGV[1] then
+    return redis.call('set',KEYS[1],ARGV[2],'KEEPTTL')
+end
+
+return false
 `
 	redisLuaExpireLockScript = `
 if redis.call("get",KEYS[1]) == ARGV[1] then
@@ -0,0 +1,323 @@
+package gocache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+)
+
+// ManagerEnvPrefix is the prefix of the environment variables overriding the configuration loaded by LoadManager
+// and ParseManager. GOCACHE_DEFAULT overrides the default store while GOCACHE_STORES_<NAME>_<OPTION> overrides an
+// option of a store, e.g. GOCACHE_STORES_REDIS_ADDR, GOCACHE_STORES_REDIS_ENCODER or GOCACHE_STORES_REDIS_DSN
+const ManagerEnvPrefix = "GOCACHE"
+
+type (
+	// Manager manages a set of named stores, building each of them lazily via New the first time it is requested
+	Manager struct {
+		mu           sync.Mutex
+		defaultStore string
+		stores       map[string]*managedStore
+		encoders     map[string]encoder.Encoder
+	}
+	managedStore struct {
+		config      config
+		encoder     encoder.Encoder
+		encoderName string
+		cache       Cache
+	}
+	// managerFile represents the layout of the files loaded by LoadManager
+	managerFile struct {
+		Default string                            `json:"default" yaml:"default"`
+		Stores  map[string]map[string]interface{} `json:"stores" yaml:"stores"`
+	}
+)
+
+// NewManager creates an empty *Manager. Stores can then be added via Register
+func NewManager() *Manager {
+	return &Manager{
+		stores:   map[string]*managedStore{},
+		encoders: namedEncoders(),
+	}
+}
+
+// LoadManager creates a *Manager from the YAML or JSON file at path. See ParseManager for the file layout
+func LoadManager(path string) (*Manager, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseManager(data)
+}
+
+// ParseManager creates a *Manager from YAML or JSON data with the following layout:
+//
+//	default: app
+//	stores:
+//	  app:
+//	    driver: redis
+//	    encoder: msgpack
+//	    prefix: "app:"
+//	    addr: localhost:6379
+//	    pool_size: 20
+//	  local:
+//	    driver: local
+//	    default_interval: 1m
+//
+// Every store requires either a driver (redis, memcache, local, database, file or null) or a dsn as accepted by
+// Open, and optionally an encoder (json, msgpack, gob, cbor or proto, json being the default). The remaining keys are the driver
+// config fields in snake case, which take precedence over the dsn. Environment variables prefixed by
+// ManagerEnvPrefix take precedence over the values in data
+func ParseManager(data []byte) (*Manager, error) {
+	var (
+		file managerFile
+		err  error
+	)
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		err = decoder.Decode(&file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gocache: could not parse manager config: %w", err)
+	}
+
+	m := NewManager()
+	if len(file.Default) > 0 {
+		m.defaultStore = file.Default
+	}
+	if env, exists := os.LookupEnv(ManagerEnvPrefix + "_DEFAULT"); exists {
+		m.defaultStore = env
+	}
+
+	var names = make([]string, 0, len(file.Stores))
+	for name := range file.Stores {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		var (
+			options     = map[string]interface{}{}
+			driver      string
+			dsn         string
+			encoderName string
+			envPrefix   = ManagerEnvPrefix + "_STORES_" + envName(name) + "_"
+		)
+		for key, value := range file.Stores[name] {
+			switch strings.ToLower(key) {
+			case "driver":
+				driver = fmt.Sprint(value)
+			case "dsn":
+				dsn = fmt.Sprint(value)
+			case "encoder":
+				encoderName = fmt.Sprint(value)
+			default:
+				options[strings.ToLower(key)] = value
+			}
+		}
+		if env, exists := os.LookupEnv(envPrefix + "DRIVER"); exists {
+			driver = env
+		}
+		if env, exists := os.LookupEnv(envPrefix + "DSN"); exists {
+			dsn = env
+		}
+		if env, exists := os.LookupEnv(envPrefix + "ENCODER"); exists {
+			encoderName = env
+		}
+
+		var cnf config
+		if len(dsn) > 0 {
+			var dsnEncoderName string
+			if cnf, dsnEncoderName, err = parseDSN(dsn); err != nil {
+				return nil, fmt.Errorf("gocache: store %q: %w", name, err)
+			}
+			if len(encoderName) == 0 {
+				encoderName = dsnEncoderName
+			}
+		} else if cnf, err = configForDriver(driver); err != nil {
+			return nil, fmt.Errorf("gocache: store %q: %w", name, err)
+		}
+		if len(encoderName) == 0 {
+			encoderName = "json"
+		}
+		for _, option := range optionNames(cnf) {
+			if env, exists := os.LookupEnv(envPrefix + strings.ToUpper(option)); exists {
+				options[option] = env
+			}
+		}
+		if err = applyOptions(cnf, options); err != nil {
+			return nil, fmt.Errorf("gocache: store %q: %w", name, err)
+		}
+
+		m.stores[name] = &managedStore{
+			config:      cnf,
+			encoderName: strings.ToLower(encoderName),
+		}
+	}
+	if len(m.defaultStore) > 0 {
+		if _, exists := m.stores[m.defaultStore]; !exists {
+			return nil, fmt.Errorf("gocache: default store %q is not defined", m.defaultStore)
+		}
+	}
+
+	return m, nil
+}
+
+// Register adds a named store to the manager. The first registered store becomes the default store unless
+// SetDefault is called. Registering a name that has already been built closes the previously built store
+func (m *Manager) Register(name string, cnf config, enc encoder.Encoder) error {
+	if cnf == nil {
+		return errors.New("gocache: a store config needs to be specified")
+	}
+	if enc == nil {
+		return errors.New("gocache: a store encoder needs to be specified")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if previous, exists := m.stores[name]; exists && previous.cache != nil {
+		if err := previous.cache.Close(); err != nil {
+			return err
+		}
+	}
+	if len(m.defaultStore) == 0 {
+		m.defaultStore = name
+	}
+
+	m.stores[name] = &managedStore{
+		config:  cnf,
+		encoder: enc,
+	}
+
+	return nil
+}
+
+// RegisterEncoder makes an encoder available by name to the stores loaded via LoadManager or ParseManager
+func (m *Manager) RegisterEncoder(name string, enc encoder.Encoder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.encoders[strings.ToLower(name)] = enc
+}
+
+// SetDefault sets the store returned by Default
+func (m *Manager) SetDefault(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.stores[name]; !exists {
+		return fmt.Errorf("gocache: store %q is not defined", name)
+	}
+
+	m.defaultStore = name
+
+	return nil
+}
+
+// Names returns the sorted names of the stores managed by the manager
+func (m *Manager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.sortedNames()
+}
+
+// Default returns the default store building it if needed
+func (m *Manager) Default() (Cache, error) {
+	m.mu.Lock()
+	name := m.defaultStore
+	m.mu.Unlock()
+
+	if len(name) == 0 {
+		return nil, errors.New("gocache: no default store has been defined")
+	}
+
+	return m.Store(name)
+}
+
+// Store returns the named store building it via New the first time it is requested
+func (m *Manager) Store(name string) (Cache, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	store, exists := m.stores[name]
+	if !exists {
+		return nil, fmt.Errorf("gocache: store %q is not defined", name)
+	}
+	if store.cache != nil {
+		return store.cache, nil
+	}
+
+	enc := store.encoder
+	if enc == nil {
+		if enc, exists = m.encoders[store.encoderName]; !exists {
+			return nil, fmt.Errorf("gocache: store %q: unknown encoder %q", name, store.encoderName)
+		}
+	}
+
+	cache, err := New(store.config, enc)
+	if err != nil {
+		return nil, fmt.Errorf("gocache: store %q: %w", name, err)
+	}
+
+	store.cache = cache
+
+	return cache, nil
+}
+
+// Close closes every store that has been built. Stores are rebuilt if requested again
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	for _, name := range m.sortedNames() {
+		store := m.stores[name]
+		if store.cache == nil {
+			continue
+		}
+		if err := store.cache.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("gocache: store %q: %w", name, err))
+		}
+
+		store.cache = nil
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *Manager) sortedNames() []string {
+	var names = make([]string, 0, len(m.stores))
+	for name := range m.stores {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// envName converts a store name to its environment variable form, e.g. redis-sessions to REDIS_SESSIONS
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+
+		return '_'
+	}, strings.ToUpper(name))
+}
@@ -0,0 +1,168 @@
+package gocache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+	"github.com/alejandro-carstens/gocache/gocachetest/testserver"
+)
+
+func TestParseManager(t *testing.T) {
+	var (
+		redisServer = testserver.RunRedis(t)
+		directory   = t.TempDir()
+	)
+	t.Setenv("GOCACHE_STORES_REDIS_ADDR", redisServer.Addr())
+	t.Setenv("GOCACHE_STORES_FILES_DIRECTORY", directory)
+
+	m, err := ParseManager([]byte(`
+default: redis
+stores:
+  redis:
+    driver: redis
+    encoder: msgpack
+    prefix: "app:"
+    addr: 127.0.0.1:1
+    pool_size: 20
+    read_timeout: 500ms
+  local:
+    driver: local
+    default_interval: 1m
+  files:
+    driver: file
+    file_mode: "0600"
+  memcache:
+    driver: memcache
+    servers: 127.0.0.1:11211, 127.0.0.1:11212
+    weighted_servers:
+      - addr: 127.0.0.1:11213
+        weight: 3
+    distribution: ketama
+`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"files", "local", "memcache", "redis"}, m.Names())
+
+	redisConfig := m.stores["redis"].config.(*RedisConfig)
+	require.Equal(t, "app:", redisConfig.Prefix)
+	require.Equal(t, redisServer.Addr(), redisConfig.Addr)
+	require.Equal(t, 20, redisConfig.PoolSize)
+	require.Equal(t, 500*time.Millisecond, redisConfig.ReadTimeout)
+	require.Equal(t, "msgpack", m.stores["redis"].encoderName)
+
+	require.Equal(t, time.Minute, m.stores["local"].config.(*LocalConfig).DefaultInterval)
+	require.Equal(t, os.FileMode(0600), m.stores["files"].config.(*FileConfig).FileMode)
+	require.Equal(t, &MemcacheConfig{
+		Servers:         []string{"127.0.0.1:11211", "127.0.0.1:11212"},
+		WeightedServers: []MemcacheServer{{Addr: "127.0.0.1:11213", Weight: 3}},
+		Distribution:    MemcacheDistributionKetama,
+	}, m.stores["memcache"].config)
+
+	cache, err := m.Default()
+	require.NoError(t, err)
+	require.IsType(t, &RedisStore{}, cache)
+	require.NoError(t, cache.Put("key", "value", time.Minute))
+	require.Equal(t, []string{"app:key"}, redisServer.Keys())
+
+	same, err := m.Store("redis")
+	require.NoError(t, err)
+	require.Same(t, cache, same)
+
+	files, err := m.Store("files")
+	require.NoError(t, err)
+	require.NoError(t, files.Put("key", "value", time.Minute))
+	require.Equal(t, directory, files.(*FileStore).directory)
+
+	_, err = m.Store("missing")
+	require.Error(t, err)
+
+	require.NoError(t, m.Close())
+
+	rebuilt, err := m.Store("redis")
+	require.NoError(t, err)
+	require.NotSame(t, cache, rebuilt)
+	require.NoError(t, m.Close())
+}
+
+func TestParseManager_JSON(t *testing.T) {
+	t.Setenv("GOCACHE_DEFAULT", "other")
+
+	m, err := ParseManager([]byte(`{
+	"default": "local",
+	"stores": {
+		"local": {"driver": "local", "prefix": "app:"},
+		"other": {"driver": "null", "encoder": "custom"}
+	}
+}`))
+	require.NoError(t, err)
+
+	_, err = m.Default()
+	require.EqualError(t, err, `gocache: store "other": unknown encoder "custom"`)
+
+	m.RegisterEncoder("custom", encoder.JSON{})
+
+	cache, err := m.Default()
+	require.NoError(t, err)
+	require.IsType(t, &NullStore{}, cache)
+}
+
+func TestParseManager_DSN(t *testing.T) {
+	t.Setenv("GOCACHE_STORES_APP_DSN", "local://?max_entries=10&encoder=msgpack")
+
+	m, err := ParseManager([]byte("stores: {app: {dsn: 'null://', prefix: 'app:'}}"))
+	require.NoError(t, err)
+	require.Equal(t, &LocalConfig{Prefix: "app:", MaxEntries: 10}, m.stores["app"].config)
+	require.Equal(t, "msgpack", m.stores["app"].encoderName)
+}
+
+func TestParseManager_Errors(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown driver":  "stores: {app: {driver: mongo}}",
+		"unknown option":  "stores: {app: {driver: local, pool_size: 1}}",
+		"invalid option":  "stores: {app: {driver: redis, pool_size: many}}",
+		"unknown default": "default: other\nstores: {app: {driver: local}}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseManager([]byte(data))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestManager_Register(t *testing.T) {
+	m := NewManager()
+
+	_, err := m.Default()
+	require.Error(t, err)
+
+	require.NoError(t, m.Register("local", &LocalConfig{}, encoder.JSON{}))
+	require.NoError(t, m.Register("files", &FileConfig{Directory: t.TempDir()}, encoder.Msgpack{}))
+
+	cache, err := m.Default()
+	require.NoError(t, err)
+	require.IsType(t, &LocalStore{}, cache)
+
+	require.NoError(t, m.SetDefault("files"))
+
+	cache, err = m.Default()
+	require.NoError(t, err)
+	require.IsType(t, &FileStore{}, cache)
+	require.Error(t, m.SetDefault("missing"))
+
+	require.NoError(t, m.Register("invalid", &RedisConfig{}, encoder.JSON{}))
+
+	_, err = m.Store("invalid")
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "cache.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("stores: {app: {driver: local}}"), 0644))
+
+	loaded, err := LoadManager(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"app"}, loaded.Names())
+	require.NoError(t, m.Close())
+}
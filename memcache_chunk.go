@@ -0,0 +1,386 @@
+package gocache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/rs/xid"
+)
+
+// defaultMemcacheChunkSize stays below the default 1MB memcached item size limit leaving room for the key and
+// the item header
+const defaultMemcacheChunkSize = 1024*1024 - 1024
+
+// chunkManifestVersion is the version of the manifest layout written by MemcacheStore
+const chunkManifestVersion byte = 2
+
+// chunkManifestMagic prefixes every chunk manifest. It is followed by the manifest version, the number of chunks,
+// the length of the value and the length of the generation as uvarints, the generation and the SHA-256 checksum of
+// the value
+var chunkManifestMagic = []byte{0x00, 'g', 'c', 'm'}
+
+var _ Streamer = &MemcacheStore{}
+
+// chunkManifest describes a value that was split across several chunk items. Every write keys its chunks by a new
+// generation, so that a write never overwrites the chunks of the value it replaces
+type chunkManifest struct {
+	generation string
+	chunks     int
+	length     int
+	checksum   [sha256.Size]byte
+}
+
+func (m chunkManifest) encode() []byte {
+	var data = make([]byte, 0, len(chunkManifestMagic)+1+3*binary.MaxVarintLen64+len(m.generation)+sha256.Size)
+	data = append(data, chunkManifestMagic...)
+	data = append(data, chunkManifestVersion)
+	data = binary.AppendUvarint(data, uint64(m.chunks))
+	data = binary.AppendUvarint(data, uint64(m.length))
+	data = binary.AppendUvarint(data, uint64(len(m.generation)))
+	data = append(data, m.generation...)
+
+	return append(data, m.checksum[:]...)
+}
+
+// keys returns the keys of the chunks of the given prefixed key
+func (m chunkManifest) keys(key string) []string {
+	var keys = make([]string, m.chunks)
+	for i := range keys {
+		keys[i] = chunkKey(key, m.generation, i)
+	}
+
+	return keys
+}
+
+// parseChunkManifest returns the manifest held by data. The last return value reports whether data is a manifest
+func parseChunkManifest(data []byte) (chunkManifest, bool) {
+	if !bytes.HasPrefix(data, chunkManifestMagic) || len(data) <= len(chunkManifestMagic) {
+		return chunkManifest{}, false
+	}
+
+	data = data[len(chunkManifestMagic):]
+	if data[0] != chunkManifestVersion {
+		return chunkManifest{}, false
+	}
+
+	data = data[1:]
+	chunks, n := binary.Uvarint(data)
+	if n <= 0 {
+		return chunkManifest{}, false
+	}
+
+	data = data[n:]
+	length, n := binary.Uvarint(data)
+	if n <= 0 {
+		return chunkManifest{}, false
+	}
+
+	data = data[n:]
+	size, n := binary.Uvarint(data)
+	if n <= 0 || size > uint64(len(data[n:])) {
+		return chunkManifest{}, false
+	}
+
+	var m = chunkManifest{
+		generation: string(data[n : n+int(size)]),
+		chunks:     int(chunks),
+		length:     int(length),
+	}
+
+	data = data[n+int(size):]
+	if len(data) != sha256.Size {
+		return chunkManifest{}, false
+	}
+
+	copy(m.checksum[:], data)
+
+	return m, true
+}
+
+// chunkKey returns the key of the nth chunk of the given prefixed key for the given generation
+func chunkKey(key, generation string, n int) string {
+	return key + ":chunk:" + generation + ":" + strconv.Itoa(n)
+}
+
+// chunks splits the value of item across chunk items whenever it exceeds the configured chunk size, in which case
+// the value of item is replaced by the manifest of the chunks. The chunks need to be written before item so that
+// readers never see a manifest pointing to chunks that do not exist yet
+func (s *MemcacheStore) chunks(item *memcache.Item) []*memcache.Item {
+	if s.chunkSize <= 0 || len(item.Value) <= s.chunkSize {
+		return nil
+	}
+
+	var (
+		value      = item.Value
+		generation = xid.New().String()
+		chunks     = make([]*memcache.Item, 0, (len(value)+s.chunkSize-1)/s.chunkSize)
+	)
+	for offset := 0; offset < len(value); offset += s.chunkSize {
+		end := offset + s.chunkSize
+		if end > len(value) {
+			end = len(value)
+		}
+
+		chunks = append(chunks, &memcache.Item{
+			Key:        chunkKey(item.Key, generation, len(chunks)),
+			Value:      value[offset:end],
+			Expiration: item.Expiration,
+		})
+	}
+
+	item.Value = chunkManifest{
+		generation: generation,
+		chunks:     len(chunks),
+		length:     len(value),
+		checksum:   sha256.Sum256(value),
+	}.encode()
+
+	return chunks
+}
+
+// setChunks writes the given chunk items concurrently
+func (s *MemcacheStore) setChunks(chunks []*memcache.Item) error {
+	var keys = make([]string, len(chunks))
+	for i, chunk := range chunks {
+		keys[i] = chunk.Key
+	}
+
+	return s.batch(keys, func(i int) error {
+		return s.client.Set(chunks[i])
+	})
+}
+
+// deleteChunks deletes the chunks of the given manifests keyed by the prefixed key they belong to
+func (s *MemcacheStore) deleteChunks(manifests map[string]chunkManifest) error {
+	var keys []string
+	for key, m := range manifests {
+		keys = append(keys, m.keys(key)...)
+	}
+
+	return s.deleteItems(keys)
+}
+
+// deleteItems deletes the items stored under the given prefixed keys, ignoring the ones that do not exist
+func (s *MemcacheStore) deleteItems(keys []string) error {
+	return s.batch(keys, func(i int) error {
+		if err := s.client.Delete(keys[i]); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// manifests returns the manifests held by the given prefixed keys, keys holding regular values being left out
+func (s *MemcacheStore) manifests(keys ...string) (map[string]chunkManifest, error) {
+	var manifests = map[string]chunkManifest{}
+	if len(keys) == 0 {
+		return manifests, nil
+	}
+
+	items, err := s.client.GetMulti(keys)
+	if err != nil {
+		return nil, err
+	}
+	for key, item := range items {
+		if m, chunked := parseChunkManifest(item.Value); chunked {
+			manifests[key] = m
+		}
+	}
+
+	return manifests, nil
+}
+
+// assemble returns the value of the given item reassembling it from its chunks if item holds a manifest. Missing
+// chunks as well as chunks that do not match the manifest checksum, e.g. because they were overwritten by a
+// concurrent write, are reported as ErrNotFound
+func (s *MemcacheStore) assemble(item *memcache.Item) ([]byte, error) {
+	m, chunked := parseChunkManifest(item.Value)
+	if !chunked {
+		return item.Value, nil
+	}
+
+	keys := m.keys(item.Key)
+
+	results, err := s.client.GetMulti(keys)
+	if err != nil {
+		return nil, checkErrNotFound(err)
+	}
+
+	var value = make([]byte, 0, m.length)
+	for _, key := range keys {
+		chunk, exists := results[key]
+		if !exists {
+			return nil, ErrNotFound
+		}
+
+		value = append(value, chunk.Value...)
+	}
+	if len(value) != m.length || sha256.Sum256(value) != m.checksum {
+		return nil, ErrNotFound
+	}
+
+	return value, nil
+}
+
+// PutStream implementation of the Streamer interface. The stream is written chunk by chunk following the layout
+// of chunked values under a new generation, the manifest being written last, therefore streamed values can be read
+// via Get and vice versa and a failed stream leaves the existing value untouched. Streams that fit in a single chunk
+// are stored as a regular item
+func (s *MemcacheStore) PutStream(key string, r io.Reader, duration time.Duration) error {
+	w := &memcacheStreamWriter{
+		store:      s,
+		key:        s.k(key),
+		generation: xid.New().String(),
+		expiration: int32(duration.Seconds()),
+		hash:       sha256.New(),
+	}
+	w.chunkWriter = chunkWriter{
+		size:  s.chunkSize,
+		flush: w.setChunk,
+	}
+	if w.size <= 0 {
+		w.size = math.MaxInt
+	}
+
+	return writeStream(s.encoder, w, r)
+}
+
+// GetStream implementation of the Streamer interface. Chunks are fetched one at a time, therefore a missing or
+// stale chunk may only be detected, and reported as ErrNotFound, after part of the value has been written to w
+func (s *MemcacheStore) GetStream(key string, w io.Writer) error {
+	item, err := s.client.Get(s.k(key))
+	if err != nil {
+		return checkErrNotFound(err)
+	}
+
+	m, chunked := parseChunkManifest(item.Value)
+	if !chunked {
+		return readStream(bytes.NewReader(item.Value), w)
+	}
+
+	return readStream(&memcacheStreamReader{
+		store:    s,
+		key:      item.Key,
+		manifest: m,
+		hash:     sha256.New(),
+	}, w)
+}
+
+// memcacheStreamWriter writes a stream as chunk items followed by their manifest
+type memcacheStreamWriter struct {
+	chunkWriter
+	store      *MemcacheStore
+	key        string
+	generation string
+	expiration int32
+	chunks     int
+	length     int
+	hash       hash.Hash
+}
+
+func (w *memcacheStreamWriter) setChunk(chunk []byte) error {
+	if err := w.store.client.Set(&memcache.Item{
+		Key:        chunkKey(w.key, w.generation, w.chunks),
+		Value:      chunk,
+		Expiration: w.expiration,
+	}); err != nil {
+		return err
+	}
+
+	_, _ = w.hash.Write(chunk)
+	w.chunks++
+	w.length += len(chunk)
+
+	return nil
+}
+
+func (w *memcacheStreamWriter) commit() error {
+	var item = &memcache.Item{
+		Key:        w.key,
+		Value:      w.buf,
+		Expiration: w.expiration,
+	}
+	if w.chunks == 0 {
+		return w.store.client.Set(item)
+	}
+	if err := w.setChunk(w.buf); err != nil {
+		w.abort()
+
+		return err
+	}
+
+	m := chunkManifest{
+		generation: w.generation,
+		chunks:     w.chunks,
+		length:     w.length,
+	}
+	copy(m.checksum[:], w.hash.Sum(nil))
+	item.Value = m.encode()
+
+	previous, err := w.store.manifests(w.key)
+	if err != nil {
+		w.abort()
+
+		return err
+	}
+	if err = w.store.client.Set(item); err != nil {
+		w.abort()
+
+		return err
+	}
+
+	return w.store.deleteChunks(previous)
+}
+
+// abort deletes the chunks written so far, which belong to a generation no manifest points to
+func (w *memcacheStreamWriter) abort() {
+	_ = w.store.deleteChunks(map[string]chunkManifest{w.key: {generation: w.generation, chunks: w.chunks}})
+}
+
+// memcacheStreamReader reads a chunked value one chunk at a time verifying its checksum once fully read
+type memcacheStreamReader struct {
+	store    *MemcacheStore
+	key      string
+	manifest chunkManifest
+	next     int
+	length   int
+	buf      []byte
+	hash     hash.Hash
+}
+
+// Read implementation of the io.Reader interface
+func (r *memcacheStreamReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.next == r.manifest.chunks {
+			if r.length != r.manifest.length || !bytes.Equal(r.hash.Sum(nil), r.manifest.checksum[:]) {
+				return 0, ErrNotFound
+			}
+
+			return 0, io.EOF
+		}
+
+		item, err := r.store.client.Get(chunkKey(r.key, r.manifest.generation, r.next))
+		if err != nil {
+			return 0, checkErrNotFound(err)
+		}
+
+		_, _ = r.hash.Write(item.Value)
+		r.next++
+		r.length += len(item.Value)
+		r.buf = item.Value
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
@@ -9,7 +9,7 @@ import (
 
 var _ Lock = &memcacheLock{}
 
-func newMemcacheLock(client *memcache.Client, name, owner string, duration time.Duration) *memcacheLock {
+func newMemcacheLock(client *memcacheClient, name, owner string, duration time.Duration) *memcacheLock {
 	return (&memcacheLock{
 		client:   client,
 		name:     name,
@@ -20,7 +20,7 @@ func newMemcacheLock(client *memcache.Client, name, owner string, duration time.
 
 type memcacheLock struct {
 	baseLock
-	client   *memcache.Client
+	client   *memcacheClient
 	name     string
 	owner    string
 	duration time.Duration
@@ -0,0 +1,342 @@
+package gocache
+
+import (
+	"errors"
+	"hash/crc32"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const defaultMemcacheRetryTimeout = 2 * time.Second
+
+var _ memcache.ServerSelector = &memcacheSelector{}
+
+type (
+	// memcacheSelector is a memcache.ServerSelector implementation that supports weighted servers, modula and ketama
+	// distributions and the ejection of servers that fail consecutively
+	memcacheSelector struct {
+		mu           sync.RWMutex
+		distribution MemcacheDistribution
+		failureLimit int
+		retryTimeout time.Duration
+		servers      []*memcacheNode
+		nodes        map[string]*memcacheNode
+		live         []*memcacheNode
+		ring         *hashRing
+		nextRetry    time.Time
+	}
+	memcacheNode struct {
+		name         string
+		addr         net.Addr
+		weight       int
+		failures     int
+		ejectedUntil time.Time
+	}
+	// memcacheClient wraps a *memcache.Client in order to report connection failures to its selector
+	memcacheClient struct {
+		*memcache.Client
+		selector *memcacheSelector
+	}
+)
+
+func newMemcacheSelector(cnf *MemcacheConfig) (*memcacheSelector, error) {
+	ss := &memcacheSelector{
+		distribution: cnf.Distribution,
+		failureLimit: cnf.FailureLimit,
+		retryTimeout: cnf.RetryTimeout,
+		nodes:        map[string]*memcacheNode{},
+	}
+	if ss.retryTimeout <= 0 {
+		ss.retryTimeout = defaultMemcacheRetryTimeout
+	}
+
+	servers := make([]MemcacheServer, 0, len(cnf.Servers)+len(cnf.WeightedServers))
+	for _, server := range cnf.Servers {
+		servers = append(servers, MemcacheServer{
+			Addr:   server,
+			Weight: 1,
+		})
+	}
+
+	servers = append(servers, cnf.WeightedServers...)
+	for _, server := range servers {
+		addr, err := resolveMemcacheAddr(server.Addr)
+		if err != nil {
+			return nil, err
+		}
+		if node, exists := ss.nodes[server.Addr]; exists {
+			node.weight += memcacheWeight(server.Weight)
+
+			continue
+		}
+
+		node := &memcacheNode{
+			name:   server.Addr,
+			addr:   addr,
+			weight: memcacheWeight(server.Weight),
+		}
+
+		ss.nodes[node.name] = node
+		ss.servers = append(ss.servers, node)
+	}
+
+	ss.rebuild()
+
+	return ss, nil
+}
+
+// PickServer implementation of the memcache.ServerSelector interface
+func (ss *memcacheSelector) PickServer(key string) (net.Addr, error) {
+	ss.restore()
+
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	if len(ss.live) == 0 {
+		return nil, memcache.ErrNoServers
+	}
+	if len(ss.live) == 1 {
+		return ss.live[0].addr, nil
+	}
+	if ss.distribution == MemcacheDistributionKetama {
+		name, _ := ss.ring.get(key)
+
+		return ss.nodes[name].addr, nil
+	}
+
+	var total uint32
+	for _, node := range ss.live {
+		total += uint32(node.weight)
+	}
+
+	cs := crc32.ChecksumIEEE([]byte(key)) % total
+	for _, node := range ss.live {
+		if cs < uint32(node.weight) {
+			return node.addr, nil
+		}
+
+		cs -= uint32(node.weight)
+	}
+
+	return ss.live[len(ss.live)-1].addr, nil
+}
+
+// Each implementation of the memcache.ServerSelector interface
+func (ss *memcacheSelector) Each(fn func(net.Addr) error) error {
+	ss.restore()
+
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	for _, node := range ss.live {
+		if err := fn(node.addr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// report keeps track of consecutive connection failures for the server that owns the given key, any other outcome
+// resetting its count. Once the failure limit is reached the server is ejected until the retry timeout elapses
+func (ss *memcacheSelector) report(key string, err error) {
+	if ss.failureLimit <= 0 {
+		return
+	}
+
+	addr, pickErr := ss.PickServer(key)
+	if pickErr != nil {
+		return
+	}
+
+	failed := err != nil && isMemcacheConnErr(err)
+	if !failed && !ss.failing(addr) {
+		return
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	for _, node := range ss.live {
+		if node.addr.String() != addr.String() {
+			continue
+		}
+		if !failed {
+			node.failures = 0
+
+			return
+		}
+
+		node.failures++
+		if node.failures < ss.failureLimit {
+			return
+		}
+
+		node.failures = 0
+		node.ejectedUntil = time.Now().Add(ss.retryTimeout)
+		if ss.nextRetry.IsZero() || node.ejectedUntil.Before(ss.nextRetry) {
+			ss.nextRetry = node.ejectedUntil
+		}
+
+		ss.rebuild()
+
+		return
+	}
+}
+
+// failing returns whether the given live server has failed since its last successful call, which avoids taking
+// the write lock on every successful call
+func (ss *memcacheSelector) failing(addr net.Addr) bool {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	for _, node := range ss.live {
+		if node.addr.String() == addr.String() {
+			return node.failures > 0
+		}
+	}
+
+	return false
+}
+
+// restore puts back into rotation all ejected servers whose retry timeout has elapsed
+func (ss *memcacheSelector) restore() {
+	ss.mu.RLock()
+	pending := !ss.nextRetry.IsZero() && !time.Now().Before(ss.nextRetry)
+	ss.mu.RUnlock()
+
+	if !pending {
+		return
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var now = time.Now()
+
+	ss.nextRetry = time.Time{}
+	for _, node := range ss.servers {
+		if node.ejectedUntil.IsZero() {
+			continue
+		}
+		if !now.Before(node.ejectedUntil) {
+			node.ejectedUntil = time.Time{}
+
+			continue
+		}
+		if ss.nextRetry.IsZero() || node.ejectedUntil.Before(ss.nextRetry) {
+			ss.nextRetry = node.ejectedUntil
+		}
+	}
+
+	ss.rebuild()
+}
+
+func (ss *memcacheSelector) rebuild() {
+	var (
+		live    []*memcacheNode
+		weights = map[string]int{}
+	)
+	for _, node := range ss.servers {
+		if !node.ejectedUntil.IsZero() {
+			continue
+		}
+
+		live = append(live, node)
+		weights[node.name] = node.weight
+	}
+
+	ss.live = live
+	if ss.distribution == MemcacheDistributionKetama {
+		ss.ring = newHashRing(weights, memcachePointKey)
+	}
+}
+
+func memcacheWeight(weight int) int {
+	if weight <= 0 {
+		return 1
+	}
+
+	return weight
+}
+
+func resolveMemcacheAddr(server string) (net.Addr, error) {
+	if strings.Contains(server, "/") {
+		return net.ResolveUnixAddr("unix", server)
+	}
+
+	return net.ResolveTCPAddr("tcp", server)
+}
+
+func isMemcacheConnErr(err error) bool {
+	var (
+		netErr     net.Error
+		timeoutErr *memcache.ConnectTimeoutError
+	)
+	if errors.As(err, &netErr) || errors.As(err, &timeoutErr) {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Get wraps memcache.Client.Get
+func (c *memcacheClient) Get(key string) (*memcache.Item, error) {
+	item, err := c.Client.Get(key)
+	c.selector.report(key, err)
+
+	return item, err
+}
+
+// Set wraps memcache.Client.Set
+func (c *memcacheClient) Set(item *memcache.Item) error {
+	err := c.Client.Set(item)
+	c.selector.report(item.Key, err)
+
+	return err
+}
+
+// Add wraps memcache.Client.Add
+func (c *memcacheClient) Add(item *memcache.Item) error {
+	err := c.Client.Add(item)
+	c.selector.report(item.Key, err)
+
+	return err
+}
+
+// Delete wraps memcache.Client.Delete
+func (c *memcacheClient) Delete(key string) error {
+	err := c.Client.Delete(key)
+	c.selector.report(key, err)
+
+	return err
+}
+
+// Touch wraps memcache.Client.Touch
+func (c *memcacheClient) Touch(key string, seconds int32) error {
+	err := c.Client.Touch(key, seconds)
+	c.selector.report(key, err)
+
+	return err
+}
+
+// Increment wraps memcache.Client.Increment
+func (c *memcacheClient) Increment(key string, delta uint64) (uint64, error) {
+	res, err := c.Client.Increment(key, delta)
+	c.selector.report(key, err)
+
+	return res, err
+}
+
+// Decrement wraps memcache.Client.Decrement
+func (c *memcacheClient) Decrement(key string, delta uint64) (uint64, error) {
+	res, err := c.Client.Decrement(key, delta)
+	c.selector.report(key, err)
+
+	return res, err
+}
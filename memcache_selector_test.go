@@ -0,0 +1,130 @@
+package gocache
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemcacheSelector_Distribution(t *testing.T) {
+	for _, distribution := range []MemcacheDistribution{MemcacheDistributionModula, MemcacheDistributionKetama} {
+		t.Run(strconv.Itoa(int(distribution)), func(t *testing.T) {
+			ss, err := newMemcacheSelector(&MemcacheConfig{
+				Servers: []string{"127.0.0.1:11211"},
+				WeightedServers: []MemcacheServer{
+					{Addr: "127.0.0.1:11212", Weight: 3},
+				},
+				Distribution: distribution,
+			})
+			require.NoError(t, err)
+
+			counts := map[string]int{}
+			for i := 0; i < 10000; i++ {
+				addr, err := ss.PickServer("key:" + strconv.Itoa(i))
+				require.NoError(t, err)
+
+				counts[addr.String()]++
+			}
+
+			require.Len(t, counts, 2)
+			require.Greater(t, counts["127.0.0.1:11212"], 2*counts["127.0.0.1:11211"])
+		})
+	}
+}
+
+func TestMemcacheSelector_KetamaVectors(t *testing.T) {
+	ss, err := newMemcacheSelector(&MemcacheConfig{
+		Servers: []string{"10.0.1.1:11211", "10.0.1.2:11211"},
+		WeightedServers: []MemcacheServer{
+			{Addr: "10.0.1.3:11212", Weight: 2},
+		},
+		Distribution: MemcacheDistributionKetama,
+	})
+	require.NoError(t, err)
+
+	// Assignments of libmemcached's weighted ketama continuum, which hashes "host-i" for servers listening on
+	// the default port and "host:port-i" otherwise
+	for key, expected := range map[string]string{
+		"foo":         "10.0.1.1:11211",
+		"bar":         "10.0.1.3:11212",
+		"baz":         "10.0.1.3:11212",
+		"user:1":      "10.0.1.3:11212",
+		"user:2":      "10.0.1.2:11211",
+		"session:abc": "10.0.1.2:11211",
+		"gocache":     "10.0.1.2:11211",
+		"ketama":      "10.0.1.2:11211",
+		"0":           "10.0.1.3:11212",
+		"1":           "10.0.1.1:11211",
+		"2":           "10.0.1.3:11212",
+		"3":           "10.0.1.3:11212",
+	} {
+		addr, err := ss.PickServer(key)
+		require.NoError(t, err)
+		require.Equal(t, expected, addr.String(), key)
+	}
+}
+
+func TestMemcacheSelector_AutoEject(t *testing.T) {
+	ss, err := newMemcacheSelector(&MemcacheConfig{
+		Servers:      []string{"127.0.0.1:11211", "127.0.0.1:11212"},
+		Distribution: MemcacheDistributionKetama,
+		FailureLimit: 2,
+		RetryTimeout: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	original, err := ss.PickServer("key")
+	require.NoError(t, err)
+
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: net.UnknownNetworkError("refused")}
+	ss.report("key", connErr)
+
+	addr, err := ss.PickServer("key")
+	require.NoError(t, err)
+	require.Equal(t, original.String(), addr.String())
+
+	ss.report("key", connErr)
+
+	addr, err = ss.PickServer("key")
+	require.NoError(t, err)
+	require.NotEqual(t, original.String(), addr.String())
+
+	time.Sleep(60 * time.Millisecond)
+
+	addr, err = ss.PickServer("key")
+	require.NoError(t, err)
+	require.Equal(t, original.String(), addr.String())
+}
+
+func TestMemcacheSelector_AutoEjectConsecutiveFailures(t *testing.T) {
+	ss, err := newMemcacheSelector(&MemcacheConfig{
+		Servers:      []string{"127.0.0.1:11211", "127.0.0.1:11212"},
+		Distribution: MemcacheDistributionKetama,
+		FailureLimit: 2,
+		RetryTimeout: time.Minute,
+	})
+	require.NoError(t, err)
+
+	original, err := ss.PickServer("key")
+	require.NoError(t, err)
+
+	// A successful call, cache misses included, in between failures resets the count
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: net.UnknownNetworkError("refused")}
+	for _, err := range []error{connErr, nil, connErr, memcache.ErrCacheMiss, connErr} {
+		ss.report("key", err)
+
+		addr, err := ss.PickServer("key")
+		require.NoError(t, err)
+		require.Equal(t, original.String(), addr.String())
+	}
+
+	ss.report("key", connErr)
+
+	addr, err := ss.PickServer("key")
+	require.NoError(t, err)
+	require.NotEqual(t, original.String(), addr.String())
+}
@@ -3,6 +3,7 @@ package gocache
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
@@ -10,6 +11,8 @@ import (
 	"github.com/alejandro-carstens/gocache/encoder"
 )
 
+const defaultMemcacheBatchConcurrency = 4
+
 var _ Cache = &MemcacheStore{}
 
 // NewMemcacheStore validates the passed in config and creates a Cache implementation of type *MemcacheStore
@@ -18,27 +21,60 @@ func NewMemcacheStore(cnf *MemcacheConfig, encoder encoder.Encoder) (*MemcacheSt
 		return nil, err
 	}
 
-	client := memcache.New(cnf.Servers...)
+	selector, err := newMemcacheSelector(cnf)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &memcacheClient{
+		Client:   memcache.NewFromSelector(selector),
+		selector: selector,
+	}
 	if cnf.MaxIdleConns > 0 {
 		client.MaxIdleConns = cnf.MaxIdleConns
 	}
 
 	client.Timeout = cnf.Timeout
 
+	batchConcurrency := cnf.BatchConcurrency
+	if batchConcurrency <= 0 {
+		batchConcurrency = defaultMemcacheBatchConcurrency
+	}
+
+	chunkSize := cnf.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = defaultMemcacheChunkSize
+	}
+
+	tagIndexBuckets := cnf.TagIndexBuckets
+	if tagIndexBuckets <= 0 {
+		tagIndexBuckets = defaultMemcacheTagIndexBuckets
+	}
+
 	return &MemcacheStore{
 		prefix: prefix{
 			val: cnf.Prefix,
 		},
-		client:  client,
-		encoder: encoder,
+		client:           client,
+		encoder:          encoder,
+		batchConcurrency: batchConcurrency,
+		chunkSize:        chunkSize,
+		tagIndexLimit:    cnf.TagIndexLimit,
+		tagIndexBuckets:  tagIndexBuckets,
 	}, nil
 }
 
-// MemcacheStore is the representation of the memcache caching store
+// MemcacheStore is the representation of the memcache caching store. Values larger than MemcacheConfig.ChunkSize
+// are transparently split across key:chunk:{generation}:N items and a manifest stored under the key itself. Tagged keys are
+// recorded in per tag indexes so that tagged Flush calls evict them
 type MemcacheStore struct {
 	prefix
-	client  *memcache.Client
-	encoder encoder.Encoder
+	client           *memcacheClient
+	encoder          encoder.Encoder
+	batchConcurrency int
+	chunkSize        int
+	tagIndexLimit    int
+	tagIndexBuckets  int
 }
 
 // Put puts a value in the given store for a predetermined amount of time in seconds
@@ -48,7 +84,7 @@ func (s *MemcacheStore) Put(key string, value interface{}, duration time.Duratio
 		return err
 	}
 
-	return s.client.Set(item)
+	return s.set(item)
 }
 
 // Add an item to the cache only if an item doesn't already exist for the given key, or if the existing item has
@@ -58,7 +94,22 @@ func (s *MemcacheStore) Add(key string, value interface{}, duration time.Duratio
 	if err != nil {
 		return false, err
 	}
+	if chunks := s.chunks(item); len(chunks) > 0 {
+		// Writing the chunks of an existing value would corrupt it, hence the check
+		exists, err := s.Exists(key)
+		if err != nil || exists {
+			return false, err
+		}
+		if err = s.setChunks(chunks); err != nil {
+			return false, err
+		}
+	}
 	if err = s.client.Add(item); errors.Is(err, memcache.ErrNotStored) {
+		// The chunks written under the new generation are not referenced by any manifest
+		if m, chunked := parseChunkManifest(item.Value); chunked {
+			return false, s.deleteChunks(map[string]chunkManifest{item.Key: m})
+		}
+
 		return false, nil
 	} else if err != nil {
 		return false, err
@@ -78,8 +129,8 @@ func (s *MemcacheStore) GetFloat64(key string) (float64, error) {
 	if err != nil {
 		return 0, err
 	}
-	if !isStringNumeric(value) {
-		return 0, errors.New("invalid numeric value")
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
 	}
 
 	return stringToFloat64(value)
@@ -91,8 +142,8 @@ func (s *MemcacheStore) GetFloat32(key string) (float32, error) {
 	if err != nil {
 		return 0, err
 	}
-	if !isStringNumeric(value) {
-		return 0, errors.New("invalid numeric value")
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
 	}
 
 	return stringToFloat32(value)
@@ -104,8 +155,8 @@ func (s *MemcacheStore) GetInt64(key string) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	if !isStringNumeric(value) {
-		return 0, errors.New("invalid numeric value")
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
 	}
 
 	return stringToInt64(value)
@@ -117,8 +168,8 @@ func (s *MemcacheStore) GetInt(key string) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	if !isStringNumeric(value) {
-		return 0, errors.New("invalid numeric value")
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
 	}
 
 	return stringToInt(value)
@@ -130,8 +181,8 @@ func (s *MemcacheStore) GetUint64(key string) (uint64, error) {
 	if err != nil {
 		return 0, err
 	}
-	if !isStringNumeric(value) {
-		return 0, errors.New("invalid numeric val")
+	if value, err = numericString(s.encoder, value); err != nil {
+		return 0, err
 	}
 
 	return stringToUint64(value)
@@ -143,36 +194,60 @@ func (s *MemcacheStore) GetBool(key string) (bool, error) {
 	if err != nil {
 		return false, checkErrNotFound(err)
 	}
-	if isStringNumeric(value) || isStringBool(value) {
-		return stringToBool(value), nil
-	}
-	if err = s.encoder.Decode([]byte(value), &value); err != nil {
-		return false, err
-	}
 
-	return stringToBool(value), nil
+	return boolValue(s.encoder, value)
 }
 
 // GetString gets a string value from the store
 func (s *MemcacheStore) GetString(key string) (string, error) {
-	item, err := s.client.Get(s.k(key))
+	value, err := s.value(key)
 	if err != nil {
-		return "", checkErrNotFound(err)
+		return "", err
 	}
 
-	var v = string(item.Value)
-	if isStringNumeric(v) || isStringBool(v) {
-		return v, nil
+	return stringValue(s.encoder, value)
+}
+
+// GetTime gets a time.Time value from the store
+func (s *MemcacheStore) GetTime(key string) (time.Time, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return time.Time{}, err
 	}
-	if err = s.encoder.Decode(item.Value, &v); err != nil {
-		return "", err
+
+	return timeValue(s.encoder, value)
+}
+
+// GetDuration gets a time.Duration value from the store
+func (s *MemcacheStore) GetDuration(key string) (time.Duration, error) {
+	value, err := s.value(key)
+	if err != nil {
+		return 0, err
 	}
 
-	return v, nil
+	return durationValue(s.encoder, value)
+}
+
+// GetBytes gets the raw bytes stored for the given key bypassing the encoder
+func (s *MemcacheStore) GetBytes(key string) ([]byte, error) {
+	return s.bytes(key)
+}
+
+// PutBytes puts raw bytes in the given store for a predetermined amount of time bypassing the encoder
+func (s *MemcacheStore) PutBytes(key string, value []byte, duration time.Duration) error {
+	return s.set(&memcache.Item{
+		Key:        s.k(key),
+		Value:      value,
+		Expiration: int32(duration.Seconds()),
+	})
 }
 
 // Increment increments an integer counter by a given value
 func (s *MemcacheStore) Increment(key string, value int64) (int64, error) {
+	if isEncrypted(s.encoder) {
+		return 0, ErrEncryptedCounter
+	}
+
 	res, err := s.client.Increment(s.k(key), uint64(value))
 	if err != nil {
 		if !errors.Is(err, memcache.ErrCacheMiss) {
@@ -199,6 +274,10 @@ func (s *MemcacheStore) Increment(key string, value int64) (int64, error) {
 // Decrement decrements an integer counter by a given value. Please note that for memcache a new value will be
 // capped at 0
 func (s *MemcacheStore) Decrement(key string, value int64) (int64, error) {
+	if isEncrypted(s.encoder) {
+		return 0, ErrEncryptedCounter
+	}
+
 	newValue, err := s.client.Decrement(s.k(key), uint64(value))
 	if err != nil {
 		if !errors.Is(err, memcache.ErrCacheMiss) {
@@ -219,72 +298,181 @@ func (s *MemcacheStore) Decrement(key string, value int64) (int64, error) {
 	return int64(newValue), nil
 }
 
-// PutMany puts many values in the given store until they are forgotten/evicted
+// PutMany puts many values in the given store until they are forgotten/evicted. Entries are grouped by server
+// and written concurrently, with at most MemcacheConfig.BatchConcurrency in flight requests per server
 func (s *MemcacheStore) PutMany(entries ...Entry) error {
-	for _, entry := range entries {
-		if err := s.Put(entry.Key, entry.Value, entry.Duration); err != nil {
+	var (
+		items  = make([]*memcache.Item, len(entries))
+		keys   = make([]string, len(entries))
+		chunks []*memcache.Item
+	)
+	var chunked []string
+	for i, entry := range entries {
+		item, err := s.item(entry.Key, entry.Value, entry.Duration)
+		if err != nil {
 			return err
 		}
+
+		if itemChunks := s.chunks(item); len(itemChunks) > 0 {
+			chunks = append(chunks, itemChunks...)
+			chunked = append(chunked, item.Key)
+		}
+
+		items[i] = item
+		keys[i] = item.Key
 	}
 
-	return nil
+	previous, err := s.manifests(chunked...)
+	if err != nil {
+		return err
+	}
+	if err = s.setChunks(chunks); err != nil {
+		return err
+	}
+	if err = s.batch(keys, func(i int) error {
+		return s.client.Set(items[i])
+	}); err != nil {
+		return err
+	}
+
+	return s.deleteChunks(previous)
 }
 
-// Many gets many values from the store
+// Many gets many values from the store. Keys are grouped by server and fetched concurrently with one multi-get
+// per server. Errors are reported per key via Item.Error()
 func (s *MemcacheStore) Many(keys ...string) (Items, error) {
-	items := Items{}
+	var (
+		items  = Items{}
+		groups = map[string][]string{}
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+	)
 	for _, key := range keys {
-		item, err := s.client.Get(s.k(key))
+		addr, err := s.client.selector.PickServer(s.k(key))
 		if err != nil {
 			items[key] = Item{
 				key: key,
-				err: checkErrNotFound(err),
+				err: err,
 			}
 
 			continue
 		}
 
-		v := string(item.Value)
-		if isStringNumeric(v) || isStringBool(v) {
-			items[key] = Item{
-				key:     key,
-				value:   v,
-				encoder: s.encoder,
+		groups[addr.String()] = append(groups[addr.String()], key)
+	}
+
+	for _, group := range groups {
+		wg.Add(1)
+		go func(group []string) {
+			defer wg.Done()
+
+			var prefixedKeys = make([]string, len(group))
+			for i, key := range group {
+				prefixedKeys[i] = s.k(key)
 			}
 
-			continue
-		}
+			var (
+				results, err = s.client.GetMulti(prefixedKeys)
+				groupItems   = make(Items, len(group))
+			)
+			for i, key := range group {
+				if err != nil {
+					groupItems[key] = Item{
+						key: key,
+						err: checkErrNotFound(err),
+					}
+
+					continue
+				}
+
+				result, exists := results[prefixedKeys[i]]
+				if !exists {
+					groupItems[key] = Item{
+						key: key,
+						err: ErrNotFound,
+					}
+
+					continue
+				}
+
+				value, assembleErr := s.assemble(result)
+				if assembleErr != nil {
+					groupItems[key] = Item{
+						key: key,
+						err: assembleErr,
+					}
+
+					continue
+				}
+
+				groupItems[key] = Item{
+					key:     key,
+					value:   string(value),
+					encoder: s.encoder,
+				}
+			}
 
-		items[key] = Item{
-			key:     key,
-			value:   v,
-			encoder: s.encoder,
-		}
+			mu.Lock()
+			defer mu.Unlock()
+
+			for key, item := range groupItems {
+				items[key] = item
+			}
+		}(group)
 	}
 
+	wg.Wait()
+
 	return items, nil
 }
 
 // Forget forgets/evicts a given key-value pair from the store
 func (s *MemcacheStore) Forget(key string) (bool, error) {
-	if err := s.client.Delete(s.k(key)); errors.Is(err, memcache.ErrCacheMiss) {
+	manifests, err := s.chunkedManifests(s.k(key))
+	if err != nil {
+		return false, err
+	}
+	if err = s.client.Delete(s.k(key)); errors.Is(err, memcache.ErrCacheMiss) {
 		return false, nil
 	} else if err != nil {
 		return false, err
 	}
 
-	return true, nil
+	return true, s.deleteChunks(manifests)
 }
 
-// ForgetMany forgets/evicts a set of given key-value pair from the store
+// ForgetMany forgets/evicts a set of given key-value pair from the store. Keys are grouped by server and deleted
+// concurrently, with at most MemcacheConfig.BatchConcurrency in flight requests per server
 func (s *MemcacheStore) ForgetMany(keys ...string) error {
-	for _, key := range keys {
-		if err := s.client.Delete(s.k(key)); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+	var prefixedKeys = make([]string, len(keys))
+	for i, key := range keys {
+		prefixedKeys[i] = s.k(key)
+	}
+
+	manifests, err := s.chunkedManifests(prefixedKeys...)
+	if err != nil {
+		return err
+	}
+	if err = s.batch(prefixedKeys, func(i int) error {
+		if err := s.client.Delete(prefixedKeys[i]); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
 			return err
 		}
+
+		return nil
+	}); err != nil {
+		return err
 	}
 
-	return nil
+	return s.deleteChunks(manifests)
+}
+
+// chunkedManifests returns the manifests held by the given prefixed keys unless chunking is disabled
+func (s *MemcacheStore) chunkedManifests(keys ...string) (map[string]chunkManifest, error) {
+	if s.chunkSize <= 0 {
+		return nil, nil
+	}
+
+	return s.manifests(keys...)
 }
 
 // Flush flushes the store
@@ -298,12 +486,12 @@ func (s *MemcacheStore) Flush() (bool, error) {
 
 // Get gets the struct representation of a value from the store
 func (s *MemcacheStore) Get(key string, entity interface{}) error {
-	item, err := s.client.Get(s.k(key))
+	value, err := s.bytes(key)
 	if err != nil {
-		return checkErrNotFound(err)
+		return err
 	}
 
-	return s.encoder.Decode(item.Value, &entity)
+	return decode(s.encoder, value, entity)
 }
 
 // Close closes the c releasing all open resources
@@ -313,12 +501,15 @@ func (*MemcacheStore) Close() error {
 
 // Tags returns the taggedCache for the given store
 func (s *MemcacheStore) Tags(names ...string) TaggedCache {
-	return &taggedCache{
-		store: s,
-		tags: &TagSet{
+	return &indexedTaggedCache{
+		taggedCache: taggedCache{
 			store: s,
-			names: names,
+			tags: &TagSet{
+				store: s,
+				names: names,
+			},
 		},
+		index: s,
 	}
 }
 
@@ -339,8 +530,21 @@ func (s *MemcacheStore) Exists(key string) (bool, error) {
 	return false, err
 }
 
-// Expire implementation of the Cache interface
+// Expire implementation of the Cache interface. The chunks of chunked values are touched as well
 func (s *MemcacheStore) Expire(key string, duration time.Duration) error {
+	if s.chunkSize > 0 {
+		item, err := s.client.Get(s.k(key))
+		if err != nil {
+			return checkErrNotFound(err)
+		}
+		if m, chunked := parseChunkManifest(item.Value); chunked {
+			for _, chunk := range m.keys(item.Key) {
+				if err = s.client.Touch(chunk, int32(duration.Seconds())); err != nil {
+					return checkErrNotFound(err)
+				}
+			}
+		}
+	}
 	if err := s.client.Touch(s.k(key), int32(duration.Seconds())); err != nil {
 		return checkErrNotFound(err)
 	}
@@ -348,13 +552,97 @@ func (s *MemcacheStore) Expire(key string, duration time.Duration) error {
 	return nil
 }
 
+// batch groups the given prefixed keys by server and invokes fn concurrently for each of them. The first error
+// encountered is returned
+func (s *MemcacheStore) batch(keys []string, fn func(i int) error) error {
+	var groups = map[string][]int{}
+	for i, key := range keys {
+		addr, err := s.client.selector.PickServer(key)
+		if err != nil {
+			return err
+		}
+
+		groups[addr.String()] = append(groups[addr.String()], i)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for _, group := range groups {
+		workers := s.batchConcurrency
+		if workers > len(group) {
+			workers = len(group)
+		}
+
+		queue := make(chan int, len(group))
+		for _, i := range group {
+			queue <- i
+		}
+
+		close(queue)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for i := range queue {
+					if err := fn(i); err != nil {
+						once.Do(func() {
+							firstErr = err
+						})
+					}
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
 func (s *MemcacheStore) value(key string) (string, error) {
+	value, err := s.bytes(key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+// bytes returns the value stored for key reassembling it from its chunks if needed
+func (s *MemcacheStore) bytes(key string) ([]byte, error) {
 	item, err := s.client.Get(s.k(key))
 	if err != nil {
-		return "", checkErrNotFound(err)
+		return nil, checkErrNotFound(err)
+	}
+
+	return s.assemble(item)
+}
+
+// set writes item along with its chunks if its value exceeds the chunk size. The chunks of the chunked value it
+// replaces are deleted once it has been written, whereas those of a chunked value replaced by a regular one are
+// left to expire or be evicted, sparing regular writes a read
+func (s *MemcacheStore) set(item *memcache.Item) error {
+	chunks := s.chunks(item)
+	if len(chunks) == 0 {
+		return s.client.Set(item)
+	}
+
+	previous, err := s.manifests(item.Key)
+	if err != nil {
+		return err
+	}
+	if err = s.setChunks(chunks); err != nil {
+		return err
+	}
+	if err = s.client.Set(item); err != nil {
+		return err
 	}
 
-	return string(item.Value), nil
+	return s.deleteChunks(previous)
 }
 
 func (s *MemcacheStore) item(key string, value interface{}, duration time.Duration) (*memcache.Item, error) {
@@ -362,10 +650,10 @@ func (s *MemcacheStore) item(key string, value interface{}, duration time.Durati
 		val []byte
 		err error
 	)
-	if isNumeric(value) || isBool(value) {
+	if storedAsIs(s.encoder, value) {
 		val = []byte(fmt.Sprint(value))
 	} else {
-		val, err = s.encoder.Encode(value)
+		val, err = encode(s.encoder, value)
 	}
 	if err != nil {
 		return nil, err
@@ -0,0 +1,405 @@
+package gocache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+	"github.com/alejandro-carstens/gocache/gocachetest/testserver"
+)
+
+func TestMemcacheStore_Chunking(t *testing.T) {
+	var (
+		server = testserver.RunMemcache(t)
+		value  = make([]byte, 3*testserver.MemcacheMaxItemSize)
+	)
+	rand.New(rand.NewSource(1)).Read(value)
+
+	cache, err := NewMemcacheStore(&MemcacheConfig{
+		Prefix:  "golavel:",
+		Servers: []string{server.Addr()},
+	}, encoder.JSON{})
+	require.NoError(t, err)
+
+	require.NoError(t, cache.PutBytes("blob", value, time.Minute))
+	require.Equal(t, 5, server.Len())
+
+	got, err := cache.GetBytes("blob")
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(value, got))
+
+	large := example{Name: "report", Description: string(bytes.Repeat([]byte("a"), 2*testserver.MemcacheMaxItemSize))}
+	require.NoError(t, cache.PutMany(Entry{Key: "report", Value: large, Duration: time.Minute}))
+
+	var e example
+	require.NoError(t, cache.Get("report", &e))
+	require.Equal(t, large, e)
+
+	items, err := cache.Many("blob", "report", "missing")
+	require.NoError(t, err)
+	require.NoError(t, items["report"].Unmarshal(&e))
+	require.Equal(t, large, e)
+	require.True(t, items["missing"].EntryNotFound())
+
+	got, err = items["blob"].Bytes()
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(value, got))
+
+	added, err := cache.Add("blob", value, time.Minute)
+	require.NoError(t, err)
+	require.False(t, added)
+	require.NoError(t, cache.Expire("blob", time.Hour))
+
+	got, err = cache.GetBytes("blob")
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(value, got))
+
+	// A stale chunk, e.g. one left behind by a concurrent write, is treated as a miss
+	require.NoError(t, cache.client.Set(&memcache.Item{Key: chunkKeys(t, cache, "blob")[1], Value: []byte("stale")}))
+
+	_, err = cache.GetBytes("blob")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, cache.GetStream("blob", io.Discard), ErrNotFound)
+
+	// So is a missing one
+	require.NoError(t, cache.client.Delete(chunkKeys(t, cache, "report")[0]))
+	require.ErrorIs(t, cache.Get("report", &e), ErrNotFound)
+
+	items, err = cache.Many("report")
+	require.NoError(t, err)
+	require.True(t, items["report"].EntryNotFound())
+
+	disabled, err := NewMemcacheStore(&MemcacheConfig{
+		Servers:   []string{server.Addr()},
+		ChunkSize: -1,
+	}, encoder.JSON{})
+	require.NoError(t, err)
+	require.Error(t, disabled.PutBytes("blob", value, time.Minute))
+}
+
+func TestMemcacheStore_Get(t *testing.T) {
+	server := testserver.RunMemcache(t)
+	for name, e := range map[string]encoder.Encoder{
+		"json":    encoder.JSON{},
+		"msgpack": encoder.Msgpack{},
+		"gob":     encoder.Gob{},
+		"cbor":    encoder.CBOR{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cache, err := NewMemcacheStore(&MemcacheConfig{
+				Prefix:  name + ":",
+				Servers: []string{server.Addr()},
+			}, e)
+			require.NoError(t, err)
+
+			expected := example{Name: "name", Description: "description"}
+			require.NoError(t, cache.Put("key", expected, time.Minute))
+
+			var got example
+			require.NoError(t, cache.Get("key", &got))
+			require.Equal(t, expected, got)
+		})
+	}
+}
+
+func TestMemcacheStore_ChunkCleanup(t *testing.T) {
+	var (
+		server = testserver.RunMemcache(t)
+		value  = make([]byte, 3*testserver.MemcacheMaxItemSize)
+	)
+	rand.New(rand.NewSource(1)).Read(value)
+
+	cache, err := NewMemcacheStore(&MemcacheConfig{
+		Prefix:  "golavel:",
+		Servers: []string{server.Addr()},
+	}, encoder.JSON{})
+	require.NoError(t, err)
+
+	// Overwriting a chunked value writes new chunks and deletes the replaced ones
+	require.NoError(t, cache.PutBytes("blob", value, time.Minute))
+	previous := chunkKeys(t, cache, "blob")
+	require.NoError(t, cache.PutBytes("blob", value[:2*testserver.MemcacheMaxItemSize], time.Minute))
+	require.Equal(t, 4, server.Len())
+	require.NotEqual(t, previous[0], chunkKeys(t, cache, "blob")[0])
+
+	got, err := cache.GetBytes("blob")
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(value[:2*testserver.MemcacheMaxItemSize], got))
+
+	forgotten, err := cache.Forget("blob")
+	require.NoError(t, err)
+	require.True(t, forgotten)
+	require.Zero(t, server.Len())
+
+	require.NoError(t, cache.PutMany(
+		Entry{Key: "first", Value: value, Duration: time.Minute},
+		Entry{Key: "second", Value: value, Duration: 0},
+		Entry{Key: "small", Value: "small", Duration: 0},
+	))
+	require.NoError(t, cache.PutMany(Entry{Key: "first", Value: value, Duration: time.Minute}))
+	// Values encoded as base64 by encoder.JSON span 5 chunks
+	require.Equal(t, 13, server.Len())
+	require.NoError(t, cache.ForgetMany("first", "second", "small", "missing"))
+	require.Zero(t, server.Len())
+
+	// Streams are written under a new generation, a stream failing midway deletes the chunks it wrote and
+	// a complete one deletes the chunks of the value it replaces
+	require.NoError(t, cache.PutStream("stream", bytes.NewReader(value), time.Minute))
+	previous = chunkKeys(t, cache, "stream")
+	require.Equal(t, len(previous)+1, server.Len())
+	require.Error(t, cache.PutStream("stream", &failingAfter{r: bytes.NewReader(value), n: len(value) - 1}, time.Minute))
+	require.Equal(t, previous, chunkKeys(t, cache, "stream"))
+	require.Equal(t, len(previous)+1, server.Len())
+	require.NoError(t, cache.PutStream("stream", bytes.NewReader(value), time.Minute))
+	require.NotEqual(t, previous, chunkKeys(t, cache, "stream"))
+	require.Equal(t, len(previous)+1, server.Len())
+	require.NoError(t, cache.ForgetMany("stream"))
+	require.Zero(t, server.Len())
+}
+
+// chunkKeys returns the keys of the chunks of the given chunked key
+func chunkKeys(t *testing.T, cache *MemcacheStore, key string) []string {
+	t.Helper()
+
+	item, err := cache.client.Get(cache.k(key))
+	require.NoError(t, err)
+
+	m, chunked := parseChunkManifest(item.Value)
+	require.True(t, chunked)
+
+	return m.keys(item.Key)
+}
+
+func TestMemcacheStore_Batch(t *testing.T) {
+	var (
+		servers = []*testserver.Memcache{testserver.RunMemcache(t), testserver.RunMemcache(t)}
+		entries []Entry
+		keys    []string
+	)
+	cache, err := NewMemcacheStore(&MemcacheConfig{
+		Prefix:           "gocache:",
+		Servers:          []string{servers[0].Addr(), servers[1].Addr()},
+		BatchConcurrency: 2,
+	}, encoder.JSON{})
+	require.NoError(t, err)
+
+	for i := 0; i < 40; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		entries = append(entries, Entry{Key: key, Value: i, Duration: time.Minute})
+		keys = append(keys, key)
+	}
+
+	// Writes and deletes are spread across the servers with at most BatchConcurrency requests in flight per server
+	for _, server := range servers {
+		server.SetLatency(10 * time.Millisecond)
+	}
+	require.NoError(t, cache.PutMany(entries...))
+	require.NoError(t, cache.ForgetMany(keys[:10]...))
+	for _, server := range servers {
+		require.NotZero(t, server.Len())
+		require.Equal(t, 2, server.MaxInFlight())
+
+		server.SetLatency(0)
+	}
+
+	// Reads are grouped in a single multi-get per server and errors are reported per key
+	var gets = make([]int, len(servers))
+	for i, server := range servers {
+		gets[i] = server.Commands("get") + server.Commands("gets")
+	}
+
+	items, err := cache.Many(append(keys, "missing")...)
+	require.NoError(t, err)
+	require.Len(t, items, len(keys)+1)
+	for i, server := range servers {
+		require.Equal(t, gets[i]+1, server.Commands("get")+server.Commands("gets"))
+	}
+	for i, key := range keys {
+		if i < 10 {
+			require.ErrorIs(t, items[key].Error(), ErrNotFound, key)
+
+			continue
+		}
+
+		v, err := items[key].Int()
+		require.NoError(t, err)
+		require.Equal(t, i, v)
+	}
+	require.ErrorIs(t, items["missing"].Error(), ErrNotFound)
+
+	// The keys of a failing server report its error while the remaining ones are still returned
+	failing, err := cache.client.selector.PickServer(cache.k(keys[len(keys)-1]))
+	require.NoError(t, err)
+	for _, server := range servers {
+		if server.Addr() == failing.String() {
+			require.NoError(t, server.Close())
+		}
+	}
+
+	items, err = cache.Many(keys[10:]...)
+	require.NoError(t, err)
+	for i, key := range keys[10:] {
+		addr, err := cache.client.selector.PickServer(cache.k(key))
+		require.NoError(t, err)
+		if addr.String() == failing.String() {
+			require.Error(t, items[key].Error(), key)
+			require.NotErrorIs(t, items[key].Error(), ErrNotFound, key)
+
+			continue
+		}
+
+		v, err := items[key].Int()
+		require.NoError(t, err)
+		require.Equal(t, i+10, v)
+	}
+
+	require.Error(t, cache.PutMany(entries...))
+	require.Error(t, cache.ForgetMany(keys...))
+}
+
+func TestMemcacheStore_TagIndex(t *testing.T) {
+	cache, err := NewMemcacheStore(&MemcacheConfig{
+		Prefix:          "gocache:",
+		Servers:         []string{testserver.RunMemcache(t).Addr()},
+		TagIndexLimit:   2,
+		TagIndexBuckets: 1,
+	}, encoder.JSON{})
+	require.NoError(t, err)
+
+	tagged := cache.Tags("people")
+	for _, key := range []string{"jane", "john", "joe", "joe"} {
+		require.NoError(t, tagged.Put(key, key, time.Minute))
+	}
+
+	ids, err := tagged.TagSet().TagIds()
+	require.NoError(t, err)
+
+	index, err := cache.client.Get(cache.k(tagIndexKey(ids[0], 0)))
+	require.NoError(t, err)
+	require.Len(t, tagIndexKeys(index.Value), 2)
+
+	var keys = map[string]string{}
+	for _, key := range []string{"jane", "john", "joe"} {
+		keys[key], err = tagged.(*indexedTaggedCache).tagKey(key)
+		require.NoError(t, err)
+	}
+
+	_, err = tagged.Flush()
+	require.NoError(t, err)
+
+	// jane was dropped from the bounded index and is only orphaned by the rotated tag id
+	for key, expected := range map[string]bool{"jane": true, "john": false, "joe": false} {
+		exists, err := cache.Exists(keys[key])
+		require.NoError(t, err)
+		require.Equal(t, expected, exists, key)
+	}
+
+	_, err = cache.client.Get(cache.k(tagIndexKey(ids[0], 0)))
+	require.ErrorIs(t, err, memcache.ErrCacheMiss)
+}
+
+func TestMemcacheStore_TagKeys(t *testing.T) {
+	cache, err := NewMemcacheStore(&MemcacheConfig{
+		Prefix:          "gocache:",
+		Servers:         []string{testserver.RunMemcache(t).Addr()},
+		TagIndexLimit:   4,
+		TagIndexBuckets: 1,
+	}, encoder.JSON{})
+	require.NoError(t, err)
+
+	tagged := cache.Tags("people")
+	for _, key := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, tagged.Put(key, key, time.Minute))
+	}
+
+	page, cursor, err := tagged.Keys("", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, page)
+
+	// Dropping the oldest keys from the bounded index does not shift the following page
+	for _, key := range []string{"e", "f"} {
+		require.NoError(t, tagged.Put(key, key, time.Minute))
+	}
+
+	page, cursor, err = tagged.Keys(cursor, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"c", "d"}, page)
+
+	// Keys whose entries are gone are neither returned nor counted
+	_, err = tagged.Forget("e")
+	require.NoError(t, err)
+
+	page, cursor, err = tagged.Keys(cursor, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"f"}, page)
+	require.Empty(t, cursor)
+
+	counts, err := tagged.TagSet().Counts()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"people": 3}, counts)
+}
+
+func TestMemcacheStore_TagIndexBuckets(t *testing.T) {
+	cache, err := NewMemcacheStore(&MemcacheConfig{
+		Prefix:  "gocache:",
+		Servers: []string{testserver.RunMemcache(t).Addr()},
+	}, encoder.JSON{})
+	require.NoError(t, err)
+
+	var (
+		tagged = cache.Tags("people")
+		wg     sync.WaitGroup
+		errs   = make(chan error, 200)
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				errs <- tagged.Put(fmt.Sprintf("key-%d-%d", i, j), j, time.Minute)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	ids, err := tagged.TagSet().TagIds()
+	require.NoError(t, err)
+
+	// Keys are spread across the buckets of the index
+	items, err := cache.client.GetMulti(cache.tagIndexBucketKeys(ids[0]))
+	require.NoError(t, err)
+	require.Greater(t, len(items), 1)
+
+	count, err := tagged.TagSet().Counts()
+	require.NoError(t, err)
+	require.Equal(t, int64(200), count["people"])
+
+	keys, err := cache.readTagIndex(ids[0])
+	require.NoError(t, err)
+	require.Len(t, keys, 200)
+
+	_, err = tagged.Flush()
+	require.NoError(t, err)
+
+	// Every tagged entry is evicted
+	existing, err := cache.existingKeys(keys)
+	require.NoError(t, err)
+	require.Empty(t, existing)
+
+	items, err = cache.client.GetMulti(cache.tagIndexBucketKeys(ids[0]))
+	require.NoError(t, err)
+	require.Empty(t, items)
+}
@@ -0,0 +1,277 @@
+package gocache
+
+import (
+	"bytes"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const (
+	// defaultMemcacheTagIndexLimit is the default maximum number of keys recorded per tag id
+	defaultMemcacheTagIndexLimit = 10000
+	// defaultMemcacheTagIndexBuckets is the default number of items each tag index is spread across
+	defaultMemcacheTagIndexBuckets = 16
+	// memcacheTagIndexRetries is the number of times an index update is retried upon a CAS conflict
+	memcacheTagIndexRetries = 16
+	// memcacheTagKeysBatchSize is the maximum number of keys looked up at once when counting the keys of a tag
+	memcacheTagKeysBatchSize = 1000
+)
+
+var (
+	_ tagIndex = &MemcacheStore{}
+	_ tagKeys  = &MemcacheStore{}
+)
+
+// tagIndexKey returns the key of the given bucket of the index holding the keys written under the given tag id
+func tagIndexKey(id string, bucket int) string {
+	return "tag:" + id + ":index:" + strconv.Itoa(bucket)
+}
+
+// tagIndexBucketKeys returns the prefixed keys of every bucket of the index of the given tag id
+func (s *MemcacheStore) tagIndexBucketKeys(id string) []string {
+	keys := make([]string, s.tagIndexBuckets)
+	for bucket := range keys {
+		keys[bucket] = s.k(tagIndexKey(id, bucket))
+	}
+
+	return keys
+}
+
+// tagIndexBucket returns the bucket of an index the given key is recorded in
+func (s *MemcacheStore) tagIndexBucket(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return int(h.Sum32() % uint32(s.tagIndexBuckets))
+}
+
+// indexTagKey implementation of the tagIndex interface. Each index is spread across MemcacheConfig.TagIndexBuckets
+// newline separated items maintained via CAS. Once a bucket holds its share of MemcacheConfig.TagIndexLimit keys or
+// reaches the chunk size its oldest keys are dropped, their entries being left to expire or be evicted as their tag
+// id is rotated on Flush anyway. ErrTagIndexContended is returned, and the entry is not written, when a bucket
+// remains contended after several retries
+func (s *MemcacheStore) indexTagKey(ids []string, key string) error {
+	if s.tagIndexLimit < 0 {
+		return nil
+	}
+
+	bucket := s.tagIndexBucket(key)
+	for _, id := range ids {
+		if err := s.appendTagIndex(s.k(tagIndexKey(id, bucket)), key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flushTagIndex implementation of the tagIndex interface
+func (s *MemcacheStore) flushTagIndex(id string) error {
+	if s.tagIndexLimit < 0 {
+		return nil
+	}
+
+	keys, err := s.readTagIndex(id)
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	if err = s.ForgetMany(keys...); err != nil {
+		return err
+	}
+
+	return s.deleteItems(s.tagIndexBucketKeys(id))
+}
+
+// scanTagKeys implementation of the tagKeys interface. The keys of an index are paged in lexicographical order and
+// the cursor is the last key of the previous page, hence keys dropped from a bounded index do not shift the following
+// pages. Keys whose entries have expired or been evicted are skipped
+func (s *MemcacheStore) scanTagKeys(id, prefix, cursor string, count int) ([]string, string, error) {
+	keys, err := s.readTagIndex(id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sort.Strings(keys)
+
+	var (
+		start = sort.SearchStrings(keys, cursor)
+		next  string
+	)
+	if start < len(keys) && keys[start] == cursor {
+		start++
+	}
+
+	end := start + count
+	if end < len(keys) {
+		next = keys[end-1]
+	} else {
+		end = len(keys)
+	}
+
+	var candidates []string
+	for _, key := range keys[start:end] {
+		if strings.HasPrefix(key, prefix) {
+			candidates = append(candidates, key)
+		}
+	}
+
+	matches, err := s.existingKeys(candidates)
+
+	return matches, next, err
+}
+
+// countTagKeys implementation of the tagKeys interface. Keys whose entries have expired or been evicted are not
+// counted
+func (s *MemcacheStore) countTagKeys(id string) (int64, error) {
+	keys, err := s.readTagIndex(id)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > memcacheTagKeysBatchSize {
+			n = memcacheTagKeysBatchSize
+		}
+
+		existing, err := s.existingKeys(keys[:n])
+		if err != nil {
+			return 0, err
+		}
+
+		count += int64(len(existing))
+		keys = keys[n:]
+	}
+
+	return count, nil
+}
+
+// existingKeys returns the given unprefixed keys that hold an entry, which are looked up via a single GetMulti
+func (s *MemcacheStore) existingKeys(keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var prefixedKeys = make([]string, len(keys))
+	for i, key := range keys {
+		prefixedKeys[i] = s.k(key)
+	}
+
+	items, err := s.client.GetMulti(prefixedKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []string
+	for i, key := range keys {
+		if _, exists := items[prefixedKeys[i]]; exists {
+			existing = append(existing, key)
+		}
+	}
+
+	return existing, nil
+}
+
+// readTagIndex returns the keys held by the index of the given tag id, bucket by bucket
+func (s *MemcacheStore) readTagIndex(id string) ([]string, error) {
+	bucketKeys := s.tagIndexBucketKeys(id)
+	items, err := s.client.GetMulti(bucketKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, bucketKey := range bucketKeys {
+		if item, exists := items[bucketKey]; exists {
+			keys = append(keys, tagIndexKeys(item.Value)...)
+		}
+	}
+
+	return keys, nil
+}
+
+// appendTagIndex adds key to the index bucket stored under the given prefixed key unless it is already recorded.
+// ErrTagIndexContended is returned once every retry ran into a concurrent update
+func (s *MemcacheStore) appendTagIndex(indexKey, key string) error {
+	for attempt := 0; attempt < memcacheTagIndexRetries; attempt++ {
+		item, err := s.client.Get(indexKey)
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			if err = s.client.Add(&memcache.Item{Key: indexKey, Value: []byte(key)}); errors.Is(err, memcache.ErrNotStored) {
+				continue
+			}
+
+			return err
+		} else if err != nil {
+			return err
+		}
+
+		keys := tagIndexKeys(item.Value)
+		for _, k := range keys {
+			if k == key {
+				return nil
+			}
+		}
+
+		item.Value = s.boundTagIndex(append(keys, key))
+		if err = s.client.CompareAndSwap(item); errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) {
+			continue
+		}
+
+		return err
+	}
+
+	return ErrTagIndexContended
+}
+
+// boundTagIndex encodes the keys of a bucket dropping the oldest ones beyond the bucket limits
+func (s *MemcacheStore) boundTagIndex(keys []string) []byte {
+	limit := s.tagIndexLimit
+	if limit == 0 {
+		limit = defaultMemcacheTagIndexLimit
+	}
+	if limit = (limit + s.tagIndexBuckets - 1) / s.tagIndexBuckets; limit < 1 {
+		limit = 1
+	}
+	if len(keys) > limit {
+		keys = keys[len(keys)-limit:]
+	}
+
+	maxSize := s.chunkSize
+	if maxSize <= 0 {
+		maxSize = defaultMemcacheChunkSize
+	}
+
+	value := []byte(strings.Join(keys, "\n"))
+	for len(value) > maxSize {
+		i := bytes.IndexByte(value, '\n')
+		if i < 0 {
+			return nil
+		}
+
+		value = value[i+1:]
+	}
+
+	return value
+}
+
+// tagIndexKeys returns the keys held by an index value. Memcache keys cannot contain whitespace
+func tagIndexKeys(value []byte) []string {
+	if len(value) == 0 {
+		return nil
+	}
+
+	var keys []string
+	for _, line := range bytes.Split(value, []byte{'\n'}) {
+		if len(line) > 0 {
+			keys = append(keys, string(line))
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,51 @@
+package gocache
+
+import "time"
+
+var _ Lock = &nullLock{}
+
+func newNullLock(name, owner string, duration time.Duration) *nullLock {
+	return (&nullLock{
+		name:     name,
+		owner:    owner,
+		duration: duration,
+	}).initBaseLock()
+}
+
+type nullLock struct {
+	baseLock
+	name     string
+	owner    string
+	duration time.Duration
+}
+
+// Acquire implementation of the Lock interface, the lock is always acquired
+func (*nullLock) Acquire() (bool, error) {
+	return true, nil
+}
+
+// Release implementation of the Lock interface, the lock is always released
+func (*nullLock) Release() (bool, error) {
+	return true, nil
+}
+
+// ForceRelease implementation of the Lock interface
+func (*nullLock) ForceRelease() error {
+	return nil
+}
+
+// GetCurrentOwner implementation of the Lock interface, given that nothing is stored there is never an owner
+func (*nullLock) GetCurrentOwner() (string, error) {
+	return "", nil
+}
+
+// Expire implementation of the Lock interface
+func (*nullLock) Expire(time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (l *nullLock) initBaseLock() *nullLock {
+	l.lock = l
+
+	return l
+}
@@ -0,0 +1,177 @@
+package gocache
+
+import (
+	"time"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+)
+
+var _ Cache = &NullStore{}
+
+// NewNullStore validates the passed in config and creates a Cache implementation of type *NullStore
+func NewNullStore(cnf *NullConfig, _ encoder.Encoder) (*NullStore, error) {
+	if err := cnf.validate(); err != nil {
+		return nil, err
+	}
+
+	return &NullStore{
+		prefix: prefix{
+			val: cnf.Prefix,
+		},
+	}, nil
+}
+
+// NullStore is a Cache implementation that does not cache anything. Every read misses with ErrNotFound while
+// writes succeed and are discarded. It allows for caching to be disabled without changing any call sites
+type NullStore struct {
+	prefix
+}
+
+// GetString implementation of the Cache interface, it always returns ErrNotFound
+func (*NullStore) GetString(string) (string, error) {
+	return "", ErrNotFound
+}
+
+// GetFloat64 implementation of the Cache interface, it always returns ErrNotFound
+func (*NullStore) GetFloat64(string) (float64, error) {
+	return 0, ErrNotFound
+}
+
+// GetFloat32 implementation of the Cache interface, it always returns ErrNotFound
+func (*NullStore) GetFloat32(string) (float32, error) {
+	return 0, ErrNotFound
+}
+
+// GetInt64 implementation of the Cache interface, it always returns ErrNotFound
+func (*NullStore) GetInt64(string) (int64, error) {
+	return 0, ErrNotFound
+}
+
+// GetInt implementation of the Cache interface, it always returns ErrNotFound
+func (*NullStore) GetInt(string) (int, error) {
+	return 0, ErrNotFound
+}
+
+// GetUint64 implementation of the Cache interface, it always returns ErrNotFound
+func (*NullStore) GetUint64(string) (uint64, error) {
+	return 0, ErrNotFound
+}
+
+// GetBool implementation of the Cache interface, it always returns ErrNotFound
+func (*NullStore) GetBool(string) (bool, error) {
+	return false, ErrNotFound
+}
+
+// GetTime implementation of the Cache interface, it always returns ErrNotFound
+func (*NullStore) GetTime(string) (time.Time, error) {
+	return time.Time{}, ErrNotFound
+}
+
+// GetDuration implementation of the Cache interface, it always returns ErrNotFound
+func (*NullStore) GetDuration(string) (time.Duration, error) {
+	return 0, ErrNotFound
+}
+
+// GetBytes implementation of the Cache interface, it always returns ErrNotFound
+func (*NullStore) GetBytes(string) ([]byte, error) {
+	return nil, ErrNotFound
+}
+
+// PutBytes implementation of the Cache interface, the value is discarded
+func (*NullStore) PutBytes(string, []byte, time.Duration) error {
+	return nil
+}
+
+// Get implementation of the Cache interface, it always returns ErrNotFound
+func (*NullStore) Get(string, interface{}) error {
+	return ErrNotFound
+}
+
+// Many implementation of the Cache interface, every Item is returned with ErrNotFound
+func (*NullStore) Many(keys ...string) (Items, error) {
+	items := Items{}
+	for _, key := range keys {
+		items[key] = Item{
+			key: key,
+			err: ErrNotFound,
+		}
+	}
+
+	return items, nil
+}
+
+// Put implementation of the Cache interface, the value is discarded
+func (*NullStore) Put(string, interface{}, time.Duration) error {
+	return nil
+}
+
+// Add implementation of the Cache interface, the value is discarded and true is always returned
+func (*NullStore) Add(string, interface{}, time.Duration) (bool, error) {
+	return true, nil
+}
+
+// Forever implementation of the Cache interface, the value is discarded
+func (*NullStore) Forever(string, interface{}) error {
+	return nil
+}
+
+// PutMany implementation of the Cache interface, the values are discarded
+func (*NullStore) PutMany(...Entry) error {
+	return nil
+}
+
+// Increment implementation of the Cache interface, the given value is returned as if the counter was missing
+func (*NullStore) Increment(_ string, value int64) (int64, error) {
+	return value, nil
+}
+
+// Decrement implementation of the Cache interface, the negated value is returned as if the counter was missing
+func (*NullStore) Decrement(_ string, value int64) (int64, error) {
+	return -1 * value, nil
+}
+
+// Forget implementation of the Cache interface, it always succeeds
+func (*NullStore) Forget(string) (bool, error) {
+	return true, nil
+}
+
+// ForgetMany implementation of the Cache interface, it always succeeds
+func (*NullStore) ForgetMany(...string) error {
+	return nil
+}
+
+// Flush implementation of the Cache interface, it always succeeds
+func (*NullStore) Flush() (bool, error) {
+	return true, nil
+}
+
+// Close implementation of the Cache interface
+func (*NullStore) Close() error {
+	return nil
+}
+
+// Exists implementation of the Cache interface, it always returns false
+func (*NullStore) Exists(string) (bool, error) {
+	return false, nil
+}
+
+// Expire implementation of the Cache interface, it always succeeds
+func (*NullStore) Expire(string, time.Duration) error {
+	return nil
+}
+
+// Tags returns the taggedCache for the given store
+func (s *NullStore) Tags(names ...string) TaggedCache {
+	return &taggedCache{
+		store: s,
+		tags: &TagSet{
+			store: s,
+			names: names,
+		},
+	}
+}
+
+// Lock returns a Lock implementation that can always be acquired
+func (*NullStore) Lock(name, owner string, duration time.Duration) Lock {
+	return newNullLock(name, owner, duration)
+}
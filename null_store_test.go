@@ -0,0 +1,67 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullStore(t *testing.T) {
+	for _, e := range encoders {
+		cache, err := New(&NullConfig{Prefix: "golavel:"}, e)
+		require.NoError(t, err)
+		require.NoError(t, cache.Put("key", "value", time.Minute))
+		require.NoError(t, cache.Forever("key", "value"))
+		require.NoError(t, cache.PutMany(Entry{Key: "key", Value: 1, Duration: time.Minute}))
+
+		_, err = cache.GetString("key")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		var ex example
+		require.ErrorIs(t, cache.Get("key", &ex), ErrNotFound)
+
+		added, err := cache.Add("key", "value", time.Minute)
+		require.NoError(t, err)
+		require.True(t, added)
+
+		added, err = cache.Add("key", "value", time.Minute)
+		require.NoError(t, err)
+		require.True(t, added)
+
+		exists, err := cache.Exists("key")
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		items, err := cache.Many("key", "other")
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.True(t, items["key"].EntryNotFound())
+		require.True(t, items["other"].EntryNotFound())
+
+		tc := cache.Tags("tag")
+		require.NoError(t, tc.Put("key", "value", time.Minute))
+
+		_, err = tc.GetString("key")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		acquired, err := cache.Lock("lock", "owner", time.Minute).Acquire()
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		acquired, err = cache.Lock("lock", "other", time.Minute).Acquire()
+		require.NoError(t, err)
+		require.True(t, acquired)
+
+		var called bool
+		acquired, err = cache.Lock("lock", "owner", time.Minute).Get(func() error {
+			called = true
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.True(t, acquired)
+		require.True(t, called)
+		require.NoError(t, cache.Close())
+	}
+}
@@ -0,0 +1,345 @@
+package gocache
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	durationType     = reflect.TypeOf(time.Duration(0))
+	fileModeType     = reflect.TypeOf(os.FileMode(0))
+	distributionType = reflect.TypeOf(MemcacheDistribution(0))
+	serversType      = reflect.TypeOf([]MemcacheServer{})
+)
+
+// configForDriver returns an empty config for the given driver name
+func configForDriver(driver string) (config, error) {
+	switch strings.ToLower(driver) {
+	case "redis":
+		return &RedisConfig{}, nil
+	case "memcache", "memcached":
+		return &MemcacheConfig{}, nil
+	case "local":
+		return &LocalConfig{}, nil
+	case "database":
+		return &DatabaseConfig{}, nil
+	case "file":
+		return &FileConfig{}, nil
+	case "null":
+		return &NullConfig{}, nil
+	}
+
+	return nil, fmt.Errorf("gocache: unsupported driver %q", driver)
+}
+
+// optionNames returns the sorted snake cased names of the config fields that can be set via applyOptions
+func optionNames(cnf config) []string {
+	var (
+		v     = reflect.ValueOf(cnf).Elem()
+		names []string
+	)
+	for i := 0; i < v.NumField(); i++ {
+		if supportedOption(v.Field(i).Type()) {
+			names = append(names, snakeCase(v.Type().Field(i).Name))
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// applyOptions sets the config fields from options keyed by their snake cased names, e.g. pool_size for PoolSize.
+// Values can either be of the field's type or strings, which allows for options to come from configuration
+// files, environment variables and URLs alike
+func applyOptions(cnf config, options map[string]interface{}) error {
+	v := reflect.ValueOf(cnf).Elem()
+
+	var fields = map[string]reflect.Value{}
+	for i := 0; i < v.NumField(); i++ {
+		if supportedOption(v.Field(i).Type()) {
+			fields[snakeCase(v.Type().Field(i).Name)] = v.Field(i)
+		}
+	}
+
+	for name, value := range options {
+		field, exists := fields[strings.ToLower(name)]
+		if !exists {
+			return fmt.Errorf("gocache: unknown option %q", name)
+		}
+		if err := setOption(field, value); err != nil {
+			return fmt.Errorf("gocache: invalid option %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func supportedOption(t reflect.Type) bool {
+	if t == serversType {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+
+	return false
+}
+
+func setOption(field reflect.Value, value interface{}) error {
+	switch t := field.Type(); {
+	case t == durationType:
+		d, err := toDuration(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(d))
+	case t == distributionType:
+		distribution, err := toDistribution(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(distribution))
+	case t == fileModeType:
+		mode, err := toUint(value, 0)
+		if err != nil {
+			return err
+		}
+
+		field.SetUint(mode)
+	case t == serversType:
+		servers, err := toServers(value)
+		if err != nil {
+			return err
+		}
+
+		field.Set(reflect.ValueOf(servers))
+	default:
+		switch t.Kind() {
+		case reflect.String:
+			field.SetString(fmt.Sprint(value))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(fmt.Sprint(value))
+			if err != nil {
+				return err
+			}
+
+			field.SetBool(b)
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+			if err != nil {
+				return err
+			}
+
+			field.SetFloat(f)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i, err := toInt(value)
+			if err != nil {
+				return err
+			}
+
+			field.SetInt(i)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			u, err := toUint(value, 10)
+			if err != nil {
+				return err
+			}
+
+			field.SetUint(u)
+		case reflect.Slice:
+			list, err := toStrings(value)
+			if err != nil {
+				return err
+			}
+
+			field.Set(reflect.ValueOf(list).Convert(t))
+		}
+	}
+
+	return nil
+}
+
+// toDuration converts Go duration strings such as 500ms or 1m to a time.Duration, plain numbers being seconds
+func toDuration(value interface{}) (time.Duration, error) {
+	if d, valid := value.(time.Duration); valid {
+		return d, nil
+	}
+
+	s := fmt.Sprint(value)
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+func toDistribution(value interface{}) (MemcacheDistribution, error) {
+	if distribution, valid := value.(MemcacheDistribution); valid {
+		return distribution, nil
+	}
+
+	switch s := strings.ToLower(fmt.Sprint(value)); s {
+	case "modula", "0":
+		return MemcacheDistributionModula, nil
+	case "ketama", "consistent", "1":
+		return MemcacheDistributionKetama, nil
+	default:
+		return 0, fmt.Errorf("unknown memcache distribution %q", s)
+	}
+}
+
+func toInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows int64", v)
+		}
+
+		return int64(v), nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+
+		return int64(v), nil
+	case json.Number:
+		return v.Int64()
+	}
+
+	return strconv.ParseInt(fmt.Sprint(value), 10, 64)
+}
+
+// toUint converts value to an unsigned integer, strings being parsed in the given base. A base of 0 allows for
+// octal file modes such as "0644"
+func toUint(value interface{}, base int) (uint64, error) {
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return 0, fmt.Errorf("%d is negative", v)
+		}
+
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	case float64:
+		if v < 0 || v != math.Trunc(v) {
+			return 0, fmt.Errorf("%v is not an unsigned integer", v)
+		}
+
+		return uint64(v), nil
+	}
+
+	return strconv.ParseUint(fmt.Sprint(value), base, 64)
+}
+
+// toStrings converts lists as well as comma separated strings to a []string
+func toStrings(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []string:
+		return v, nil
+	case []interface{}:
+		var list = make([]string, len(v))
+		for i, element := range v {
+			list[i] = fmt.Sprint(element)
+		}
+
+		return list, nil
+	case string:
+		var list []string
+		for _, element := range strings.Split(v, ",") {
+			if element = strings.TrimSpace(element); len(element) > 0 {
+				list = append(list, element)
+			}
+		}
+
+		return list, nil
+	}
+
+	return nil, fmt.Errorf("cannot convert %T to a list", value)
+}
+
+// toServers converts a list of {addr, weight} maps or of addr=weight strings to a []MemcacheServer
+func toServers(value interface{}) ([]MemcacheServer, error) {
+	if servers, valid := value.([]MemcacheServer); valid {
+		return servers, nil
+	}
+
+	var elements []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		elements = v
+	case string:
+		list, _ := toStrings(v)
+		for _, element := range list {
+			elements = append(elements, element)
+		}
+	default:
+		return nil, fmt.Errorf("cannot convert %T to a list of servers", value)
+	}
+
+	var servers = make([]MemcacheServer, len(elements))
+	for i, element := range elements {
+		var weight interface{} = 1
+		switch v := element.(type) {
+		case map[string]interface{}:
+			servers[i].Addr = fmt.Sprint(v["addr"])
+			if w, exists := v["weight"]; exists {
+				weight = w
+			}
+		default:
+			addr, w, found := strings.Cut(fmt.Sprint(v), "=")
+			servers[i].Addr = addr
+			if found {
+				weight = w
+			}
+		}
+
+		w, err := toInt(weight)
+		if err != nil {
+			return nil, err
+		}
+
+		servers[i].Weight = int(w)
+	}
+
+	return servers, nil
+}
+
+// snakeCase converts a Go identifier to snake case, e.g. ConnMaxIdleTime to conn_max_idle_time and TLSConfig to
+// tls_config
+func snakeCase(name string) string {
+	var (
+		b     strings.Builder
+		runes = []rune(name)
+	)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 &&
+			(unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteByte('_')
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
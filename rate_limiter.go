@@ -42,14 +42,14 @@ func (l *RateLimiter) TooManyAttempts(key string, maxAttempts int64) (bool, erro
 // Hit increments the counter for a given key for a given decay time
 func (l *RateLimiter) Hit(key string, decay time.Duration) (hits int64, err error) {
 	// The cache will manage the decay as the timer will be expired by the cache
-	if _, err = l.cache.Add(l.formatKey(key, "timer"), l.availableAt(decay).Unix(), decay); err != nil {
+	if _, err = l.cache.Add(l.timerKey(key), l.availableAt(decay).Unix(), decay); err != nil {
 		return 0, err
 	}
-	if _, err = l.cache.Add(l.formatKey(key, "counter"), int64(0), decay); err != nil {
+	if _, err = l.cache.Add(l.counterKey(key), int64(0), decay); err != nil {
 		return 0, err
 	}
 
-	hits, err = l.cache.Increment(l.formatKey(key, "counter"), 1)
+	hits, err = l.cache.Increment(l.counterKey(key), 1)
 	if err != nil {
 		return 0, err
 	}
@@ -59,7 +59,7 @@ func (l *RateLimiter) Hit(key string, decay time.Duration) (hits int64, err erro
 
 // Attempts gets the number of attempts for the given key
 func (l *RateLimiter) Attempts(key string) (int64, error) {
-	val, err := l.cache.GetInt64(l.formatKey(key, "counter"))
+	val, err := l.cache.GetInt64(l.counterKey(key))
 	if err != nil && !errors.Is(err, ErrNotFound) {
 		return val, err
 	}
@@ -79,7 +79,7 @@ func (l *RateLimiter) AttemptsLeft(key string, maxAttempts int64) (int64, error)
 		return left, nil
 	}
 
-	val, err := l.cache.GetInt64(l.formatKey(key, "timer"))
+	val, err := l.cache.GetInt64(l.timerKey(key))
 	if err != nil && !errors.Is(err, ErrNotFound) {
 		return 0, err
 	}
@@ -97,18 +97,18 @@ func (l *RateLimiter) AttemptsLeft(key string, maxAttempts int64) (int64, error)
 
 // Clear clears the hits and lockout timer for the given key
 func (l *RateLimiter) Clear(key string) error {
-	if _, err := l.cache.Forget(l.formatKey(key, "counter")); err != nil {
+	if _, err := l.cache.Forget(l.counterKey(key)); err != nil {
 		return err
 	}
 
-	_, err := l.cache.Forget(l.formatKey(key, "timer"))
+	_, err := l.cache.Forget(l.timerKey(key))
 
 	return err
 }
 
 // AvailableIn gets the number of seconds until the "key" is accessible again
 func (l *RateLimiter) AvailableIn(key string) (time.Duration, error) {
-	unixTime, err := l.cache.GetInt64(l.formatKey(key, "timer"))
+	unixTime, err := l.cache.GetInt64(l.timerKey(key))
 	if err != nil && !errors.Is(err, ErrNotFound) {
 		return 0, err
 	}
@@ -177,6 +177,24 @@ func (*RateLimiter) formatKey(p1, p2 string) string {
 	return fmt.Sprintf("%s:%s", p1, p2)
 }
 
+// counterKey returns the key holding the hits for key. Laravel compatible caches use the cleaned key itself
+func (l *RateLimiter) counterKey(key string) string {
+	if isLaravelCompatible(l.cache) {
+		return cleanRateLimiterKey(key)
+	}
+
+	return l.formatKey(key, "counter")
+}
+
+// timerKey returns the key holding the time at which key becomes available again
+func (l *RateLimiter) timerKey(key string) string {
+	if isLaravelCompatible(l.cache) {
+		return l.formatKey(cleanRateLimiterKey(key), "timer")
+	}
+
+	return l.formatKey(key, "timer")
+}
+
 // RetryAfter returns the duration that one should wait before executing the next call
 func (r *ThrottleResponse) RetryAfter() time.Duration {
 	return r.retryAfter
@@ -3,6 +3,9 @@ package gocache
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -15,7 +18,11 @@ const (
 	redisOk     = "OK"
 )
 
-var _ Cache = &RedisStore{}
+var (
+	_ Cache         = &RedisStore{}
+	_ tagReferences = &RedisStore{}
+	_ tagKeys       = &RedisStore{}
+)
 
 // NewRedisStore validates the passed in config and creates a Cache implementation of type *RedisStore
 func NewRedisStore(cnf *RedisConfig, encoder encoder.Encoder) (*RedisStore, error) {
@@ -24,7 +31,7 @@ func NewRedisStore(cnf *RedisConfig, encoder encoder.Encoder) (*RedisStore, erro
 	}
 	return &RedisStore{
 		prefix: prefix{
-			val: cnf.Prefix,
+			val: cnf.ConnectionPrefix + cnf.Prefix,
 		},
 		client: redis.NewClient(&redis.Options{
 			Network:         cnf.Network,
@@ -47,50 +54,69 @@ func NewRedisStore(cnf *RedisConfig, encoder encoder.Encoder) (*RedisStore, erro
 			ConnMaxIdleTime: cnf.ConnMaxIdleTime,
 			TLSConfig:       cnf.TLSConfig,
 		}),
-		encoder: encoder,
+		encoder:          encoder,
+		connectionPrefix: cnf.ConnectionPrefix,
+		laravel:          cnf.LaravelCompatible,
 	}, nil
 }
 
 // RedisStore is the representation of the redis caching store
 type RedisStore struct {
 	prefix
-	client  *redis.Client
-	encoder encoder.Encoder
+	client           *redis.Client
+	encoder          encoder.Encoder
+	connectionPrefix string
+	laravel          bool
 }
 
 // GetFloat64 gets a float64 value from the store
 func (s *RedisStore) GetFloat64(key string) (float64, error) {
-	res, err := s.get(key).Float64()
+	value, err := s.numeric(key)
+	if err != nil {
+		return 0, err
+	}
 
-	return res, checkErrNotFound(err)
+	return stringToFloat64(value)
 }
 
 // GetFloat32 gets a float32 value from the store
 func (s *RedisStore) GetFloat32(key string) (float32, error) {
-	res, err := s.get(key).Float32()
+	value, err := s.numeric(key)
+	if err != nil {
+		return 0, err
+	}
 
-	return res, checkErrNotFound(err)
+	return stringToFloat32(value)
 }
 
 // GetInt64 gets an int64 value from the store
 func (s *RedisStore) GetInt64(key string) (int64, error) {
-	res, err := s.get(key).Int64()
+	value, err := s.numeric(key)
+	if err != nil {
+		return 0, err
+	}
 
-	return res, checkErrNotFound(err)
+	return stringToInt64(value)
 }
 
 // GetInt gets an int value from the store
 func (s *RedisStore) GetInt(key string) (int, error) {
-	res, err := s.get(key).Int()
+	value, err := s.numeric(key)
+	if err != nil {
+		return 0, err
+	}
 
-	return res, checkErrNotFound(err)
+	return stringToInt(value)
 }
 
 // GetUint64 gets an uint64 value from the store
 func (s *RedisStore) GetUint64(key string) (uint64, error) {
-	res, err := s.get(key).Uint64()
+	value, err := s.numeric(key)
+	if err != nil {
+		return 0, err
+	}
 
-	return res, checkErrNotFound(err)
+	return stringToUint64(value)
 }
 
 // GetBool gets a bool value from the store
@@ -99,14 +125,8 @@ func (s *RedisStore) GetBool(key string) (bool, error) {
 	if err != nil {
 		return false, checkErrNotFound(err)
 	}
-	if isStringNumeric(value) || isStringBool(value) {
-		return stringToBool(value), nil
-	}
-	if err = s.encoder.Decode([]byte(value), &value); err != nil {
-		return false, err
-	}
 
-	return stringToBool(value), nil
+	return boolValue(s.encoder, value)
 }
 
 // GetString gets a string value from the store
@@ -115,33 +135,76 @@ func (s *RedisStore) GetString(key string) (string, error) {
 	if err != nil {
 		return "", checkErrNotFound(err)
 	}
-	if isStringNumeric(value) || isStringBool(value) {
-		return value, nil
-	}
-	if err = s.encoder.Decode([]byte(value), &value); err != nil {
+	res, err := stringValue(s.encoder, value)
+	if err != nil {
 		return "", err
 	}
 
+	reEncode(s.encoder, []byte(value), s.replacer(key))
+
+	return res, nil
+}
+
+// GetTime gets a time.Time value from the store
+func (s *RedisStore) GetTime(key string) (time.Time, error) {
+	value, err := s.get(key).Result()
+	if err != nil {
+		return time.Time{}, checkErrNotFound(err)
+	}
+
+	return timeValue(s.encoder, value)
+}
+
+// GetDuration gets a time.Duration value from the store
+func (s *RedisStore) GetDuration(key string) (time.Duration, error) {
+	value, err := s.get(key).Result()
+	if err != nil {
+		return 0, checkErrNotFound(err)
+	}
+
+	return durationValue(s.encoder, value)
+}
+
+// GetBytes gets the raw bytes stored for the given key bypassing the encoder
+func (s *RedisStore) GetBytes(key string) ([]byte, error) {
+	value, err := s.get(key).Bytes()
+	if err != nil {
+		return nil, checkErrNotFound(err)
+	}
+
 	return value, nil
 }
 
+// PutBytes puts raw bytes in the given store for a predetermined amount of time bypassing the encoder
+func (s *RedisStore) PutBytes(key string, value []byte, duration time.Duration) error {
+	return s.client.Set(context.TODO(), s.k(key), value, duration).Err()
+}
+
 // Increment increments an integer counter by a given value
 func (s *RedisStore) Increment(key string, value int64) (int64, error) {
+	if isEncrypted(s.encoder) {
+		return 0, ErrEncryptedCounter
+	}
+
 	return s.client.IncrBy(context.TODO(), s.k(key), value).Result()
 }
 
 // Decrement decrements an integer counter by a given value
 func (s *RedisStore) Decrement(key string, value int64) (int64, error) {
+	if isEncrypted(s.encoder) {
+		return 0, ErrEncryptedCounter
+	}
+
 	return s.client.DecrBy(context.TODO(), s.k(key), value).Result()
 }
 
 // Put puts a value in the given store for a predetermined amount of time in seconds
 func (s *RedisStore) Put(key string, value interface{}, duration time.Duration) error {
-	if isNumeric(value) || isBool(value) {
+	if s.raw(value) {
 		return s.client.Set(context.TODO(), s.k(key), value, duration).Err()
 	}
 
-	val, err := s.encoder.Encode(value)
+	val, err := encode(s.encoder, value)
 	if err != nil {
 		return err
 	}
@@ -152,7 +215,7 @@ func (s *RedisStore) Put(key string, value interface{}, duration time.Duration)
 // Add an item to the cache only if an item doesn't already exist for the given key, or if the existing item has
 // expired. If the record was successfully added true will be returned else false will be returned
 func (s *RedisStore) Add(key string, value interface{}, duration time.Duration) (bool, error) {
-	if isNumeric(value) || isBool(value) {
+	if s.raw(value) {
 		res, err := s.client.Eval(context.TODO(), redisLuaAddScript, []string{s.k(key)}, value, duration.Seconds()).Text()
 		if err != nil && !errors.Is(err, redis.Nil) {
 			return false, err
@@ -161,7 +224,7 @@ func (s *RedisStore) Add(key string, value interface{}, duration time.Duration)
 		return res == redisOk, nil
 	}
 
-	val, err := s.encoder.Encode(value)
+	val, err := encode(s.encoder, value)
 	if err != nil {
 		return false, err
 	}
@@ -174,9 +237,23 @@ func (s *RedisStore) Add(key string, value interface{}, duration time.Duration)
 	return res == redisOk, nil
 }
 
+// addForever implementation of the foreverAdder interface
+func (s *RedisStore) addForever(key string, value interface{}) (bool, error) {
+	if !s.raw(value) {
+		val, err := encode(s.encoder, value)
+		if err != nil {
+			return false, err
+		}
+
+		value = val
+	}
+
+	return s.client.SetNX(context.TODO(), s.k(key), value, 0).Result()
+}
+
 // Forever puts a value in the given store until it is forgotten/evicted
 func (s *RedisStore) Forever(key string, value interface{}) error {
-	if isNumeric(value) || isBool(value) {
+	if s.raw(value) {
 		if err := s.client.Set(context.TODO(), s.k(key), value, 0).Err(); err != nil {
 			return err
 		}
@@ -184,7 +261,7 @@ func (s *RedisStore) Forever(key string, value interface{}) error {
 		return s.client.Persist(context.TODO(), s.k(key)).Err()
 	}
 
-	val, err := s.encoder.Encode(value)
+	val, err := encode(s.encoder, value)
 	if err != nil {
 		return err
 	}
@@ -247,7 +324,7 @@ func (s *RedisStore) ForgetMany(keys ...string) error {
 func (s *RedisStore) PutMany(entries ...Entry) error {
 	if _, err := s.client.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
 		for _, entry := range entries {
-			if isNumeric(entry.Value) || isBool(entry.Value) {
+			if s.raw(entry.Value) {
 				if err := pipe.Set(context.TODO(), s.k(entry.Key), entry.Value, entry.Duration).Err(); err != nil {
 					return err
 				}
@@ -255,7 +332,7 @@ func (s *RedisStore) PutMany(entries ...Entry) error {
 				continue
 			}
 
-			val, err := s.encoder.Encode(entry.Value)
+			val, err := encode(s.encoder, entry.Value)
 			if err != nil {
 				return err
 			}
@@ -331,13 +408,14 @@ func (s *RedisStore) Many(keys ...string) (Items, error) {
 // Tags returns the taggedCache for the given store
 func (s *RedisStore) Tags(names ...string) TaggedCache {
 	return &redisTaggedCache{
-		taggedCache{
+		taggedCache: taggedCache{
 			store: s,
 			tags: &TagSet{
 				store: s,
 				names: names,
 			},
 		},
+		references: s,
 	}
 }
 
@@ -352,12 +430,21 @@ func (s *RedisStore) Get(key string, entity interface{}) error {
 	if err != nil {
 		return checkErrNotFound(err)
 	}
+	if err = decode(s.encoder, value, entity); err != nil {
+		return err
+	}
+
+	reEncode(s.encoder, value, s.replacer(key))
 
-	return s.encoder.Decode(value, entity)
+	return nil
 }
 
 // Lock returns a redis implementation of the Lock interface
 func (s *RedisStore) Lock(name, owner string, duration time.Duration) Lock {
+	if s.laravel {
+		name = s.k(name)
+	}
+
 	return newRedisLock(s.client, name, owner, duration)
 }
 
@@ -382,14 +469,312 @@ func (s *RedisStore) Expire(key string, duration time.Duration) error {
 	return nil
 }
 
-// Lpush runs the Redis lpush command (used via reflection, do not delete)
-func (s *RedisStore) Lpush(segment, key string) error {
-	return s.client.LPush(context.TODO(), segment, key).Err()
+// numeric returns the numeric representation of the value stored for key
+func (s *RedisStore) numeric(key string) (string, error) {
+	value, err := s.get(key).Result()
+	if err != nil {
+		return "", checkErrNotFound(err)
+	}
+
+	return numericString(s.encoder, value)
+}
+
+// replacer returns a function replacing the value of a key while keeping its expiration, as long as it still holds
+// the value that was read
+func (s *RedisStore) replacer(key string) func(current, upgraded []byte) error {
+	return func(current, upgraded []byte) error {
+		err := s.client.Eval(context.TODO(), redisLuaReplaceScript, []string{s.k(key)}, current, upgraded).Err()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+
+		return err
+	}
+}
+
+// PruneStaleTags removes the references to expired entries from the tag references, which otherwise only shrink
+// when their tags are flushed. It is meant to be run periodically and returns the number of removed references.
+// Laravel compatible stores do not record expirations, therefore nothing is pruned
+func (s *RedisStore) PruneStaleTags() (int64, error) {
+	if s.laravel {
+		return 0, nil
+	}
+
+	var (
+		pattern = escapeGlob(s.Prefix()) + tagReferencePrefix + "*" + referenceKeyEntries
+		now     = strconv.FormatInt(time.Now().Unix(), 10)
+		cursor  uint64
+		pruned  int64
+	)
+	for {
+		keys, next, err := s.client.ScanType(context.TODO(), cursor, pattern, deleteLimit, "zset").Result()
+		if err != nil {
+			return pruned, err
+		}
+		if len(keys) > 0 {
+			cmds, err := s.client.Pipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+				for _, key := range keys {
+					pipe.ZRemRangeByScore(context.TODO(), key, "0", now)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return pruned, err
+			}
+			for _, cmd := range cmds {
+				pruned += cmd.(*redis.IntCmd).Val()
+			}
+		}
+		if next == 0 {
+			return pruned, nil
+		}
+
+		cursor = next
+	}
+}
+
+// addTagReference implementation of the tagReferences interface. References are members of a ZSET scored by the
+// unix time at which the entry expires, -1 meaning forever, or members of a set for Laravel compatible stores
+func (s *RedisStore) addTagReference(reference, key string, duration time.Duration, onlyNew bool) error {
+	if s.laravel {
+		// Laravel records the keys without the connection prefix
+		return s.client.SAdd(context.TODO(), reference, strings.TrimPrefix(s.Prefix(), s.connectionPrefix)+key).Err()
+	}
+
+	var score float64 = -1
+	if duration > 0 {
+		score = float64(time.Now().Add(duration).Unix())
+	}
+
+	member := redis.Z{Score: score, Member: key}
+	if onlyNew {
+		return s.client.ZAddNX(context.TODO(), reference, member).Err()
+	}
+
+	return s.client.ZAdd(context.TODO(), reference, member).Err()
+}
+
+// flushTagReferences implementation of the tagReferences interface. The referenced keys are unlinked in batches
+func (s *RedisStore) flushTagReferences(reference string) error {
+	if s.laravel {
+		members, err := s.client.SMembers(context.TODO(), reference).Result()
+		if err != nil {
+			return err
+		}
+
+		for len(members) > 0 {
+			n := len(members)
+			if n > deleteLimit {
+				n = deleteLimit
+			}
+			if err = s.unlink(s.connectionPrefix, members[:n]); err != nil {
+				return err
+			}
+
+			members = members[n:]
+		}
+
+		return s.client.Unlink(context.TODO(), reference).Err()
+	}
+
+	// The ZSET is left untouched until its members have been unlinked, so windows over its ranks are stable
+	for start := int64(0); ; start += deleteLimit {
+		members, err := s.client.ZRange(context.TODO(), reference, start, start+deleteLimit-1).Result()
+		if err != nil {
+			return err
+		}
+		if err = s.unlink(s.Prefix(), members); err != nil {
+			return err
+		}
+		if len(members) < deleteLimit {
+			break
+		}
+	}
+
+	return s.client.Unlink(context.TODO(), reference).Err()
+}
+
+// scanTagKeys implementation of the tagKeys interface. References to expired entries are skipped. The cursor is
+// made of the position of the reference being scanned and of its ZSCAN or SSCAN cursor, hence keys might be
+// returned more than once
+func (s *RedisStore) scanTagKeys(id, prefix, cursor string, count int) ([]string, string, error) {
+	var (
+		references = s.tagReferenceKeys(id)
+		index      int
+		position   uint64
+	)
+	if len(cursor) > 0 {
+		i, p, found := strings.Cut(cursor, ":")
+		if !found {
+			return nil, "", fmt.Errorf("gocache: invalid tag keys cursor %q", cursor)
+		}
+
+		var err error
+		if index, err = strconv.Atoi(i); err != nil || index < 0 || index >= len(references) {
+			return nil, "", fmt.Errorf("gocache: invalid tag keys cursor %q", cursor)
+		}
+		if position, err = strconv.ParseUint(p, 10, 64); err != nil {
+			return nil, "", fmt.Errorf("gocache: invalid tag keys cursor %q", cursor)
+		}
+	}
+
+	var (
+		// Laravel records the keys without the connection prefix
+		memberPrefix = strings.TrimPrefix(s.Prefix(), s.connectionPrefix)
+		now          = float64(time.Now().Unix())
+		keys         []string
+	)
+	for {
+		var (
+			next uint64
+			err  error
+		)
+		if s.laravel {
+			var members []string
+			members, next, err = s.client.SScan(context.TODO(), references[index], position, escapeGlob(memberPrefix+prefix)+"*", int64(count)).Result()
+			if err != nil {
+				return nil, "", err
+			}
+			for _, member := range members {
+				keys = append(keys, strings.TrimPrefix(member, memberPrefix))
+			}
+		} else {
+			var pairs []string
+			pairs, next, err = s.client.ZScan(context.TODO(), references[index], position, escapeGlob(prefix)+"*", int64(count)).Result()
+			if err != nil {
+				return nil, "", err
+			}
+			for i := 0; i+1 < len(pairs); i += 2 {
+				score, err := strconv.ParseFloat(pairs[i+1], 64)
+				if err != nil {
+					return nil, "", err
+				}
+				if score < 0 || score > now {
+					keys = append(keys, pairs[i])
+				}
+			}
+		}
+		if next > 0 {
+			return keys, strconv.Itoa(index) + ":" + strconv.FormatUint(next, 10), nil
+		}
+		// Moving on to the following reference as long as the page is not full
+		if index++; index == len(references) {
+			return keys, "", nil
+		}
+		if len(keys) >= count {
+			return keys, strconv.Itoa(index) + ":0", nil
+		}
+
+		position = 0
+	}
+}
+
+// countTagKeys implementation of the tagKeys interface. References to expired entries are not counted
+func (s *RedisStore) countTagKeys(id string) (int64, error) {
+	var now = strconv.FormatInt(time.Now().Unix(), 10)
+
+	cmds, err := s.client.Pipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+		for _, reference := range s.tagReferenceKeys(id) {
+			if s.laravel {
+				pipe.SCard(context.TODO(), reference)
+
+				continue
+			}
+
+			pipe.ZCount(context.TODO(), reference, "-1", "-1")
+			pipe.ZCount(context.TODO(), reference, "("+now, "+inf")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, cmd := range cmds {
+		count += cmd.(*redis.IntCmd).Val()
+	}
+
+	return count, nil
+}
+
+// flushLegacyTagReferences implementation of the tagReferences interface. Releases prior to sorted set references
+// pushed the full keys of the tagged entries onto the prefix + "{tag id}:forever" and prefix + "{tag id}:standard"
+// lists, which are drained so that upgrading does not leave the entries they reference behind
+func (s *RedisStore) flushLegacyTagReferences(id string) error {
+	if s.laravel {
+		return nil
+	}
+
+	references := []string{s.Prefix() + id + legacyReferenceKeyForever, s.Prefix() + id + legacyReferenceKeyStandard}
+	for _, reference := range references {
+		for start := int64(0); ; start += deleteLimit {
+			members, err := s.client.LRange(context.TODO(), reference, start, start+deleteLimit-1).Result()
+			if err != nil {
+				return err
+			}
+			if err = s.unlink("", members); err != nil {
+				return err
+			}
+			if len(members) < deleteLimit {
+				break
+			}
+		}
+	}
+
+	return s.client.Unlink(context.TODO(), references...).Err()
+}
+
+// tagReferenceKeys implementation of the tagReferences interface. Laravel compatible stores split the references
+// of a tag between a forever and a standard set as Laravel does
+func (s *RedisStore) tagReferenceKeys(id string) []string {
+	if s.laravel {
+		return []string{s.Prefix() + id + referenceKeyForever, s.Prefix() + id + referenceKeyStandard}
+	}
+
+	return []string{s.Prefix() + tagReferencePrefix + id + referenceKeyEntries}
+}
+
+// escapeGlob escapes the glob special characters of the given string so that it is matched literally
+func escapeGlob(str string) string {
+	var b strings.Builder
+	for _, r := range str {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// unlink unlinks the given keys after prepending prefix to them
+func (s *RedisStore) unlink(prefix string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var prefixed = make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = prefix + key
+	}
+
+	return s.client.Unlink(context.TODO(), prefixed...).Err()
+}
+
+// laravelCompatible implementation of the laravelCompatible interface
+func (s *RedisStore) laravelCompatible() bool {
+	return s.laravel
 }
 
-// Lrange runs the Redis lrange command (used via reflection, do not delete)
-func (s *RedisStore) Lrange(key string, start, stop int64) []string {
-	return s.client.LRange(context.TODO(), key, start, stop).Val()
+// raw reports whether value is stored as is rather than encoded. Laravel serializes booleans and encrypted stores
+// encode every value
+func (s *RedisStore) raw(value interface{}) bool {
+	return storedAsIs(s.encoder, value) && (!isBool(value) || !s.laravel)
 }
 
 func (s *RedisStore) get(key string) *redis.StringCmd {
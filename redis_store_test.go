@@ -0,0 +1,162 @@
+package gocache
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+	"github.com/alejandro-carstens/gocache/gocachetest/testserver"
+)
+
+func TestRedisStore_TagReferences(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		addr       = testserver.RunRedis(t).Addr()
+		client     = redis.NewClient(&redis.Options{Addr: addr})
+		cache, err = NewRedisStore(&RedisConfig{Prefix: "go[cache]:", Addr: addr}, encoder.JSON{})
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+		require.NoError(t, cache.Close())
+	})
+
+	tagged := cache.Tags("people")
+	require.NoError(t, tagged.Put("jane", "jane", time.Minute))
+	require.NoError(t, tagged.Put("jane", "jane", time.Hour))
+	require.NoError(t, tagged.Forever("john", "john"))
+
+	_, err = tagged.Increment("count", 1)
+	require.NoError(t, err)
+
+	ids, err := tagged.TagSet().TagIds()
+	require.NoError(t, err)
+
+	var (
+		reference = cache.Prefix() + tagReferencePrefix + ids[0] + referenceKeyEntries
+		keys      = map[string]string{}
+	)
+	for _, key := range []string{"jane", "john", "count"} {
+		keys[key], err = tagged.(*redisTaggedCache).tagKey(key)
+		require.NoError(t, err)
+	}
+
+	members, err := client.ZRangeWithScores(ctx, reference, 0, -1).Result()
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+
+	scores := map[string]float64{}
+	for _, member := range members {
+		scores[member.Member.(string)] = member.Score
+	}
+	require.EqualValues(t, -1, scores[keys["john"]])
+	require.EqualValues(t, -1, scores[keys["count"]])
+	require.InDelta(t, time.Now().Add(time.Hour).Unix(), scores[keys["jane"]], 5)
+
+	// A reference to an entry that has already expired
+	require.NoError(t, client.ZAdd(ctx, reference, redis.Z{Score: float64(time.Now().Add(-time.Minute).Unix()), Member: "stale"}).Err())
+
+	pruned, err := cache.PruneStaleTags()
+	require.NoError(t, err)
+	require.EqualValues(t, 1, pruned)
+	require.EqualValues(t, 3, client.ZCard(ctx, reference).Val())
+
+	_, err = tagged.Flush()
+	require.NoError(t, err)
+
+	for key, tagKey := range keys {
+		exists, err := cache.Exists(tagKey)
+		require.NoError(t, err)
+		require.False(t, exists, key)
+	}
+	require.EqualValues(t, 0, client.Exists(ctx, reference).Val())
+}
+
+func TestRedisStore_LegacyTagReferences(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		addr       = testserver.RunRedis(t).Addr()
+		client     = redis.NewClient(&redis.Options{Addr: addr})
+		cache, err = NewRedisStore(&RedisConfig{Prefix: "go[cache]:", Addr: addr}, encoder.JSON{})
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+		require.NoError(t, cache.Close())
+	})
+
+	tagged := cache.Tags("people")
+	ids, err := tagged.TagSet().TagIds()
+	require.NoError(t, err)
+
+	// Entries written before upgrading, whose full keys were pushed onto the forever and standard lists
+	var legacy = map[string]string{"jane": ":forever", "john": ":standard"}
+	for key, suffix := range legacy {
+		tagKey, err := tagged.(*redisTaggedCache).tagKey(key)
+		require.NoError(t, err)
+		require.NoError(t, cache.Put(tagKey, key, time.Hour))
+		require.NoError(t, client.LPush(ctx, cache.Prefix()+ids[0]+suffix, cache.Prefix()+tagKey).Err())
+
+		legacy[key] = tagKey
+	}
+	require.NoError(t, tagged.Put("joe", "joe", time.Hour))
+
+	_, err = tagged.Flush()
+	require.NoError(t, err)
+
+	for key, tagKey := range legacy {
+		exists, err := cache.Exists(tagKey)
+		require.NoError(t, err)
+		require.False(t, exists, key)
+	}
+	require.EqualValues(t, 0, client.Exists(ctx, cache.Prefix()+ids[0]+":forever", cache.Prefix()+ids[0]+":standard").Val())
+}
+
+func TestRedisStore_GetStreamShrinks(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		addr       = testserver.RunRedis(t).Addr()
+		client     = redis.NewClient(&redis.Options{Addr: addr})
+		cache, err = NewRedisStore(&RedisConfig{Prefix: "go[cache]:", Addr: addr}, encoder.JSON{})
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+		require.NoError(t, cache.Close())
+	})
+
+	value := make([]byte, 2*streamBufferSize)
+	_, err = rand.Read(value)
+	require.NoError(t, err)
+	require.NoError(t, cache.PutStream("bytes", bytes.NewReader(value), time.Minute))
+
+	var (
+		buf     bytes.Buffer
+		deleted bool
+	)
+	err = cache.GetStream("bytes", writerFunc(func(p []byte) (int, error) {
+		if !deleted {
+			deleted = true
+			require.NoError(t, client.Del(ctx, cache.Prefix()+"bytes").Err())
+		}
+
+		return buf.Write(p)
+	}))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Less(t, buf.Len(), len(value))
+}
+
+// writerFunc adapts a function to the io.Writer interface
+type writerFunc func(p []byte) (int, error)
+
+// Write implementation of the io.Writer interface
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,168 @@
+package gocache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisStreamTempTTL bounds the lifetime of the temporary key a stream is appended to, so that it does not leak
+// if the writer dies before committing it
+const redisStreamTempTTL = time.Hour
+
+var _ Streamer = &RedisStore{}
+
+// PutStream implementation of the Streamer interface. The stream is appended in pieces to a temporary key which
+// is renamed to key once fully written, so that readers never see a partial value
+func (s *RedisStore) PutStream(key string, r io.Reader, duration time.Duration) error {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+
+	w := &redisStreamWriter{
+		client:   s.client,
+		key:      s.k(key),
+		tmp:      s.k(key) + ":stream:" + hex.EncodeToString(suffix),
+		duration: duration,
+	}
+	w.chunkWriter = chunkWriter{
+		size:  streamBufferSize,
+		flush: w.append,
+	}
+
+	return writeStream(s.encoder, w, r)
+}
+
+// GetStream implementation of the Streamer interface. The value is read in pieces via GETRANGE, therefore a value
+// replaced while being read may be written to w partially mixed with its replacement. A value that expires or
+// shrinks while being read results in io.ErrUnexpectedEOF
+func (s *RedisStore) GetStream(key string, w io.Writer) error {
+	var (
+		exists *redis.IntCmd
+		length *redis.IntCmd
+	)
+	if _, err := s.client.Pipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+		exists = pipe.Exists(context.TODO(), s.k(key))
+		length = pipe.StrLen(context.TODO(), s.k(key))
+
+		return nil
+	}); err != nil {
+		return err
+	}
+	if exists.Val() == 0 {
+		return ErrNotFound
+	}
+
+	return readStream(&redisStreamReader{
+		client: s.client,
+		key:    s.k(key),
+		length: length.Val(),
+	}, w)
+}
+
+// redisStreamWriter appends a stream to a temporary key and renames it once committed. Streams that fit in a
+// single piece are stored via SET right away
+type redisStreamWriter struct {
+	chunkWriter
+	client   *redis.Client
+	key      string
+	tmp      string
+	duration time.Duration
+	appended bool
+}
+
+func (w *redisStreamWriter) append(chunk []byte) error {
+	if _, err := w.client.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+		pipe.Append(context.TODO(), w.tmp, string(chunk))
+		if !w.appended {
+			pipe.Expire(context.TODO(), w.tmp, redisStreamTempTTL)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	w.appended = true
+
+	return nil
+}
+
+func (w *redisStreamWriter) commit() error {
+	var duration time.Duration
+	if w.duration > 0 {
+		duration = w.duration
+	}
+	if !w.appended {
+		return w.client.Set(context.TODO(), w.key, w.buf, duration).Err()
+	}
+	if err := w.append(w.buf); err != nil {
+		w.abort()
+
+		return err
+	}
+
+	_, err := w.client.TxPipelined(context.TODO(), func(pipe redis.Pipeliner) error {
+		pipe.Rename(context.TODO(), w.tmp, w.key)
+		if duration > 0 {
+			pipe.PExpire(context.TODO(), w.key, duration)
+		} else {
+			pipe.Persist(context.TODO(), w.key)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+func (w *redisStreamWriter) abort() {
+	if w.appended {
+		_ = w.client.Del(context.TODO(), w.tmp).Err()
+	}
+}
+
+// redisStreamReader reads a value of a known length in pieces via GETRANGE
+type redisStreamReader struct {
+	client *redis.Client
+	key    string
+	length int64
+	offset int64
+	buf    []byte
+}
+
+// Read implementation of the io.Reader interface
+func (r *redisStreamReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.offset >= r.length {
+			return 0, io.EOF
+		}
+
+		size := r.length - r.offset
+		if size > streamBufferSize {
+			size = streamBufferSize
+		}
+
+		value, err := r.client.GetRange(context.TODO(), r.key, r.offset, r.offset+size-1).Bytes()
+		if err != nil {
+			return 0, err
+		}
+		if int64(len(value)) < size {
+			return 0, fmt.Errorf("gocache: %s expired or shrank while being read: %w", r.key, io.ErrUnexpectedEOF)
+		}
+
+		r.offset += size
+		r.buf = value
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
@@ -1,32 +1,48 @@
 package gocache
 
 import (
-	"crypto/sha1"
-	"encoding/hex"
-	"errors"
-	"reflect"
-	"strings"
 	"time"
 )
 
 const (
-	referenceKeyForever  = ":forever"
-	referenceKeyStandard = ":standard"
+	referenceKeyEntries        = ":entries"
+	referenceKeyForever        = ":forever_ref"
+	referenceKeyStandard       = ":standard_ref"
+	legacyReferenceKeyForever  = ":forever"
+	legacyReferenceKeyStandard = ":standard"
+	tagReferencePrefix         = "tag:"
 )
 
 var _ TaggedCache = &redisTaggedCache{}
 
-// redisTaggedCache is the representation of the redis tagged cache store
-type redisTaggedCache struct {
-	taggedCache
-}
+type (
+	// tagReferences is implemented by stores recording the keys written under each tag id in a reference
+	// structure, so that flushing a tag deletes the entries it references
+	tagReferences interface {
+		// addTagReference records the given namespaced key in the given reference. The duration is the one the
+		// entry is written with and onlyNew leaves an already recorded key untouched
+		addTagReference(reference, key string, duration time.Duration, onlyNew bool) error
+		// flushTagReferences deletes the keys recorded in the given reference along with the reference itself
+		flushTagReferences(reference string) error
+		// tagReferenceKeys returns the keys of every reference of the given tag id
+		tagReferenceKeys(id string) []string
+		// flushLegacyTagReferences deletes the keys recorded under the given tag id by releases that kept tag
+		// references in lists along with the lists themselves
+		flushLegacyTagReferences(id string) error
+	}
+	// redisTaggedCache is the representation of the redis tagged cache store
+	redisTaggedCache struct {
+		taggedCache
+		references tagReferences
+	}
+)
 
 // Put implementation of the TaggedCache interface
 func (tc *redisTaggedCache) Put(key string, value interface{}, duration time.Duration) error {
 	if duration == 0 {
 		return tc.Forever(key, value)
 	}
-	if err := tc.pushKeys(key, referenceKeyStandard); err != nil {
+	if err := tc.addReferences(key, duration, false); err != nil {
 		return err
 	}
 
@@ -36,39 +52,41 @@ func (tc *redisTaggedCache) Put(key string, value interface{}, duration time.Dur
 // Add an item to the cache only if an item doesn't already exist for the given key, or if the existing item has
 // expired. If the record was successfully added true will be returned else false will be returned
 func (tc *redisTaggedCache) Add(key string, value interface{}, duration time.Duration) (bool, error) {
-	if err := tc.pushKeys(key, referenceKeyStandard); err != nil {
+	if err := tc.addReferences(key, duration, false); err != nil {
 		return false, err
 	}
 
 	return tc.taggedCache.Add(key, value, duration)
 }
 
+// PutBytes implementation of the TaggedCache interface
+func (tc *redisTaggedCache) PutBytes(key string, value []byte, duration time.Duration) error {
+	if err := tc.addReferences(key, duration, false); err != nil {
+		return err
+	}
+
+	return tc.taggedCache.PutBytes(key, value, duration)
+}
+
 // PutMany implementation of the TaggedCache interface
 func (tc *redisTaggedCache) PutMany(entries ...Entry) error {
-	for i, entry := range entries {
-		reference := referenceKeyStandard
-		if entry.Duration == 0 {
-			reference = referenceKeyForever
-		}
-		if err := tc.pushKeys(entry.Key, reference); err != nil {
-			return err
-		}
+	ids, namespace, err := tc.resolve()
+	if err != nil {
+		return err
+	}
 
-		key, err := tc.tagKey(entry.Key)
-		if err != nil {
+	for _, entry := range entries {
+		if err = tc.reference(ids, tc.namespacedKey(namespace, entry.Key), entry.Duration, false); err != nil {
 			return err
 		}
-
-		entry.Key = key
-		entries[i] = entry
 	}
 
-	return tc.store.PutMany(entries...)
+	return tc.putMany(namespace, entries)
 }
 
 // Forever implementation of the TaggedCache interface
 func (tc *redisTaggedCache) Forever(key string, value interface{}) error {
-	if err := tc.pushKeys(key, referenceKeyForever); err != nil {
+	if err := tc.addReferences(key, 0, false); err != nil {
 		return err
 	}
 
@@ -77,7 +95,7 @@ func (tc *redisTaggedCache) Forever(key string, value interface{}) error {
 
 // Increment implementation of the TaggedCache interface
 func (tc *redisTaggedCache) Increment(key string, value int64) (int64, error) {
-	if err := tc.pushKeys(key, referenceKeyForever); err != nil {
+	if err := tc.addReferences(key, 0, true); err != nil {
 		return 0, err
 	}
 
@@ -86,101 +104,49 @@ func (tc *redisTaggedCache) Increment(key string, value int64) (int64, error) {
 
 // Decrement implementation of the TaggedCache interface
 func (tc *redisTaggedCache) Decrement(key string, value int64) (int64, error) {
-	if err := tc.pushKeys(key, referenceKeyForever); err != nil {
+	if err := tc.addReferences(key, 0, true); err != nil {
 		return 0, err
 	}
 
 	return tc.taggedCache.Decrement(key, value)
 }
 
-// Flush flushes all the given tags' associated records. Note that for Redis all forever keys associated with
-// the tags will also be deleted. Standard or expiring keys will be left alone until they expire
+// Flush deletes every entry referenced by the given tags, expiring ones included, before rotating the tag ids
 func (tc *redisTaggedCache) Flush() (bool, error) {
-	if err := tc.deleteForeverKeys(); err != nil {
-		return false, err
-	}
-	if err := tc.deleteStandardKeys(); err != nil {
-		return false, err
-	}
-
-	return tc.taggedCache.Flush()
-}
-
-func (tc *redisTaggedCache) pushKeys(key, reference string) error {
-	namespace, err := tc.tags.namespace()
+	ids, err := tc.tags.TagIds()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	h := sha1.New()
-	h.Write([]byte(namespace))
-
-	fullKey := tc.Prefix() + hex.EncodeToString(h.Sum(nil)) + ":" + key
-	for _, segment := range strings.Split(namespace, "|") {
-		var (
-			inputs = []reflect.Value{reflect.ValueOf(tc.referenceKey(segment, reference)), reflect.ValueOf(fullKey)}
-			res    = reflect.ValueOf(tc.store).MethodByName("Lpush").Call(inputs)
-		)
-		for _, r := range res {
-			if !r.IsNil() {
-				return errors.New(r.String())
+	for _, id := range ids {
+		if err = tc.references.flushLegacyTagReferences(id); err != nil {
+			return false, err
+		}
+		for _, reference := range tc.references.tagReferenceKeys(id) {
+			if err = tc.references.flushTagReferences(reference); err != nil {
+				return false, err
 			}
 		}
 	}
 
-	return nil
-}
-
-func (tc *redisTaggedCache) deleteStandardKeys() error {
-	return tc.deleteKeysByReference(referenceKeyStandard)
-}
-
-func (tc *redisTaggedCache) deleteForeverKeys() error {
-	return tc.deleteKeysByReference(referenceKeyForever)
+	return tc.taggedCache.Flush()
 }
 
-func (tc *redisTaggedCache) deleteKeysByReference(reference string) error {
-	namespace, err := tc.tags.namespace()
+// addReferences records the given key in the reference of every tag id. Counters keep the expiration of the entry
+// they increment, hence they do not overwrite an already recorded reference
+func (tc *redisTaggedCache) addReferences(key string, duration time.Duration, counter bool) error {
+	ids, namespace, err := tc.resolve()
 	if err != nil {
 		return err
 	}
 
-	for _, segment := range strings.Split(namespace, "|") {
-		key := tc.referenceKey(segment, reference)
-		if err = tc.deleteValues(key); err != nil {
-			return err
-		}
-		if _, err = tc.store.Forget(segment); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return tc.reference(ids, tc.namespacedKey(namespace, key), duration, counter)
 }
 
-func (tc *redisTaggedCache) deleteValues(key string) error {
-	var (
-		inputs = []reflect.Value{reflect.ValueOf(key), reflect.ValueOf(int64(0)), reflect.ValueOf(int64(-1))}
-		keys   = reflect.ValueOf(tc.store).MethodByName("Lrange").Call(inputs)
-	)
-	if len(keys) == 0 {
-		return nil
-	}
-
-	for _, k := range keys {
-		if k.Len() == 0 {
-			continue
-		}
-
-		delKeys := make([]string, k.Len())
-		for i := 0; i < k.Len(); i++ {
-			delKeys[i] = k.Index(i).String()
-		}
-
-		if len(delKeys) == 0 {
-			continue
-		}
-		if err := tc.store.ForgetMany(delKeys...); err != nil {
+// reference records the given tagged key in the reference of each one of the given tag ids
+func (tc *redisTaggedCache) reference(ids []string, member string, duration time.Duration, counter bool) error {
+	for _, id := range ids {
+		if err := tc.references.addTagReference(tc.referenceKey(id, duration, counter), member, duration, counter); err != nil {
 			return err
 		}
 	}
@@ -188,6 +154,13 @@ func (tc *redisTaggedCache) deleteValues(key string) error {
 	return nil
 }
 
-func (tc *redisTaggedCache) referenceKey(segment, suffix string) string {
-	return tc.Prefix() + segment + suffix
+// referenceKey returns the key of the reference recording the entries written under the given tag id. Laravel
+// compatible stores record the entries that expire, counters included, in their standard reference
+func (tc *redisTaggedCache) referenceKey(id string, duration time.Duration, counter bool) string {
+	references := tc.references.tagReferenceKeys(id)
+	if len(references) > 1 && (counter || duration > 0) {
+		return references[1]
+	}
+
+	return references[0]
 }
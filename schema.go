@@ -0,0 +1,111 @@
+package gocache
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+)
+
+// schemas holds the registered schemas keyed by their reflect.Type
+var schemas sync.Map
+
+type (
+	// Migration upgrades the decoded representation of a cached value from the From schema version to the next one
+	Migration struct {
+		From uint32
+		Up   func(value map[string]interface{}) error
+	}
+	schema struct {
+		version    uint32
+		migrations map[uint32]Migration
+	}
+)
+
+// RegisterSchema registers the current schema version of T. Values of type T written via an encoder.Envelope store
+// their schema version, and retrieving a value written with an older version via Get runs the registered migrations
+// from its version up to the current one. Values for which no migration path exists, as well as values written by a
+// newer version or before the schema was registered, are treated as cache misses (ErrNotFound). Migrations operate
+// on the map representation of the value, therefore the inner encoder needs to be able to decode T as a map, which
+// is the case for JSON, Msgpack and CBOR but not for Gob
+func RegisterSchema[T any](version uint32, migrations ...Migration) {
+	var s = &schema{
+		version:    version,
+		migrations: make(map[uint32]Migration, len(migrations)),
+	}
+	for _, migration := range migrations {
+		s.migrations[migration.From] = migration
+	}
+
+	schemas.Store(reflect.TypeOf((*T)(nil)).Elem(), s)
+}
+
+// schemaFor returns the schema registered for the type of value, pointers being dereferenced
+func schemaFor(value interface{}) (*schema, bool) {
+	t := reflect.TypeOf(value)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return nil, false
+	}
+
+	s, exists := schemas.Load(t)
+	if !exists {
+		return nil, false
+	}
+
+	return s.(*schema), true
+}
+
+// encode encodes value via enc storing its schema version when enc is an encoder.Envelope
+func encode(enc encoder.Encoder, value interface{}) ([]byte, error) {
+	if envelope, valid := enc.(encoder.Envelope); valid {
+		if s, exists := schemaFor(value); exists {
+			return envelope.EncodeVersion(value, s.version)
+		}
+	}
+
+	return enc.Encode(value)
+}
+
+// decode decodes data into entity via enc migrating it to the current schema version of entity if needed
+func decode(enc encoder.Encoder, data []byte, entity interface{}) error {
+	s, exists := schemaFor(entity)
+	if !exists {
+		return enc.Decode(data, entity)
+	}
+	if _, valid := enc.(encoder.Envelope); !valid {
+		return enc.Decode(data, entity)
+	}
+
+	header, _, enveloped := encoder.OpenEnvelope(data)
+	if !enveloped || header.Flags&encoder.FlagSchemaVersion == 0 || header.SchemaVersion > s.version {
+		return ErrNotFound
+	}
+	if header.SchemaVersion == s.version {
+		return enc.Decode(data, entity)
+	}
+
+	var value map[string]interface{}
+	if err := enc.Decode(data, &value); err != nil {
+		return err
+	}
+
+	for version := header.SchemaVersion; version < s.version; version++ {
+		migration, exists := s.migrations[version]
+		if !exists {
+			return ErrNotFound
+		}
+		if err := migration.Up(value); err != nil {
+			return err
+		}
+	}
+
+	migrated, err := enc.Encode(value)
+	if err != nil {
+		return err
+	}
+
+	return enc.Decode(migrated, entity)
+}
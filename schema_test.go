@@ -0,0 +1,94 @@
+package gocache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+)
+
+type (
+	movieV1 struct {
+		Title string
+	}
+	movieV2 struct {
+		Name string
+	}
+	movie struct {
+		Name   string
+		Rating int
+	}
+	unversionedMovie struct {
+		Name string
+	}
+)
+
+func TestRegisterSchema(t *testing.T) {
+	RegisterSchema[movieV1](1)
+	RegisterSchema[movieV2](2)
+	RegisterSchema[movie](3,
+		Migration{From: 1, Up: func(value map[string]interface{}) error {
+			value["Name"] = value["Title"]
+			delete(value, "Title")
+
+			return nil
+		}},
+		Migration{From: 2, Up: func(value map[string]interface{}) error {
+			if value["Name"] == "" {
+				return errors.New("missing name")
+			}
+
+			value["Rating"] = 5
+
+			return nil
+		}},
+	)
+
+	for _, d := range drivers(t) {
+		t.Run(d.string(), func(t *testing.T) {
+			cache := createStore(t, d, encoder.Envelope{Inner: encoder.JSON{}})
+			require.NoError(t, cache.Put("v1", movieV1{Title: "Senna"}, time.Minute))
+			require.NoError(t, cache.Put("v2", &movieV2{Name: "Rush"}, time.Minute))
+			require.NoError(t, cache.Put("v3", movie{Name: "Le Mans", Rating: 4}, time.Minute))
+			require.NoError(t, cache.Put("invalid", movieV2{}, time.Minute))
+			require.NoError(t, cache.Put("unversioned", unversionedMovie{Name: "Grand Prix"}, time.Minute))
+
+			for key, expected := range map[string]movie{
+				"v1": {Name: "Senna", Rating: 5},
+				"v2": {Name: "Rush", Rating: 5},
+				"v3": {Name: "Le Mans", Rating: 4},
+			} {
+				var m movie
+				require.NoError(t, cache.Get(key, &m), key)
+				require.Equal(t, expected, m, key)
+			}
+
+			var m movie
+			require.EqualError(t, cache.Get("invalid", &m), "missing name")
+			require.ErrorIs(t, cache.Get("unversioned", &m), ErrNotFound)
+
+			// Values written by a newer version are treated as misses as well
+			var old movieV2
+			require.ErrorIs(t, cache.Get("v3", &old), ErrNotFound)
+
+			items, err := cache.Many("v1")
+			require.NoError(t, err)
+			require.NoError(t, items["v1"].Unmarshal(&m))
+			require.Equal(t, movie{Name: "Senna", Rating: 5}, m)
+		})
+	}
+}
+
+func TestRegisterSchema_WithoutEnvelope(t *testing.T) {
+	RegisterSchema[movie](3)
+
+	cache := createStore(t, localDriver, encoder.JSON{})
+	require.NoError(t, cache.Put("movie", movie{Name: "Senna"}, time.Minute))
+
+	var m movie
+	require.NoError(t, cache.Get("movie", &m))
+	require.Equal(t, "Senna", m.Name)
+}
@@ -0,0 +1,394 @@
+package gocache
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Cache = &ShardedStore{}
+
+// Shard represents a named Cache backend that takes part in a ShardedStore
+type Shard struct {
+	// Name identifies the shard on the hash ring. It should remain stable across deployments
+	// since renaming a shard remaps all of its keys
+	Name string
+	// Weight is the relative amount of keys the shard should own. Defaults to 1
+	Weight int
+	// Cache is the backend where the shard's keys are stored
+	Cache Cache
+}
+
+// NewShardedStore creates a Cache implementation of type *ShardedStore that distributes keys amongst the given
+// shards via a consistent hash ring
+func NewShardedStore(shards ...Shard) (*ShardedStore, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("at least one shard needs to be specified")
+	}
+
+	s := &ShardedStore{
+		shards: map[string]Shard{},
+	}
+	for _, shard := range shards {
+		if err := s.validateShard(shard); err != nil {
+			return nil, err
+		}
+		if _, exists := s.shards[shard.Name]; exists {
+			return nil, errors.New("shard " + shard.Name + " already exists")
+		}
+
+		s.shards[shard.Name] = shard
+	}
+
+	s.rebuild()
+
+	return s, nil
+}
+
+// ShardedStore is a Cache implementation that routes every key to one of several independent Cache backends
+// using a ketama consistent hash ring. If a key contains a {hash tag} only the portion in between the curly
+// braces is hashed, which allows for related keys to be stored on the same shard
+type ShardedStore struct {
+	mu     sync.RWMutex
+	shards map[string]Shard
+	ring   *hashRing
+}
+
+// shardGroup holds the keys or entries of a batch call that belong to the same shard
+type shardGroup struct {
+	cache   Cache
+	keys    []string
+	entries []Entry
+}
+
+// AddShard adds a shard to the ring. Only the keys that now hash to the new shard will be remapped
+func (s *ShardedStore) AddShard(shard Shard) error {
+	if err := s.validateShard(shard); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.shards[shard.Name]; exists {
+		return errors.New("shard " + shard.Name + " already exists")
+	}
+
+	s.shards[shard.Name] = shard
+	s.rebuild()
+
+	return nil
+}
+
+// RemoveShard removes a shard from the ring. Only the keys that belonged to the removed shard will be remapped.
+// The shard's Cache is not closed
+func (s *ShardedStore) RemoveShard(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.shards[name]; !exists {
+		return errors.New("shard " + name + " does not exist")
+	}
+	if len(s.shards) == 1 {
+		return errors.New("cannot remove the last shard")
+	}
+
+	delete(s.shards, name)
+	s.rebuild()
+
+	return nil
+}
+
+// Shard returns the Cache that owns the given key
+func (s *ShardedStore) Shard(key string) Cache {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.shards[s.shardName(key)].Cache
+}
+
+// GetString gets a string value from the store
+func (s *ShardedStore) GetString(key string) (string, error) {
+	return s.Shard(key).GetString(key)
+}
+
+// GetFloat64 gets a float64 value from the store
+func (s *ShardedStore) GetFloat64(key string) (float64, error) {
+	return s.Shard(key).GetFloat64(key)
+}
+
+// GetFloat32 gets a float32 value from the store
+func (s *ShardedStore) GetFloat32(key string) (float32, error) {
+	return s.Shard(key).GetFloat32(key)
+}
+
+// GetInt64 gets an int64 value from the store
+func (s *ShardedStore) GetInt64(key string) (int64, error) {
+	return s.Shard(key).GetInt64(key)
+}
+
+// GetInt gets an int value from the store
+func (s *ShardedStore) GetInt(key string) (int, error) {
+	return s.Shard(key).GetInt(key)
+}
+
+// GetUint64 gets an uint64 value from the store
+func (s *ShardedStore) GetUint64(key string) (uint64, error) {
+	return s.Shard(key).GetUint64(key)
+}
+
+// GetBool gets a bool value from the store
+func (s *ShardedStore) GetBool(key string) (bool, error) {
+	return s.Shard(key).GetBool(key)
+}
+
+// GetTime gets a time.Time value from the store
+func (s *ShardedStore) GetTime(key string) (time.Time, error) {
+	return s.Shard(key).GetTime(key)
+}
+
+// GetDuration gets a time.Duration value from the store
+func (s *ShardedStore) GetDuration(key string) (time.Duration, error) {
+	return s.Shard(key).GetDuration(key)
+}
+
+// GetBytes gets the raw bytes stored for the given key bypassing the encoder
+func (s *ShardedStore) GetBytes(key string) ([]byte, error) {
+	return s.Shard(key).GetBytes(key)
+}
+
+// PutBytes puts raw bytes in the given store for a predetermined amount of time bypassing the encoder
+func (s *ShardedStore) PutBytes(key string, value []byte, duration time.Duration) error {
+	return s.Shard(key).PutBytes(key, value, duration)
+}
+
+// Get gets the struct representation of a value from the store
+func (s *ShardedStore) Get(key string, entity interface{}) error {
+	return s.Shard(key).Get(key, entity)
+}
+
+// Put puts a value in the given store for a predetermined amount of time in seconds
+func (s *ShardedStore) Put(key string, value interface{}, duration time.Duration) error {
+	return s.Shard(key).Put(key, value, duration)
+}
+
+// Add an item to the cache only if an item doesn't already exist for the given key, or if the existing item has
+// expired. If the record was successfully added true will be returned else false will be returned
+func (s *ShardedStore) Add(key string, value interface{}, duration time.Duration) (bool, error) {
+	return s.Shard(key).Add(key, value, duration)
+}
+
+// addForever implementation of the foreverAdder interface
+func (s *ShardedStore) addForever(key string, value interface{}) (bool, error) {
+	return addForever(s.Shard(key), key, value)
+}
+
+// Forever puts a value in the given store until it is forgotten/evicted
+func (s *ShardedStore) Forever(key string, value interface{}) error {
+	return s.Shard(key).Forever(key, value)
+}
+
+// Increment increments an integer counter by a given value
+func (s *ShardedStore) Increment(key string, value int64) (int64, error) {
+	return s.Shard(key).Increment(key, value)
+}
+
+// Decrement decrements an integer counter by a given value
+func (s *ShardedStore) Decrement(key string, value int64) (int64, error) {
+	return s.Shard(key).Decrement(key, value)
+}
+
+// Forget forgets/evicts a given key-value pair from the store
+func (s *ShardedStore) Forget(key string) (bool, error) {
+	return s.Shard(key).Forget(key)
+}
+
+// ForgetMany forgets/evicts a set of given key-value pair from the store. Keys are grouped by shard so that
+// every shard is only called once
+func (s *ShardedStore) ForgetMany(keys ...string) error {
+	for _, group := range s.groupKeys(keys) {
+		if err := group.cache.ForgetMany(group.keys...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PutMany puts many values in the given store until they are forgotten/evicted. Entries are grouped by shard
+// so that every shard is only called once
+func (s *ShardedStore) PutMany(entries ...Entry) error {
+	var groups = map[string]*shardGroup{}
+
+	s.mu.RLock()
+	for _, entry := range entries {
+		group := s.group(groups, entry.Key)
+		group.entries = append(group.entries, entry)
+	}
+	s.mu.RUnlock()
+
+	for _, group := range groups {
+		if err := group.cache.PutMany(group.entries...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Many gets many values from the store. Keys are grouped by shard so that every shard is only called once
+func (s *ShardedStore) Many(keys ...string) (Items, error) {
+	items := Items{}
+	for _, group := range s.groupKeys(keys) {
+		results, err := group.cache.Many(group.keys...)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, item := range results {
+			items[key] = item
+		}
+	}
+
+	return items, nil
+}
+
+// Flush flushes every shard
+func (s *ShardedStore) Flush() (bool, error) {
+	for _, shard := range s.all() {
+		if _, err := shard.Flush(); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// Close closes every shard releasing all open resources
+func (s *ShardedStore) Close() error {
+	var errs []string
+	for _, shard := range s.all() {
+		if err := shard.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("gocache: " + strings.Join(errs, ": "))
+	}
+
+	return nil
+}
+
+// Exists checks if an entry exists in the cache for the given key
+func (s *ShardedStore) Exists(key string) (bool, error) {
+	return s.Shard(key).Exists(key)
+}
+
+// Expire allows for overriding the expiry time for a given key
+func (s *ShardedStore) Expire(key string, duration time.Duration) error {
+	return s.Shard(key).Expire(key, duration)
+}
+
+// Prefix returns an empty string given that every shard applies its own prefix
+func (*ShardedStore) Prefix() string {
+	return ""
+}
+
+// Tags returns the taggedCache for the given store. Tag ids and tagged entries are routed to their shards
+// like any other key. Given that the entries of a tag are spread across shards, flushing tags only rotates their ids
+// without deleting the underlying entries, and Keys as well as TagSet.Counts return ErrNotImplemented
+func (s *ShardedStore) Tags(names ...string) TaggedCache {
+	return &taggedCache{
+		store: s,
+		tags: &TagSet{
+			store: s,
+			names: names,
+		},
+	}
+}
+
+// Lock returns the Lock implementation of the shard that owns the lock name
+func (s *ShardedStore) Lock(name, owner string, duration time.Duration) Lock {
+	return s.Shard(name).Lock(name, owner, duration)
+}
+
+// groupKeys groups the given keys by shard, resolving every key and its shard's Cache under a single lock so that
+// a concurrent RemoveShard cannot leave a key without a Cache
+func (s *ShardedStore) groupKeys(keys []string) map[string]*shardGroup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groups := map[string]*shardGroup{}
+	for _, key := range keys {
+		group := s.group(groups, key)
+		group.keys = append(group.keys, key)
+	}
+
+	return groups
+}
+
+// group returns the group of the shard that owns the given key, creating it if needed. The caller must hold the lock
+func (s *ShardedStore) group(groups map[string]*shardGroup, key string) *shardGroup {
+	name := s.shardName(key)
+	group, exists := groups[name]
+	if !exists {
+		group = &shardGroup{cache: s.shards[name].Cache}
+		groups[name] = group
+	}
+
+	return group
+}
+
+func (s *ShardedStore) all() []Cache {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	caches := make([]Cache, 0, len(s.shards))
+	for _, shard := range s.shards {
+		caches = append(caches, shard.Cache)
+	}
+
+	return caches
+}
+
+func (s *ShardedStore) shardName(key string) string {
+	name, _ := s.ring.get(hashTag(key))
+
+	return name
+}
+
+func (s *ShardedStore) rebuild() {
+	nodes := make(map[string]int, len(s.shards))
+	for name, shard := range s.shards {
+		nodes[name] = shard.Weight
+	}
+
+	s.ring = newHashRing(nodes, ketamaPointKey)
+}
+
+func (*ShardedStore) validateShard(shard Shard) error {
+	if len(shard.Name) == 0 {
+		return errors.New("a shard name needs to be specified")
+	}
+	if shard.Cache == nil {
+		return errors.New("shard " + shard.Name + " needs a cache")
+	}
+
+	return nil
+}
+
+// hashTag returns the portion of the key enclosed in the first pair of curly braces if any
+func hashTag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+
+	return key[start+1 : start+1+end]
+}
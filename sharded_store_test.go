@@ -0,0 +1,166 @@
+package gocache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+)
+
+func TestHashRing_Remap(t *testing.T) {
+	var (
+		before = newHashRing(map[string]int{"a": 1, "b": 1, "c": 1}, ketamaPointKey)
+		after  = newHashRing(map[string]int{"a": 1, "b": 1, "c": 1, "d": 1}, ketamaPointKey)
+		moved  int
+		total  = 10000
+	)
+	for i := 0; i < total; i++ {
+		key := "key:" + strconv.Itoa(i)
+
+		from, ok := before.get(key)
+		require.True(t, ok)
+
+		to, ok := after.get(key)
+		require.True(t, ok)
+
+		if from == to {
+			continue
+		}
+		// Keys can only move to the newly added node
+		require.Equal(t, "d", to)
+		moved++
+	}
+	// Roughly a quarter of the keys should move, we leave plenty of room for variance
+	require.Less(t, moved, total/2)
+	require.Greater(t, moved, total/10)
+}
+
+func TestHashRing_Weights(t *testing.T) {
+	var (
+		ring   = newHashRing(map[string]int{"light": 1, "heavy": 3}, ketamaPointKey)
+		counts = map[string]int{}
+	)
+	for i := 0; i < 10000; i++ {
+		node, ok := ring.get("key:" + strconv.Itoa(i))
+		require.True(t, ok)
+
+		counts[node]++
+	}
+
+	require.Greater(t, counts["heavy"], 2*counts["light"])
+}
+
+func TestNewShardedStore_DuplicateNames(t *testing.T) {
+	local, err := NewLocalStore(&LocalConfig{}, encoder.JSON{})
+	require.NoError(t, err)
+
+	_, err = NewShardedStore(Shard{Name: "shard-1", Cache: local}, Shard{Name: "shard-1", Cache: local})
+	require.EqualError(t, err, "shard shard-1 already exists")
+}
+
+func TestShardedStore(t *testing.T) {
+	for _, e := range encoders {
+		t.Run(shardedDriver.string(), func(t *testing.T) {
+			cache, valid := createStore(t, shardedDriver, e).(*ShardedStore)
+			require.True(t, valid)
+
+			var keys []string
+			for i := 0; i < 100; i++ {
+				key := "key:" + strconv.Itoa(i)
+				keys = append(keys, key)
+				require.NoError(t, cache.Put(key, i, time.Minute))
+			}
+
+			var owners = map[Cache]bool{}
+			for i, key := range keys {
+				owners[cache.Shard(key)] = true
+
+				v, err := cache.Shard(key).GetInt(key)
+				require.NoError(t, err)
+				require.Equal(t, i, v)
+			}
+			require.Len(t, owners, 3)
+
+			items, err := cache.Many(keys...)
+			require.NoError(t, err)
+			require.Len(t, items, len(keys))
+
+			for i, key := range keys {
+				v, err := items[key].Int()
+				require.NoError(t, err)
+				require.Equal(t, i, v)
+			}
+
+			require.Equal(t, cache.Shard("{user:1}:profile"), cache.Shard("{user:1}:settings"))
+			require.NoError(t, cache.ForgetMany(keys...))
+
+			items, err = cache.Many(keys...)
+			require.NoError(t, err)
+
+			for _, item := range items {
+				require.True(t, item.EntryNotFound())
+			}
+
+			local, err := NewLocalStore(&LocalConfig{Prefix: "golavel:"}, e)
+			require.NoError(t, err)
+			require.NoError(t, cache.AddShard(Shard{Name: "shard-4", Cache: local}))
+			require.Error(t, cache.AddShard(Shard{Name: "shard-4", Cache: local}))
+			require.NoError(t, cache.RemoveShard("shard-4"))
+			require.Error(t, cache.RemoveShard("shard-4"))
+
+			// Batch calls racing with ring changes always resolve keys to a live shard
+			var (
+				wg        sync.WaitGroup
+				resizeErr error
+			)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50 && resizeErr == nil; i++ {
+					if resizeErr = cache.AddShard(Shard{Name: "shard-5", Cache: local}); resizeErr == nil {
+						resizeErr = cache.RemoveShard("shard-5")
+					}
+				}
+			}()
+			for i := 0; i < 50; i++ {
+				_, err = cache.Many(keys...)
+				require.NoError(t, err)
+				require.NoError(t, cache.ForgetMany(keys...))
+			}
+			wg.Wait()
+			require.NoError(t, resizeErr)
+
+			_, err = cache.Flush()
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestShardedStore_Tags(t *testing.T) {
+	cache, valid := createStore(t, shardedDriver, encoder.JSON{}).(*ShardedStore)
+	require.True(t, valid)
+
+	tagged := cache.Tags("people")
+	require.NoError(t, tagged.Put("jane", "jane", time.Minute))
+
+	value, err := tagged.GetString("jane")
+	require.NoError(t, err)
+	require.Equal(t, "jane", value)
+
+	// Tagged keys cannot be enumerated nor counted given that they are spread across shards
+	_, _, err = tagged.Keys("", 10)
+	require.ErrorIs(t, err, ErrNotImplemented)
+
+	_, err = tagged.TagSet().Counts()
+	require.ErrorIs(t, err, ErrNotImplemented)
+
+	_, err = tagged.Flush()
+	require.NoError(t, err)
+
+	_, err = tagged.GetString("jane")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -1,13 +1,20 @@
 package gocache
 
 import (
+	"database/sql"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/alejandro-carstens/gocache/encoder"
+	"github.com/alejandro-carstens/gocache/gocachetest/testserver"
 )
 
 type (
@@ -26,6 +33,9 @@ const (
 	redisDriver    driver = "redis"
 	memcacheDriver driver = "memcache"
 	localDriver    driver = "local"
+	shardedDriver  driver = "sharded"
+	databaseDriver driver = "database"
+	fileDriver     driver = "file"
 )
 
 var (
@@ -33,10 +43,15 @@ var (
 		redisDriver,
 		memcacheDriver,
 		localDriver,
+		shardedDriver,
+		databaseDriver,
+		fileDriver,
 	}
 	encoders = []encoder.Encoder{
 		encoder.JSON{},
 		encoder.Msgpack{},
+		encoder.Gob{},
+		encoder.CBOR{},
 	}
 )
 
@@ -224,6 +239,128 @@ func TestPutGetBool(t *testing.T) {
 	}
 }
 
+func TestPutGetBytes(t *testing.T) {
+	for _, e := range encoders {
+		for _, d := range drivers(t) {
+			t.Run(d.string(), func(t *testing.T) {
+				var (
+					cache = createStore(t, d, e)
+					value = []byte{0x00, 0xff, 'g', 'o'}
+				)
+				require.NoError(t, cache.PutBytes("key", value, time.Minute))
+
+				got, err := cache.GetBytes("key")
+				require.NoError(t, err)
+				require.Equal(t, value, got)
+
+				items, err := cache.Many("key")
+				require.NoError(t, err)
+
+				got, err = items["key"].Bytes()
+				require.NoError(t, err)
+				require.Equal(t, value, got)
+
+				require.NoError(t, cache.Put("key", 10, time.Minute))
+
+				got, err = cache.GetBytes("key")
+				require.NoError(t, err)
+				require.Equal(t, []byte("10"), got)
+
+				_, err = cache.GetBytes("missing")
+				require.ErrorIs(t, err, ErrNotFound)
+
+				_, err = cache.Forget("key")
+				require.NoError(t, err)
+			})
+		}
+	}
+}
+
+func TestPutGetTime(t *testing.T) {
+	var now = time.Date(2024, 5, 1, 12, 30, 15, 123456789, time.UTC)
+	for _, e := range encoders {
+		for _, d := range drivers(t) {
+			t.Run(d.string(), func(t *testing.T) {
+				cache := createStore(t, d, e)
+				require.NoError(t, cache.Put("key", now, time.Minute))
+
+				got, err := cache.GetTime("key")
+				require.NoError(t, err)
+				require.True(t, now.Equal(got))
+
+				items, err := cache.Many("key")
+				require.NoError(t, err)
+
+				got, err = items["key"].Time()
+				require.NoError(t, err)
+				require.True(t, now.Equal(got))
+
+				require.NoError(t, cache.PutBytes("key", []byte(now.Format(time.RFC3339Nano)), time.Minute))
+
+				got, err = cache.GetTime("key")
+				require.NoError(t, err)
+				require.True(t, now.Equal(got))
+
+				require.NoError(t, cache.Put("key", example{Name: "Alejandro"}, time.Minute))
+
+				_, err = cache.GetTime("key")
+				require.Error(t, err)
+
+				_, err = cache.Forget("key")
+				require.NoError(t, err)
+			})
+		}
+	}
+}
+
+func TestPutGetDuration(t *testing.T) {
+	for _, e := range encoders {
+		for _, d := range drivers(t) {
+			t.Run(d.string(), func(t *testing.T) {
+				cache := createStore(t, d, e)
+				require.NoError(t, cache.Put("key", 90*time.Second, time.Minute))
+
+				got, err := cache.GetDuration("key")
+				require.NoError(t, err)
+				require.Equal(t, 90*time.Second, got)
+
+				items, err := cache.Many("key")
+				require.NoError(t, err)
+
+				got, err = items["key"].Duration()
+				require.NoError(t, err)
+				require.Equal(t, 90*time.Second, got)
+
+				require.NoError(t, cache.Put("key", "1m30s", time.Minute))
+
+				got, err = cache.GetDuration("key")
+				require.NoError(t, err)
+				require.Equal(t, 90*time.Second, got)
+
+				require.NoError(t, cache.Put("key", int64(time.Millisecond), time.Minute))
+
+				got, err = cache.GetDuration("key")
+				require.NoError(t, err)
+				require.Equal(t, time.Millisecond, got)
+
+				require.NoError(t, cache.PutBytes("key", []byte("2h"), time.Minute))
+
+				got, err = cache.GetDuration("key")
+				require.NoError(t, err)
+				require.Equal(t, 2*time.Hour, got)
+
+				require.NoError(t, cache.Put("key", "whatever", time.Minute))
+
+				_, err = cache.GetDuration("key")
+				require.Error(t, err)
+
+				_, err = cache.Forget("key")
+				require.NoError(t, err)
+			})
+		}
+	}
+}
+
 func TestForever(t *testing.T) {
 	for _, e := range encoders {
 		for _, d := range drivers(t) {
@@ -396,6 +533,255 @@ func TestPutGet(t *testing.T) {
 	}
 }
 
+func TestPutGetProto(t *testing.T) {
+	for _, d := range drivers(t) {
+		t.Run(d.string(), func(t *testing.T) {
+			var (
+				cache   = createStore(t, d, encoder.Proto{Fallback: encoder.JSON{}})
+				message = timestamppb.New(time.Unix(1700000000, 5))
+			)
+			require.NoError(t, cache.Put("proto", message, 10*time.Second))
+			require.NoError(t, cache.Put("string", "gocache", 10*time.Second))
+
+			var ts timestamppb.Timestamp
+			require.NoError(t, cache.Get("proto", &ts))
+			require.True(t, proto.Equal(message, &ts))
+
+			s, err := cache.GetString("string")
+			require.NoError(t, err)
+			require.Equal(t, "gocache", s)
+
+			_, err = cache.Forget("proto")
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPutGetCompressed(t *testing.T) {
+	for _, d := range drivers(t) {
+		t.Run(d.string(), func(t *testing.T) {
+			var (
+				cache        = createStore(t, d, encoder.Compressed{Inner: encoder.Msgpack{}, Algorithm: encoder.Zstd, MinSize: 64})
+				firstExample = example{
+					Name:        "Alejandro",
+					Description: strings.Repeat("Whatever", 128),
+				}
+			)
+			require.NoError(t, cache.Put("key", firstExample, 10*time.Second))
+			require.NoError(t, cache.Put("string", "gocache", 10*time.Second))
+
+			var newExample example
+			require.NoError(t, cache.Get("key", &newExample))
+			require.Equal(t, firstExample, newExample)
+
+			s, err := cache.GetString("string")
+			require.NoError(t, err)
+			require.Equal(t, "gocache", s)
+
+			_, err = cache.Forget("key")
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestReEncrypt(t *testing.T) {
+	var (
+		oldKey = encoder.Key{ID: "old", Secret: []byte("0123456789abcdef0123456789abcdef")}
+		newKey = encoder.Key{ID: "new", Secret: []byte("fedcba9876543210fedcba9876543210")}
+	)
+	for _, d := range drivers(t, memcacheDriver, shardedDriver) {
+		t.Run(d.string(), func(t *testing.T) {
+			cache := createStore(t, d, encoder.Encrypted{Inner: encoder.JSON{}, Keys: []encoder.Key{oldKey}})
+			require.NoError(t, cache.Put("key", example{Name: "Alejandro"}, time.Minute))
+			require.NoError(t, cache.Put("string", "secret", time.Minute))
+
+			setEncoder(cache, encoder.Encrypted{Inner: encoder.JSON{}, Keys: []encoder.Key{newKey, oldKey}, ReEncrypt: true})
+
+			var e example
+			require.NoError(t, cache.Get("key", &e))
+			require.Equal(t, "Alejandro", e.Name)
+
+			s, err := cache.GetString("string")
+			require.NoError(t, err)
+			require.Equal(t, "secret", s)
+
+			// Values are readable once the old key has been retired given that they were re-encrypted
+			setEncoder(cache, encoder.Encrypted{Inner: encoder.JSON{}, Keys: []encoder.Key{newKey}})
+
+			require.NoError(t, cache.Get("key", &e))
+
+			s, err = cache.GetString("string")
+			require.NoError(t, err)
+			require.Equal(t, "secret", s)
+
+			setEncoder(cache, encoder.Encrypted{Inner: encoder.JSON{}, Keys: []encoder.Key{oldKey}})
+
+			var tamperErr *encoder.TamperError
+			require.ErrorAs(t, cache.Get("key", &e), &tamperErr)
+		})
+	}
+}
+
+func TestReEncryptDoesNotOverwriteConcurrentWrites(t *testing.T) {
+	for _, d := range drivers(t, memcacheDriver, shardedDriver) {
+		t.Run(d.string(), func(t *testing.T) {
+			cache := createStore(t, d, encoder.JSON{})
+			require.NoError(t, cache.Put("key", "old", time.Minute))
+
+			current, err := cache.GetBytes("key")
+			require.NoError(t, err)
+
+			// A write landing between the read and the re-encryption
+			require.NoError(t, cache.Put("key", "new", time.Minute))
+
+			replace := cache.(interface {
+				replacer(string) func(current, upgraded []byte) error
+			}).replacer("key")
+			require.NoError(t, replace(current, []byte(`"upgraded"`)))
+
+			value, err := cache.GetString("key")
+			require.NoError(t, err)
+			require.Equal(t, "new", value)
+
+			current, err = cache.GetBytes("key")
+			require.NoError(t, err)
+			require.NoError(t, replace(current, []byte(`"upgraded"`)))
+
+			value, err = cache.GetString("key")
+			require.NoError(t, err)
+			require.Equal(t, "upgraded", value)
+		})
+	}
+}
+
+func TestEncryptedScalars(t *testing.T) {
+	var key = encoder.Key{ID: "key", Secret: []byte("0123456789abcdef0123456789abcdef")}
+	for _, d := range drivers(t, localDriver, shardedDriver) {
+		t.Run(d.string(), func(t *testing.T) {
+			cache := createStore(t, d, encoder.Encrypted{Inner: encoder.JSON{}, Keys: []encoder.Key{key}})
+			require.NoError(t, cache.Put("int", 4096, time.Minute))
+			require.NoError(t, cache.Forever("float", 2.5))
+			require.NoError(t, cache.PutMany(Entry{Key: "bool", Value: true, Duration: time.Minute}))
+
+			// Numbers and booleans are encrypted like any other value
+			for key, plaintext := range map[string]string{"int": "4096", "float": "2.5", "bool": "true"} {
+				raw, err := cache.GetBytes(key)
+				require.NoError(t, err)
+				require.NotContains(t, string(raw), plaintext, key)
+			}
+
+			i, err := cache.GetInt("int")
+			require.NoError(t, err)
+			require.Equal(t, 4096, i)
+
+			f, err := cache.GetFloat64("float")
+			require.NoError(t, err)
+			require.Equal(t, 2.5, f)
+
+			b, err := cache.GetBool("bool")
+			require.NoError(t, err)
+			require.True(t, b)
+
+			str, err := cache.GetString("int")
+			require.NoError(t, err)
+			require.Equal(t, "4096", str)
+
+			items, err := cache.Many("int", "bool")
+			require.NoError(t, err)
+
+			i, err = items["int"].Int()
+			require.NoError(t, err)
+			require.Equal(t, 4096, i)
+
+			b, err = items["bool"].Bool()
+			require.NoError(t, err)
+			require.True(t, b)
+
+			// Counters would have to be stored in plaintext
+			_, err = cache.Increment("int", 1)
+			require.ErrorIs(t, err, ErrEncryptedCounter)
+
+			_, err = cache.Decrement("counter", 1)
+			require.ErrorIs(t, err, ErrEncryptedCounter)
+		})
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	type (
+		customInt   int
+		customFloat float64
+	)
+	for _, d := range drivers(t) {
+		t.Run(d.string(), func(t *testing.T) {
+			cache := createStore(t, d, encoder.Envelope{Inner: encoder.Msgpack{}})
+			require.NoError(t, cache.Put("string", "123", time.Minute))
+			require.NoError(t, cache.Put("custom_int", customInt(7), time.Minute))
+			require.NoError(t, cache.Put("custom_float", customFloat(1.5), time.Minute))
+			require.NoError(t, cache.Put("int", 5, time.Minute))
+			require.NoError(t, cache.Put("struct", example{Name: "Alejandro"}, time.Minute))
+
+			s, err := cache.GetString("string")
+			require.NoError(t, err)
+			require.Equal(t, "123", s)
+
+			_, err = cache.GetInt("string")
+			require.Error(t, err)
+
+			i, err := cache.GetInt("custom_int")
+			require.NoError(t, err)
+			require.Equal(t, 7, i)
+
+			s, err = cache.GetString("custom_int")
+			require.NoError(t, err)
+			require.Equal(t, "7", s)
+
+			f, err := cache.GetFloat64("custom_float")
+			require.NoError(t, err)
+			require.Equal(t, 1.5, f)
+
+			i, err = cache.GetInt("int")
+			require.NoError(t, err)
+			require.Equal(t, 5, i)
+
+			var e example
+			require.NoError(t, cache.Get("struct", &e))
+			require.Equal(t, "Alejandro", e.Name)
+
+			items, err := cache.Many("string", "custom_int")
+			require.NoError(t, err)
+
+			s, err = items["string"].String()
+			require.NoError(t, err)
+			require.Equal(t, "123", s)
+
+			i, err = items["custom_int"].Int()
+			require.NoError(t, err)
+			require.Equal(t, 7, i)
+		})
+	}
+}
+
+func TestEnvelope_Legacy(t *testing.T) {
+	for _, d := range drivers(t, memcacheDriver, shardedDriver) {
+		t.Run(d.string(), func(t *testing.T) {
+			cache := createStore(t, d, encoder.Msgpack{})
+			require.NoError(t, cache.Put("string", "legacy", time.Minute))
+			require.NoError(t, cache.Put("struct", example{Name: "Alejandro"}, time.Minute))
+
+			setEncoder(cache, encoder.Envelope{Inner: encoder.Msgpack{}})
+
+			s, err := cache.GetString("string")
+			require.NoError(t, err)
+			require.Equal(t, "legacy", s)
+
+			var e example
+			require.NoError(t, cache.Get("struct", &e))
+			require.Equal(t, "Alejandro", e.Name)
+		})
+	}
+}
+
 func TestIncrement(t *testing.T) {
 	for _, e := range encoders {
 		for _, d := range drivers(t) {
@@ -425,6 +811,9 @@ func TestDecrement(t *testing.T) {
 		memcacheDriver.string(): 0,
 		redisDriver.string():    -2,
 		localDriver.string():    -2,
+		shardedDriver.string():  -2,
+		databaseDriver.string(): -2,
+		fileDriver.string():     -2,
 	}
 	for _, e := range encoders {
 		for _, d := range drivers(t) {
@@ -637,7 +1026,7 @@ func TestForgetMany(t *testing.T) {
 
 func TestExpire(t *testing.T) {
 	for _, e := range encoders {
-		for _, d := range drivers(t, localDriver) {
+		for _, d := range drivers(t, localDriver, shardedDriver) {
 			t.Run(d.string(), func(t *testing.T) {
 				var (
 					cache = createStore(t, d, e)
@@ -651,6 +1040,19 @@ func TestExpire(t *testing.T) {
 	}
 }
 
+func setEncoder(cache Cache, enc encoder.Encoder) {
+	switch s := cache.(type) {
+	case *RedisStore:
+		s.encoder = enc
+	case *LocalStore:
+		s.encoder = enc
+	case *DatabaseStore:
+		s.encoder = enc
+	case *FileStore:
+		s.encoder = enc
+	}
+}
+
 func createStore(t *testing.T, d driver, encoder encoder.Encoder) Cache {
 	t.Helper()
 
@@ -660,19 +1062,67 @@ func createStore(t *testing.T, d driver, encoder encoder.Encoder) Cache {
 	)
 	switch d {
 	case redisDriver:
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			addr = testserver.RunRedis(t).Addr()
+		}
+
 		cnf = &RedisConfig{
 			Prefix: "golavel:",
-			Addr:   os.Getenv("REDIS_ADDR"),
+			Addr:   addr,
 		}
 	case memcacheDriver:
+		server := os.Getenv("MEMCACHE_SERVER")
+		if server == "" {
+			server = testserver.RunMemcache(t).Addr()
+		}
+
 		cnf = &MemcacheConfig{
 			Prefix:  "golavel:",
-			Servers: []string{os.Getenv("MEMCACHE_SERVER")},
+			Servers: []string{server},
 		}
 	case localDriver:
 		cnf = &LocalConfig{
 			Prefix: "golavel:",
 		}
+	case databaseDriver:
+		db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "cache.db")+"?_busy_timeout=5000&_journal_mode=WAL&_synchronous=NORMAL")
+		require.NoError(t, err)
+		// SQLite only allows for one writer at a time
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() {
+			require.NoError(t, db.Close())
+		})
+
+		cnf = &DatabaseConfig{
+			Prefix:      "golavel:",
+			DB:          db,
+			Dialect:     DatabaseDialectSQLite,
+			AutoMigrate: true,
+		}
+	case fileDriver:
+		cnf = &FileConfig{
+			Prefix:    "golavel:",
+			Directory: t.TempDir(),
+		}
+	case shardedDriver:
+		var shards []Shard
+		for _, name := range []string{"shard-1", "shard-2", "shard-3"} {
+			store, err := NewLocalStore(&LocalConfig{
+				Prefix: "golavel:",
+			}, encoder)
+			require.NoError(t, err)
+
+			shards = append(shards, Shard{
+				Name:  name,
+				Cache: store,
+			})
+		}
+
+		cache, err := NewShardedStore(shards...)
+		require.NoError(t, err)
+
+		return cache
 	}
 
 	cache, err := New(cnf, encoder)
@@ -0,0 +1,125 @@
+package gocache
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+)
+
+// streamBufferSize is the size of the pieces in which streams are written to and read from the stores
+const streamBufferSize = 512 * 1024
+
+// streamWriter is the destination of a stream. The value is only made visible to readers once committed
+type streamWriter interface {
+	io.Writer
+	// commit makes the written value visible
+	commit() error
+	// abort discards the written value
+	abort()
+}
+
+// writeStream copies r to sw compressing it if enc is an encoder.Compressed and commits it. Nothing is committed
+// if an error occurs
+func writeStream(enc encoder.Encoder, sw streamWriter, r io.Reader) error {
+	if err := copyStream(enc, sw, r); err != nil {
+		sw.abort()
+
+		return err
+	}
+
+	return sw.commit()
+}
+
+func copyStream(enc encoder.Encoder, sw streamWriter, r io.Reader) error {
+	var compressed *encoder.Compressed
+	switch e := enc.(type) {
+	case encoder.Compressed:
+		compressed = &e
+	case *encoder.Compressed:
+		compressed = e
+	}
+	if compressed == nil {
+		_, err := io.Copy(sw, r)
+
+		return err
+	}
+
+	w, err := encoder.NewCompressWriter(sw, compressed.Algorithm)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, r); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// readStream copies r to w decompressing it if needed
+func readStream(r io.Reader, w io.Writer) error {
+	rc, err := encoder.NewDecompressReader(r)
+	if err != nil {
+		return err
+	}
+
+	defer rc.Close()
+
+	if _, err = io.Copy(w, rc); err != nil {
+		return err
+	}
+
+	return drainStream(r)
+}
+
+// drainStream reads r until EOF so that readers verifying their contents once fully read, e.g. chunked Memcache
+// values, get to report a mismatch even if the decompressor stopped reading early
+func drainStream(r io.Reader) error {
+	_, err := io.Copy(io.Discard, r)
+
+	return err
+}
+
+// chunkWriter buffers a stream and hands it to flush in pieces of size bytes. A piece is only flushed once it is
+// full and more data is written, the last piece being left in buf for the caller to commit
+type chunkWriter struct {
+	size  int
+	buf   []byte
+	flush func(chunk []byte) error
+}
+
+// Write implementation of the io.Writer interface
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	var n = len(p)
+	for len(p) > 0 {
+		if len(w.buf) >= w.size {
+			if err := w.flush(w.buf); err != nil {
+				return 0, err
+			}
+
+			w.buf = w.buf[:0]
+		}
+
+		m := w.size - len(w.buf)
+		if m > len(p) {
+			m = len(p)
+		}
+
+		w.buf = append(w.buf, p[:m]...)
+		p = p[m:]
+	}
+
+	return n, nil
+}
+
+// bufferStreamWriter buffers a whole stream in memory and hands it to put once committed
+type bufferStreamWriter struct {
+	bytes.Buffer
+	put func(value []byte) error
+}
+
+func (w *bufferStreamWriter) commit() error {
+	return w.put(w.Bytes())
+}
+
+func (*bufferStreamWriter) abort() {}
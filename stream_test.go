@@ -0,0 +1,92 @@
+package gocache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alejandro-carstens/gocache/encoder"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+// failingAfter reads from r and fails once n bytes have been read
+type failingAfter struct {
+	r io.Reader
+	n int
+}
+
+func (f *failingAfter) Read(p []byte) (int, error) {
+	if f.n <= 0 {
+		return 0, errors.New("read failed")
+	}
+	if len(p) > f.n {
+		p = p[:f.n]
+	}
+
+	n, err := f.r.Read(p)
+	f.n -= n
+
+	return n, err
+}
+
+func TestPutGetStream(t *testing.T) {
+	var value = make([]byte, 3*1024*1024)
+	rand.New(rand.NewSource(1)).Read(value[:len(value)/2])
+
+	for _, e := range []encoder.Encoder{
+		encoder.JSON{},
+		encoder.Compressed{Inner: encoder.JSON{}},
+		encoder.Compressed{Inner: encoder.JSON{}, Algorithm: encoder.Zstd},
+	} {
+		for _, d := range []driver{redisDriver, memcacheDriver, localDriver} {
+			t.Run(d.string(), func(t *testing.T) {
+				cache, valid := createStore(t, d, e).(Streamer)
+				require.True(t, valid)
+
+				for _, v := range [][]byte{value, []byte("small"), {}} {
+					require.NoError(t, cache.PutStream("stream", bytes.NewReader(v), time.Minute))
+
+					var buf bytes.Buffer
+					require.NoError(t, cache.GetStream("stream", &buf))
+					require.True(t, bytes.Equal(v, buf.Bytes()))
+				}
+
+				require.ErrorIs(t, cache.GetStream("missing", &bytes.Buffer{}), ErrNotFound)
+
+				// Values stored via PutBytes can be streamed and, unless compressed, the other way around
+				require.NoError(t, cache.(Cache).PutBytes("bytes", value, time.Minute))
+
+				var buf bytes.Buffer
+				require.NoError(t, cache.GetStream("bytes", &buf))
+				require.True(t, bytes.Equal(value, buf.Bytes()))
+
+				if _, compressed := e.(encoder.Compressed); !compressed {
+					require.NoError(t, cache.PutStream("stream", bytes.NewReader(value), time.Minute))
+
+					got, err := cache.(Cache).GetBytes("stream")
+					require.NoError(t, err)
+					require.True(t, bytes.Equal(value, got))
+				}
+
+				// A failed stream does not replace the existing value, whether it fails right away or after several
+				// chunks have been written
+				require.Error(t, cache.PutStream("bytes", failingReader{}, time.Minute))
+				require.Error(t, cache.PutStream("bytes", &failingAfter{r: bytes.NewReader(value), n: len(value) - 1}, time.Minute))
+
+				buf.Reset()
+				require.NoError(t, cache.GetStream("bytes", &buf))
+				require.True(t, bytes.Equal(value, buf.Bytes()))
+			})
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package gocache
+
+import "time"
+
+var _ TaggedCache = &indexedTaggedCache{}
+
+type (
+	// tagIndex is implemented by stores keeping track of the keys written under each tag id, so that flushing a
+	// tag evicts the underlying entries rather than merely orphaning them by rotating the tag id
+	tagIndex interface {
+		// indexTagKey records the given tagged key under every one of the given tag ids
+		indexTagKey(ids []string, key string) error
+		// flushTagIndex evicts the keys recorded under the given tag id and drops its index
+		flushTagIndex(id string) error
+	}
+	// indexedTaggedCache is the representation of a tagged cache whose store keeps a tagIndex
+	indexedTaggedCache struct {
+		taggedCache
+		index tagIndex
+	}
+)
+
+// Put implementation of the TaggedCache interface
+func (tc *indexedTaggedCache) Put(key string, value interface{}, duration time.Duration) error {
+	if err := tc.indexKey(key); err != nil {
+		return err
+	}
+
+	return tc.taggedCache.Put(key, value, duration)
+}
+
+// Add implementation of the TaggedCache interface
+func (tc *indexedTaggedCache) Add(key string, value interface{}, duration time.Duration) (bool, error) {
+	if err := tc.indexKey(key); err != nil {
+		return false, err
+	}
+
+	return tc.taggedCache.Add(key, value, duration)
+}
+
+// Forever implementation of the TaggedCache interface
+func (tc *indexedTaggedCache) Forever(key string, value interface{}) error {
+	if err := tc.indexKey(key); err != nil {
+		return err
+	}
+
+	return tc.taggedCache.Forever(key, value)
+}
+
+// PutBytes implementation of the TaggedCache interface
+func (tc *indexedTaggedCache) PutBytes(key string, value []byte, duration time.Duration) error {
+	if err := tc.indexKey(key); err != nil {
+		return err
+	}
+
+	return tc.taggedCache.PutBytes(key, value, duration)
+}
+
+// PutMany implementation of the TaggedCache interface
+func (tc *indexedTaggedCache) PutMany(entries ...Entry) error {
+	ids, namespace, err := tc.resolve()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err = tc.index.indexTagKey(ids, tc.namespacedKey(namespace, entry.Key)); err != nil {
+			return err
+		}
+	}
+
+	return tc.putMany(namespace, entries)
+}
+
+// Increment implementation of the TaggedCache interface
+func (tc *indexedTaggedCache) Increment(key string, value int64) (int64, error) {
+	if err := tc.indexKey(key); err != nil {
+		return 0, err
+	}
+
+	return tc.taggedCache.Increment(key, value)
+}
+
+// Decrement implementation of the TaggedCache interface
+func (tc *indexedTaggedCache) Decrement(key string, value int64) (int64, error) {
+	if err := tc.indexKey(key); err != nil {
+		return 0, err
+	}
+
+	return tc.taggedCache.Decrement(key, value)
+}
+
+// Flush evicts the entries recorded under every tag of the TagSet before rotating the tag ids
+func (tc *indexedTaggedCache) Flush() (bool, error) {
+	ids, err := tc.tags.TagIds()
+	if err != nil {
+		return false, err
+	}
+
+	for _, id := range ids {
+		if err = tc.index.flushTagIndex(id); err != nil {
+			return false, err
+		}
+	}
+
+	return tc.taggedCache.Flush()
+}
+
+// indexKey records the tagged key of the given key under every tag id of the TagSet. It is recorded before the
+// entry is written so that a failed index update never leaves an entry Flush does not know about
+func (tc *indexedTaggedCache) indexKey(key string) error {
+	ids, namespace, err := tc.resolve()
+	if err != nil {
+		return err
+	}
+
+	return tc.index.indexTagKey(ids, tc.namespacedKey(namespace, key))
+}
@@ -7,6 +7,10 @@ import (
 	"github.com/rs/xid"
 )
 
+// tagIdCreationRetries is the number of times adding a tag id is attempted when the id a concurrent process added
+// is gone by the time it is read, e.g. because the store evicted it right away
+const tagIdCreationRetries = 3
+
 // TagSet is the representation of a set of tags to be used to interact with the caching stores
 type TagSet struct {
 	store store
@@ -53,6 +57,29 @@ func (ts *TagSet) TagIds() ([]string, error) {
 	return tagIds, nil
 }
 
+// Counts returns the number of keys written under each tag of the TagSet, whichever tag set they were written with.
+// Only RedisStore, MemcacheStore and LocalStore are able to count them
+func (ts *TagSet) Counts() (map[string]int64, error) {
+	s, valid := ts.store.(tagKeys)
+	if !valid {
+		return nil, ErrNotImplemented
+	}
+
+	var counts = make(map[string]int64, len(ts.names))
+	for _, name := range ts.names {
+		id, err := ts.tagId(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if counts[name], err = s.countTagKeys(id); err != nil {
+			return nil, err
+		}
+	}
+
+	return counts, nil
+}
+
 func (ts *TagSet) namespace() (string, error) {
 	tagsIds, err := ts.TagIds()
 	if err != nil {
@@ -68,12 +95,57 @@ func (ts *TagSet) tagId(name string) (string, error) {
 		return "", err
 	}
 	if len(value) == 0 {
-		return ts.resetTag(name)
+		return ts.createTag(name)
 	}
 
 	return fmt.Sprint(value), nil
 }
 
+// createTag adds a new id for the given tag unless a concurrent process already did, in which case the id it added
+// is returned so that both write under the same namespace. Should the tag be flushed in between, adding is retried
+// up to tagIdCreationRetries times so that no concurrent process ends up overwriting an id another one is writing
+// under
+func (ts *TagSet) createTag(name string) (string, error) {
+	for attempt := 0; attempt < tagIdCreationRetries; attempt++ {
+		id := xid.New().String()
+
+		added, err := addForever(ts.store, ts.tagKey(name), id)
+		if err != nil {
+			return "", err
+		}
+		if added {
+			return id, nil
+		}
+
+		value, err := ts.store.GetString(ts.tagKey(name))
+		if err != nil && !isErrNotFound(err) {
+			return "", err
+		}
+		if len(value) > 0 {
+			return value, nil
+		}
+	}
+
+	return "", fmt.Errorf("gocache: failed to create an id for tag %s after %d attempts", name, tagIdCreationRetries)
+}
+
+// foreverAdder is implemented by stores whose Add does not keep an entry until it is forgotten/evicted given a 0
+// duration
+type foreverAdder interface {
+	// addForever adds the given value until it is forgotten/evicted unless an entry already exists for the given key
+	addForever(key string, value interface{}) (bool, error)
+}
+
+// addForever adds the given value to the given store until it is forgotten/evicted unless an entry already exists
+// for the given key. A 0 duration keeps the entries added by the stores that do not implement foreverAdder forever
+func addForever(s store, key string, value interface{}) (bool, error) {
+	if adder, valid := s.(foreverAdder); valid {
+		return adder.addForever(key, value)
+	}
+
+	return s.Add(key, value, 0)
+}
+
 func (ts *TagSet) tagKey(name string) string {
 	return "tag:" + name + ":key"
 }
@@ -4,16 +4,30 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"errors"
+	"strings"
 	"time"
 )
 
+// defaultTagKeysCount is the number of keys returned per page when enumerating a tag set's keys
+const defaultTagKeysCount = 1000
+
 var _ TaggedCache = &taggedCache{}
 
-// taggedCache is the representation of a tagged caching store
-type taggedCache struct {
-	store store
-	tags  *TagSet
-}
+type (
+	// tagKeys is implemented by stores able to enumerate the keys recorded under each tag id
+	tagKeys interface {
+		// scanTagKeys returns about count of the tagged keys recorded under the given tag id which start with
+		// prefix, along with the cursor of the following page which is empty once the keys are exhausted
+		scanTagKeys(id, prefix, cursor string, count int) ([]string, string, error)
+		// countTagKeys returns the number of keys recorded under the given tag id
+		countTagKeys(id string) (int64, error)
+	}
+	// taggedCache is the representation of a tagged caching store
+	taggedCache struct {
+		store store
+		tags  *TagSet
+	}
+)
 
 // Put puts a value in the given store for a predetermined amount of time in seconds
 func (tc *taggedCache) Put(key string, value interface{}, duration time.Duration) error {
@@ -68,14 +82,14 @@ func (tc *taggedCache) Forget(key string) (bool, error) {
 
 // ForgetMany forgets/evicts a set of given key-value pair from the store
 func (tc *taggedCache) ForgetMany(keys ...string) error {
+	_, namespace, err := tc.resolve()
+	if err != nil {
+		return err
+	}
+
 	var tagKeys = make([]string, len(keys))
 	for i, key := range keys {
-		tagKey, err := tc.tagKey(key)
-		if err != nil {
-			return err
-		}
-
-		tagKeys[i] = tagKey
+		tagKeys[i] = tc.namespacedKey(namespace, key)
 	}
 
 	return tc.store.ForgetMany(tagKeys...)
@@ -93,15 +107,17 @@ func (tc *taggedCache) Forever(key string, value interface{}) error {
 
 // Many gets many values from the store
 func (tc *taggedCache) Many(keys ...string) (Items, error) {
+	_, namespace, err := tc.resolve()
+	if err != nil {
+		return nil, err
+	}
+
 	var (
 		taggedKeys = make([]string, len(keys))
 		tagKeyMap  = map[string]string{}
 	)
 	for i, key := range keys {
-		tagKey, err := tc.tagKey(key)
-		if err != nil {
-			return nil, err
-		}
+		tagKey := tc.namespacedKey(namespace, key)
 
 		taggedKeys[i] = tagKey
 		tagKeyMap[tagKey] = key
@@ -129,17 +145,12 @@ func (tc *taggedCache) Many(keys ...string) (Items, error) {
 
 // PutMany puts many values in the given store until they are forgotten/evicted
 func (tc *taggedCache) PutMany(entries ...Entry) error {
-	for i, entry := range entries {
-		key, err := tc.tagKey(entry.Key)
-		if err != nil {
-			return err
-		}
-
-		entry.Key = key
-		entries[i] = entry
+	_, namespace, err := tc.resolve()
+	if err != nil {
+		return err
 	}
 
-	return tc.store.PutMany(entries...)
+	return tc.putMany(namespace, entries)
 }
 
 // Prefix gets the cache key val
@@ -207,6 +218,46 @@ func (tc *taggedCache) GetFloat32(key string) (float32, error) {
 	return tc.store.GetFloat32(tagKey)
 }
 
+// GetTime gets a time.Time value from the store
+func (tc *taggedCache) GetTime(key string) (time.Time, error) {
+	tagKey, err := tc.tagKey(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return tc.store.GetTime(tagKey)
+}
+
+// GetDuration gets a time.Duration value from the store
+func (tc *taggedCache) GetDuration(key string) (time.Duration, error) {
+	tagKey, err := tc.tagKey(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return tc.store.GetDuration(tagKey)
+}
+
+// GetBytes gets the raw bytes stored for the given key bypassing the encoder
+func (tc *taggedCache) GetBytes(key string) ([]byte, error) {
+	tagKey, err := tc.tagKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return tc.store.GetBytes(tagKey)
+}
+
+// PutBytes puts raw bytes in the given store for a predetermined amount of time bypassing the encoder
+func (tc *taggedCache) PutBytes(key string, value []byte, duration time.Duration) error {
+	tagKey, err := tc.tagKey(key)
+	if err != nil {
+		return err
+	}
+
+	return tc.store.PutBytes(tagKey, value, duration)
+}
+
 // Get gets the struct representation of a value from the store
 func (tc *taggedCache) Get(key string, entity interface{}) error {
 	tagKey, err := tc.tagKey(key)
@@ -264,6 +315,58 @@ func (tc *taggedCache) TagSet() *TagSet {
 	return tc.tags
 }
 
+// Keys implementation of the TaggedCache interface. Pages may hold fewer keys than count
+func (tc *taggedCache) Keys(cursor string, count int) ([]string, string, error) {
+	s, valid := tc.store.(tagKeys)
+	if !valid {
+		return nil, "", ErrNotImplemented
+	}
+
+	ids, namespace, err := tc.resolve()
+	if err != nil || len(ids) == 0 {
+		return nil, "", err
+	}
+	if count <= 0 {
+		count = defaultTagKeysCount
+	}
+
+	// Every entry of the tag set is recorded under each one of its tag ids, hence scanning the first one suffices
+	var prefix = tc.namespacedKey(namespace, "")
+
+	tagged, next, err := s.scanTagKeys(ids[0], prefix, cursor, count)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var keys = make([]string, len(tagged))
+	for i, key := range tagged {
+		keys[i] = strings.TrimPrefix(key, prefix)
+	}
+
+	return keys, next, nil
+}
+
+// resolve returns the tag ids of the TagSet along with the namespace they make up. Batch operations resolve them
+// once rather than once per key
+func (tc *taggedCache) resolve() ([]string, string, error) {
+	ids, err := tc.tags.TagIds()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ids, strings.Join(ids, "|"), nil
+}
+
+// putMany puts the given entries under the given namespace
+func (tc *taggedCache) putMany(namespace string, entries []Entry) error {
+	for i, entry := range entries {
+		entry.Key = tc.namespacedKey(namespace, entry.Key)
+		entries[i] = entry
+	}
+
+	return tc.store.PutMany(entries...)
+}
+
 // tagKey returns the underlying tagged cache item key
 func (tc *taggedCache) tagKey(key string) (string, error) {
 	namespace, err := tc.tags.namespace()
@@ -271,8 +374,18 @@ func (tc *taggedCache) tagKey(key string) (string, error) {
 		return namespace, err
 	}
 
+	return tc.namespacedKey(namespace, key), nil
+}
+
+// namespacedKey returns the underlying tagged cache item key of key for the given namespace. Laravel compatible
+// stores only prefix it once, as Laravel does
+func (tc *taggedCache) namespacedKey(namespace, key string) string {
 	h := sha1.New()
 	h.Write([]byte(namespace))
 
-	return tc.Prefix() + hex.EncodeToString(h.Sum(nil)) + ":" + key, nil
+	if isLaravelCompatible(tc.store) {
+		return hex.EncodeToString(h.Sum(nil)) + ":" + key
+	}
+
+	return tc.Prefix() + hex.EncodeToString(h.Sum(nil)) + ":" + key
 }
@@ -2,10 +2,16 @@ package gocache
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/alejandro-carstens/gocache/encoder"
 )
 
 func TestPutGetInt64WithTags(t *testing.T) {
@@ -565,6 +571,311 @@ func TestFlushWithTags(t *testing.T) {
 	}
 }
 
+func TestFlushWithTagsEvictsEntries(t *testing.T) {
+	for _, e := range encoders {
+		for _, d := range drivers(t, shardedDriver, databaseDriver, fileDriver) {
+			t.Run(d.string(), func(t *testing.T) {
+				var (
+					cache  = createStore(t, d, e)
+					ts     = tag()
+					tagged = cache.Tags(ts, "evict")
+					keys   []string
+				)
+				require.NoError(t, tagged.Put("put", "value", time.Minute))
+				require.NoError(t, tagged.Forever("forever", "value"))
+				require.NoError(t, tagged.PutMany(Entry{Key: "many", Value: "value", Duration: time.Minute}))
+
+				_, err := tagged.Increment("counter", 1)
+				require.NoError(t, err)
+
+				for _, key := range []string{"put", "forever", "many", "counter"} {
+					tagKey, err := tagged.(interface{ tagKey(string) (string, error) }).tagKey(key)
+					require.NoError(t, err)
+
+					exists, err := cache.Exists(tagKey)
+					require.NoError(t, err)
+					require.True(t, exists, key)
+
+					keys = append(keys, tagKey)
+				}
+
+				_, err = cache.Tags(ts).Flush()
+				require.NoError(t, err)
+
+				for _, key := range keys {
+					exists, err := cache.Exists(key)
+					require.NoError(t, err)
+					require.False(t, exists, key)
+				}
+				require.NoError(t, tagged.TagSet().Flush())
+			})
+		}
+	}
+}
+
+func TestTaggedCacheKeys(t *testing.T) {
+	for _, e := range encoders {
+		for _, d := range drivers(t, shardedDriver, databaseDriver, fileDriver) {
+			t.Run(d.string(), func(t *testing.T) {
+				var (
+					cache  = createStore(t, d, e)
+					ts     = tag()
+					tagged = cache.Tags(ts, "keys")
+					keys   = map[string]bool{}
+					cursor string
+				)
+				for _, key := range []string{"a", "b", "c"} {
+					require.NoError(t, tagged.Put(key, key, time.Minute))
+				}
+				require.NoError(t, tagged.Forever("d", "d"))
+				require.NoError(t, cache.Tags(ts).Put("e", "e", time.Minute))
+
+				for {
+					var (
+						page []string
+						err  error
+					)
+					page, cursor, err = tagged.Keys(cursor, 2)
+					require.NoError(t, err)
+					for _, key := range page {
+						keys[key] = true
+					}
+					if len(cursor) == 0 {
+						break
+					}
+				}
+				require.Equal(t, map[string]bool{"a": true, "b": true, "c": true, "d": true}, keys)
+
+				page, cursor, err := cache.Tags(ts).Keys("", 0)
+				require.NoError(t, err)
+				require.Equal(t, []string{"e"}, page)
+				require.Empty(t, cursor)
+
+				counts, err := tagged.TagSet().Counts()
+				require.NoError(t, err)
+				require.Equal(t, map[string]int64{ts: 5, "keys": 4}, counts)
+
+				_, err = tagged.Flush()
+				require.NoError(t, err)
+				require.NoError(t, cache.Tags(ts).TagSet().Flush())
+			})
+		}
+	}
+}
+
+func TestLocalStore_TagKeysWrittenWhilePaging(t *testing.T) {
+	local, err := NewLocalStore(&LocalConfig{}, encoder.JSON{})
+	require.NoError(t, err)
+
+	tagged := local.Tags("people")
+	for i := 0; i < 100; i++ {
+		require.NoError(t, tagged.Put(fmt.Sprintf("key-%03d", i), i, time.Minute))
+	}
+
+	var (
+		seen   = map[string]int{}
+		cursor string
+	)
+	for i := 0; ; i++ {
+		page, next, err := tagged.Keys(cursor, 10)
+		require.NoError(t, err)
+		for _, key := range page {
+			seen[key]++
+		}
+		if len(next) == 0 {
+			break
+		}
+
+		// Keys written in between pages are returned by the following pages as long as they sort after the cursor
+		require.NoError(t, tagged.Put(fmt.Sprintf("abc-%03d", i), i, time.Minute))
+		require.NoError(t, tagged.Put(fmt.Sprintf("zzz-%03d", i), i, time.Minute))
+		cursor = next
+	}
+
+	for key, n := range seen {
+		require.Equal(t, 1, n, key)
+		require.NotContains(t, key, "abc-")
+	}
+	for i := 0; i < 100; i++ {
+		require.Contains(t, seen, fmt.Sprintf("key-%03d", i))
+	}
+	require.Contains(t, seen, "zzz-000")
+}
+
+func TestLocalStore_TagIndexPrune(t *testing.T) {
+	local, err := NewLocalStore(&LocalConfig{}, encoder.JSON{})
+	require.NoError(t, err)
+
+	// Pruning the index never drops the keys indexed since the previous prune, e.g. the one being written
+	tagged := local.Tags("people")
+	for i := 0; i < 3*localTagIndexMinPrune; i++ {
+		require.NoError(t, tagged.Put(fmt.Sprintf("key-%03d", i), i, time.Minute))
+	}
+
+	counts, err := tagged.TagSet().Counts()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"people": 3 * localTagIndexMinPrune}, counts)
+
+	_, err = tagged.Flush()
+	require.NoError(t, err)
+	for key := range local.c.Items() {
+		require.NotContains(t, key, "key-")
+	}
+}
+
+func TestTagIdCreationRace(t *testing.T) {
+	for _, e := range encoders {
+		for _, d := range drivers(t) {
+			t.Run(d.string(), func(t *testing.T) {
+				var (
+					cache = createStore(t, d, e)
+					ids   = make([]string, 10)
+					errs  = make([]error, len(ids))
+					wg    sync.WaitGroup
+				)
+				require.NoError(t, cache.Tags("race").TagSet().Flush())
+
+				wg.Add(len(ids))
+				for i := range ids {
+					go func(i int) {
+						defer wg.Done()
+
+						tagIds, err := cache.Tags("race").TagSet().TagIds()
+						if errs[i] = err; err == nil {
+							ids[i] = tagIds[0]
+						}
+					}(i)
+				}
+				wg.Wait()
+
+				for i, id := range ids {
+					require.NoError(t, errs[i])
+					require.Equal(t, ids[0], id)
+				}
+				require.NoError(t, cache.Tags("race").TagSet().Flush())
+			})
+		}
+	}
+}
+
+func TestTagIdsNeverExpire(t *testing.T) {
+	local, err := NewLocalStore(&LocalConfig{DefaultExpiration: 50 * time.Millisecond}, encoder.JSON{})
+	require.NoError(t, err)
+
+	ids, err := local.Tags("people").TagSet().TagIds()
+	require.NoError(t, err)
+
+	// Add keeps using the default expiration given a 0 duration
+	added, err := local.Add("key", "value", 0)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	time.Sleep(60 * time.Millisecond)
+
+	exists, err := local.Exists("key")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	current, err := local.Tags("people").TagSet().TagIds()
+	require.NoError(t, err)
+	require.Equal(t, ids, current)
+}
+
+func TestTagIdCreationRetriesFlushedTags(t *testing.T) {
+	local, err := NewLocalStore(&LocalConfig{}, encoder.JSON{})
+	require.NoError(t, err)
+
+	var (
+		flushing = &flushingAdder{LocalStore: local, failures: 1}
+		ts       = &TagSet{store: flushing, names: []string{"people"}}
+	)
+	ids, err := ts.TagIds()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, flushing.adds.Load())
+	require.Zero(t, flushing.forevers.Load())
+
+	id, err := local.GetString(ts.tagKey("people"))
+	require.NoError(t, err)
+	require.Equal(t, []string{id}, ids)
+}
+
+func TestTagIdCreationGivesUp(t *testing.T) {
+	local, err := NewLocalStore(&LocalConfig{}, encoder.JSON{})
+	require.NoError(t, err)
+
+	var (
+		evicting = &flushingAdder{LocalStore: local, failures: math.MaxInt64}
+		ts       = &TagSet{store: evicting, names: []string{"people"}}
+	)
+	_, err = ts.TagIds()
+	require.EqualError(t, err, "gocache: failed to create an id for tag people after 3 attempts")
+	require.EqualValues(t, tagIdCreationRetries, evicting.adds.Load())
+}
+
+// flushingAdder behaves as if the first tag ids it is asked to add were added and flushed by concurrent processes
+type flushingAdder struct {
+	*LocalStore
+	failures int64
+	adds     atomic.Int64
+	forevers atomic.Int64
+}
+
+func (s *flushingAdder) addForever(key string, value interface{}) (bool, error) {
+	if s.adds.Add(1) <= s.failures {
+		return false, nil
+	}
+
+	return s.LocalStore.addForever(key, value)
+}
+
+func (s *flushingAdder) Forever(key string, value interface{}) error {
+	s.forevers.Add(1)
+
+	return s.LocalStore.Forever(key, value)
+}
+
+func TestTaggedCacheBatchResolvesTagIdsOnce(t *testing.T) {
+	local, err := NewLocalStore(&LocalConfig{}, encoder.JSON{})
+	require.NoError(t, err)
+
+	var (
+		counting = &getStringCounter{LocalStore: local}
+		tagged   = &taggedCache{store: counting, tags: &TagSet{store: counting, names: []string{"people", "authors"}}}
+	)
+	_, err = tagged.TagSet().TagIds()
+	require.NoError(t, err)
+
+	counting.calls.Store(0)
+	require.NoError(t, tagged.PutMany(
+		Entry{Key: "jane", Value: "jane", Duration: time.Minute},
+		Entry{Key: "john", Value: "john", Duration: time.Minute},
+		Entry{Key: "joe", Value: "joe", Duration: time.Minute},
+	))
+	require.EqualValues(t, 2, counting.calls.Load())
+
+	counting.calls.Store(0)
+	items, err := tagged.Many("jane", "john", "joe")
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	require.EqualValues(t, 2, counting.calls.Load())
+
+	counting.calls.Store(0)
+	require.NoError(t, tagged.ForgetMany("jane", "john", "joe"))
+	require.EqualValues(t, 2, counting.calls.Load())
+}
+
+// getStringCounter counts the GetString calls made against a LocalStore, i.e. the tag id lookups
+type getStringCounter struct {
+	*LocalStore
+	calls atomic.Int64
+}
+
+func (s *getStringCounter) GetString(key string) (string, error) {
+	s.calls.Add(1)
+
+	return s.LocalStore.GetString(key)
+}
+
 func TestTagExists(t *testing.T) {
 	for _, e := range encoders {
 		for _, d := range drivers(t) {